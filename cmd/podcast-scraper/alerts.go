@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/alert"
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// The Postgres store keeps alert state across processes
+var _ alert.State = (*repository.PodcastRepository)(nil)
+
+// newNotifier returns the notifier for the configured alert sinks, or nil
+// when none is configured. Sent alerts are remembered in the store when it
+// supports it, so the cooldown holds across CronJob runs.
+func newNotifier(config *Config, store repository.Store) *alert.Notifier {
+	state, ok := store.(alert.State)
+	if !ok {
+		state = alert.NewMemoryState()
+	}
+	return alert.New(config.Alerts, state)
+}
+
+// runOutcome is what a finished run tells the notifier
+type runOutcome struct {
+	run         *scrapers.ScraperRun
+	show        string
+	err         error // why the run failed, if it did
+	driftFields int
+	heldRows    int // metric rows kept out by data quality rules
+	anomalies   []*scrapers.MetricAnomaly
+}
+
+// maxAnomalyDetails is how many anomalies an alert describes
+const maxAnomalyDetails = 5
+
+// alerts returns the alerts a run raises, and the triggers it shows have
+// cleared. A failed run clears nothing, as it says nothing about its data.
+func (o runOutcome) alerts() ([]*alert.Alert, []alert.Trigger) {
+	run := o.run
+	newAlert := func(trigger alert.Trigger, summary string) *alert.Alert {
+		a := &alert.Alert{
+			Trigger:  trigger,
+			Platform: run.Platform,
+			Show:     o.show,
+			RunID:    run.ID,
+			Status:   run.Status,
+			Summary:  summary,
+		}
+		if run.ErrorMessage != nil {
+			a.Detail = *run.ErrorMessage
+		}
+		return a
+	}
+
+	switch run.Status {
+	case scrapers.RunStatusAuthFailed:
+		return []*alert.Alert{newAlert(alert.TriggerAuthExpired, "credentials were rejected")}, nil
+	case scrapers.RunStatusFailed:
+		if class, _ := scrapers.ClassifyError(o.err); class == scrapers.ErrorClassAuth {
+			return []*alert.Alert{newAlert(alert.TriggerAuthExpired, "credentials were rejected during the run")}, nil
+		}
+		return []*alert.Alert{newAlert(alert.TriggerRunFailed, "run failed")}, nil
+	case scrapers.RunStatusCompleted, scrapers.RunStatusPartial:
+	default:
+		return nil, nil
+	}
+
+	var fired []*alert.Alert
+	if run.Status == scrapers.RunStatusPartial {
+		fired = append(fired, newAlert(alert.TriggerRunPartial, "run finished with item errors or schema drift"))
+	}
+	if run.MetricsCollected == 0 {
+		fired = append(fired, newAlert(alert.TriggerZeroMetrics, fmt.Sprintf("collected no metrics from %d episodes", run.EpisodesProcessed)))
+	}
+
+	// Suspicious data raises a single alert, so neither cause suppresses the other
+	var anomalies []string
+	if o.driftFields > 0 {
+		anomalies = append(anomalies, fmt.Sprintf("responses drifted from their expected shape in %d fields", o.driftFields))
+	}
+	if o.heldRows > 0 {
+		anomalies = append(anomalies, fmt.Sprintf("data quality rules held back %d metric rows", o.heldRows))
+	}
+	if len(o.anomalies) > 0 {
+		anomalies = append(anomalies, fmt.Sprintf("%d metric values stood out from their baseline", len(o.anomalies)))
+	}
+	if len(anomalies) > 0 {
+		a := newAlert(alert.TriggerAnomaly, strings.Join(anomalies, "; "))
+		a.Detail = strings.TrimSpace(a.Detail + "\n" + anomalyDetails(o.anomalies))
+		fired = append(fired, a)
+	}
+
+	firing := make(map[alert.Trigger]bool, len(fired))
+	for _, a := range fired {
+		firing[a.Trigger] = true
+	}
+	var cleared []alert.Trigger
+	for _, t := range alert.AllTriggers {
+		if !firing[t] {
+			cleared = append(cleared, t)
+		}
+	}
+	return fired, cleared
+}
+
+// anomalyDetails lists the strongest anomalies, one per line
+func anomalyDetails(anomalies []*scrapers.MetricAnomaly) string {
+	sorted := append([]*scrapers.MetricAnomaly(nil), anomalies...)
+	sort.SliceStable(sorted, func(i, j int) bool { return math.Abs(sorted[i].Score) > math.Abs(sorted[j].Score) })
+
+	var lines []string
+	for i, a := range sorted {
+		if i == maxAnomalyDetails {
+			lines = append(lines, fmt.Sprintf("and %d more", len(sorted)-i))
+			break
+		}
+		lines = append(lines, describeAnomaly(a))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// notifyRun sends the alerts raised by a finished run and resolves those it
+// shows have cleared. Failures to alert are logged, never returned.
+func notifyRun(ctx context.Context, notifier *alert.Notifier, outcome runOutcome) {
+	if notifier == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	fired, cleared := outcome.alerts()
+	for _, a := range fired {
+		if err := notifier.Notify(ctx, a); err != nil {
+			slog.ErrorContext(ctx, "Failed to send alert", "alert", a.Key(), logging.KeyError, err)
+		}
+	}
+	if err := notifier.Resolve(ctx, outcome.run.Platform, outcome.show, cleared...); err != nil {
+		slog.ErrorContext(ctx, "Failed to resolve alerts", logging.KeyError, err)
+	}
+}
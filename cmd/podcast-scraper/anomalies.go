@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/anomaly"
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// anomalyStore reads metric history and records the anomalies found in it
+type anomalyStore interface {
+	MetricHistory(ctx context.Context, platform scrapers.Platform, podcastID int64, since, until time.Time) ([]*repository.MetricPoint, error)
+	ReplaceMetricAnomalies(ctx context.Context, platform scrapers.Platform, podcastID int64, from, to time.Time, anomalies []*scrapers.MetricAnomaly) error
+}
+
+var _ anomalyStore = (*repository.PodcastRepository)(nil)
+
+// metricSpan is the range of metric dates a run wrote
+type metricSpan struct {
+	from, to time.Time
+}
+
+// add widens the span to cover date; zero dates are ignored
+func (s *metricSpan) add(date time.Time) {
+	if date.IsZero() {
+		return
+	}
+	if s.from.IsZero() || date.Before(s.from) {
+		s.from = date
+	}
+	if date.After(s.to) {
+		s.to = date
+	}
+}
+
+func (s *metricSpan) empty() bool {
+	return s.from.IsZero()
+}
+
+// seriesKey identifies one metric of one episode, or of the show when
+// episodeID is 0
+type seriesKey struct {
+	podcastID int64
+	episodeID int64
+	metric    string
+}
+
+// detectAnomalies compares a platform's metric dates from to to with their
+// baselines and replaces the anomalies recorded for those dates with what it
+// finds. A podcastID of 0 covers every podcast on the platform; runID is 0
+// outside a collection.
+func detectAnomalies(ctx context.Context, store anomalyStore, cfg anomaly.Config, platform scrapers.Platform, podcastID, runID int64, from, to time.Time) ([]*scrapers.MetricAnomaly, error) {
+	from, to = truncateDay(from), truncateDay(to)
+
+	points, err := store.MetricHistory(ctx, platform, podcastID, from.AddDate(0, 0, -cfg.WindowDays), to)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []seriesKey
+	series := make(map[seriesKey][]anomaly.Point)
+	for _, p := range points {
+		key := seriesKey{podcastID: p.PodcastID, episodeID: p.EpisodeID, metric: p.Metric}
+		if _, ok := series[key]; !ok {
+			keys = append(keys, key)
+		}
+		series[key] = append(series[key], anomaly.Point{Date: p.Date, Value: p.Value})
+	}
+
+	now := time.Now()
+	var found []*scrapers.MetricAnomaly
+	for _, key := range keys {
+		for _, f := range cfg.Detect(series[key], from, to) {
+			a := &scrapers.MetricAnomaly{
+				RunID:      runID,
+				Platform:   platform,
+				PodcastID:  key.podcastID,
+				Metric:     key.metric,
+				MetricDate: f.Date,
+				Value:      f.Value,
+				Baseline:   f.Median,
+				MAD:        f.MAD,
+				Score:      f.Score,
+				Direction:  string(f.Direction),
+				Method:     string(cfg.Method),
+				DetectedAt: now,
+			}
+			if key.episodeID != 0 {
+				id := key.episodeID
+				a.EpisodeID = &id
+			}
+			found = append(found, a)
+		}
+	}
+
+	if err := store.ReplaceMetricAnomalies(ctx, platform, podcastID, from, to, found); err != nil {
+		return nil, err
+	}
+	for _, a := range found {
+		promMetrics.ObserveAnomaly(a)
+	}
+	return found, nil
+}
+
+// describeAnomaly is a one-line description of a for logs and alerts
+func describeAnomaly(a *scrapers.MetricAnomaly) string {
+	subject := "show"
+	switch {
+	case a.EpisodeTitle != "":
+		subject = fmt.Sprintf("episode %q", a.EpisodeTitle)
+	case a.EpisodeID != nil:
+		subject = fmt.Sprintf("episode %d", *a.EpisodeID)
+	}
+
+	verb := "spiked to"
+	if a.Direction == string(anomaly.DirectionDrop) {
+		verb = "dropped to"
+	}
+
+	return fmt.Sprintf("%s %s %s %.0f on %s (baseline %.0f)",
+		subject, a.Metric, verb, a.Value, a.MetricDate.Format("2006-01-02"), a.Baseline)
+}
+
+// detectRunAnomalies detects anomalies on the metric dates a collection
+// wrote for a podcast. Detection problems are logged and never fail the run.
+func detectRunAnomalies(ctx context.Context, store repository.Store, cfg *anomaly.Config, run *scrapers.ScraperRun, podcastID int64, span metricSpan, episodes []*scrapers.Episode) []*scrapers.MetricAnomaly {
+	s, ok := store.(anomalyStore)
+	if cfg == nil || !ok || span.empty() {
+		return nil
+	}
+
+	found, err := detectAnomalies(ctx, s, *cfg, run.Platform, podcastID, run.ID, span.from, span.to)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to detect metric anomalies", logging.KeyError, err)
+		return nil
+	}
+
+	titles := make(map[int64]string, len(episodes))
+	for _, e := range episodes {
+		titles[e.ID] = e.EpisodeTitle
+	}
+	for _, a := range found {
+		if a.EpisodeID != nil {
+			a.EpisodeTitle = titles[*a.EpisodeID]
+		}
+		slog.WarnContext(ctx, "Metric anomaly", "anomaly", describeAnomaly(a), "score", a.Score)
+	}
+	return found
+}
+
+// runAnomalies implements `podcast-scraper anomalies`
+func runAnomalies(ctx context.Context, config *Config, args []string) error {
+	fs := flag.NewFlagSet("anomalies", flag.ContinueOnError)
+	var f commandFlags
+	f.registerPlatform(fs)
+	f.registerDateRange(fs)
+	detect := fs.Bool("detect", false, "detect anomalies for the date range again before listing them, e.g. after a backfill")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := f.apply(config); err != nil {
+		return err
+	}
+
+	startDate, endDate, err := f.dateRange(config.LookbackDays)
+	if err != nil {
+		return err
+	}
+
+	db, err := connectDatabase(config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+	repo := repository.NewPodcastRepository(db)
+
+	if *detect {
+		cfg := anomaly.DefaultConfig()
+		if config.Anomalies != nil {
+			cfg = *config.Anomalies
+		}
+		for _, platform := range scrapers.AllPlatforms {
+			if !config.wantsPlatform(platform) {
+				continue
+			}
+			found, err := detectAnomalies(ctx, repo, cfg, platform, 0, 0, startDate, endDate)
+			if err != nil {
+				return fmt.Errorf("failed to detect %s anomalies: %w", platform, err)
+			}
+			slog.InfoContext(ctx, "Detected metric anomalies", logging.KeyPlatform, platform, "anomalies", len(found))
+		}
+	}
+
+	anomalies, err := repo.ListMetricAnomalies(ctx, config.Platforms, startDate, endDate)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tPLATFORM\tEPISODE\tMETRIC\tDIRECTION\tVALUE\tBASELINE\tSCORE")
+	for _, a := range anomalies {
+		episode := "-"
+		if a.EpisodeID != nil {
+			episode = a.EpisodeTitle
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%.0f\t%.0f\t%.1f\n",
+			a.MetricDate.Format("2006-01-02"),
+			a.Platform,
+			episode,
+			a.Metric,
+			a.Direction,
+			a.Value,
+			a.Baseline,
+			a.Score,
+		)
+	}
+
+	return w.Flush()
+}
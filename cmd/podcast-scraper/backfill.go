@@ -0,0 +1,391 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/quality"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// errQuotaExhausted stops a platform's backfill before its quota runs out
+var errQuotaExhausted = errors.New("quota reserve reached")
+
+// checkpointStore persists backfill progress
+type checkpointStore interface {
+	CompletedCheckpoints(ctx context.Context, platform scrapers.Platform, podcastID int64, startDate, endDate time.Time) (map[repository.CheckpointKey]bool, error)
+	SaveCheckpoint(ctx context.Context, cp *repository.BackfillCheckpoint) error
+}
+
+// BackfillOptions selects the history a backfill fetches
+type BackfillOptions struct {
+	ShowName  string
+	StartDate time.Time
+	EndDate   time.Time
+
+	// WindowDays overrides the per-platform window size when positive
+	WindowDays int
+
+	// QuotaReserve is the quota left untouched for regular collections
+	QuotaReserve int64
+
+	// BatchSize and FlushInterval control how episode metrics are buffered
+	// before a batched write
+	BatchSize     int
+	FlushInterval time.Duration
+
+	// HeartbeatInterval is how often the run's row is marked alive
+	HeartbeatInterval time.Duration
+
+	// Quality checks metric rows before they are written; nil checks nothing
+	Quality *quality.Policy
+}
+
+// dateWindow is an inclusive range of metric dates
+type dateWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// splitDateRange splits [start, end] into consecutive windows of at most days days
+func splitDateRange(start, end time.Time, days int) []dateWindow {
+	if days <= 0 {
+		days = 1
+	}
+
+	start = truncateDay(start)
+	end = truncateDay(end)
+
+	var windows []dateWindow
+	for ws := start; !ws.After(end); ws = ws.AddDate(0, 0, days) {
+		we := ws.AddDate(0, 0, days-1)
+		if we.After(end) {
+			we = end
+		}
+		windows = append(windows, dateWindow{Start: ws, End: we})
+	}
+	return windows
+}
+
+// withWindow returns a context whose log records name window
+func withWindow(ctx context.Context, window dateWindow) context.Context {
+	return logging.With(ctx, "window_start", window.Start.Format("2006-01-02"), "window_end", window.End.Format("2006-01-02"))
+}
+
+// truncateDay drops the time of day, keeping the calendar date
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// pacer spaces out requests to stay under a requests-per-minute limit
+type pacer struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func newPacer(requestsPerMinute int) *pacer {
+	p := &pacer{}
+	if requestsPerMinute > 0 {
+		p.interval = time.Minute / time.Duration(requestsPerMinute)
+	}
+	return p
+}
+
+// wait blocks until the next request is allowed
+func (p *pacer) wait(ctx context.Context) error {
+	if delay := time.Until(p.next); delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	p.next = time.Now().Add(p.interval)
+	return nil
+}
+
+// Backfiller fetches historical metrics in per-platform sized windows and
+// checkpoints each window, so a killed backfill resumes where it stopped
+type Backfiller struct {
+	store       repository.Store
+	checkpoints checkpointStore
+	scrapers    []scrapers.Scraper
+}
+
+// NewBackfiller creates a new backfiller. checkpoints may be nil, in which
+// case every window is fetched again on each backfill.
+func NewBackfiller(store repository.Store, checkpoints checkpointStore, scrapers []scrapers.Scraper) *Backfiller {
+	return &Backfiller{
+		store:       store,
+		checkpoints: checkpoints,
+		scrapers:    scrapers,
+	}
+}
+
+// Backfill runs the backfill for every scraper
+func (b *Backfiller) Backfill(ctx context.Context, opts BackfillOptions) error {
+	for _, scraper := range b.scrapers {
+		if err := b.backfillPlatform(ctx, scraper, opts); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.ErrorContext(ctx, "Backfill failed", logging.KeyPlatform, scraper.GetPlatform(), logging.KeyError, err)
+			// Continue with other platforms even if one fails
+			continue
+		}
+	}
+
+	return nil
+}
+
+// backfillPlatform backfills a single platform
+func (b *Backfiller) backfillPlatform(ctx context.Context, scraper scrapers.Scraper, opts BackfillOptions) error {
+	platform := scraper.GetPlatform()
+	ctx = logging.With(ctx, logging.KeyPlatform, platform, logging.KeyShow, opts.ShowName)
+
+	limits := scrapers.DefaultBackfillLimits[platform]
+	if opts.WindowDays > 0 {
+		limits.WindowDays = opts.WindowDays
+	}
+	windows := splitDateRange(opts.StartDate, opts.EndDate, limits.WindowDays)
+	pace := newPacer(limits.RequestsPerMinute)
+
+	slog.InfoContext(ctx, "Starting backfill", "windows", len(windows), "window_days", limits.WindowDays)
+
+	ctx, span := startRunSpan(ctx, "backfill", platform, opts.ShowName)
+
+	run := &scrapers.ScraperRun{
+		Platform:     platform,
+		RunStartedAt: time.Now(),
+		Status:       scrapers.RunStatusRunning,
+	}
+	defer endRunSpan(span, run)
+
+	runID, err := b.store.RecordScraperRun(ctx, run)
+	if err != nil {
+		return fmt.Errorf("failed to record scraper run: %w", err)
+	}
+
+	// Archived responses are filed under the run, and responses are checked
+	// against their declared shapes
+	ctx = scrapers.WithRunID(ctx, runID)
+	ctx = logging.With(ctx, logging.KeyRunID, runID)
+	span.SetAttributes(scrapers.AttrRunID.Int64(runID))
+	drift := newRunDrift(b.store, runID)
+	ctx = scrapers.WithDriftReporter(ctx, drift)
+	heartbeat := startHeartbeat(ctx, b.store, runID, opts.HeartbeatInterval)
+	checks := newRunQuality(opts.Quality, b.store, runID, platform)
+	quota := newRunQuota(scraper, b.store, runID)
+	ledger := newRunLedger(b.store, runID, platform)
+	ctx = scrapers.WithArchiveFailureReporter(ctx, ledger)
+
+	defer func() {
+		heartbeat.end()
+
+		completedAt := time.Now()
+		run.RunCompletedAt = &completedAt
+		drift.flag(run)
+		checks.flag(run)
+		quota.save(context.WithoutCancel(ctx))
+
+		if err := b.store.UpdateScraperRun(context.WithoutCancel(ctx), runID, run); err != nil {
+			slog.ErrorContext(ctx, "Failed to update scraper run", logging.KeyError, err)
+		}
+		observeRun(scraper, run)
+	}()
+
+	fail := func(err error) error {
+		run.Status = scrapers.RunStatusFailed
+		errMsg := err.Error()
+		run.ErrorMessage = &errMsg
+		return err
+	}
+
+	podcast, err := scraper.FetchPodcastInfo(ctx, opts.ShowName)
+	if err != nil {
+		return fail(fmt.Errorf("failed to fetch podcast info: %w", err))
+	}
+
+	podcastID, err := b.store.UpsertPodcast(ctx, podcast)
+	if err != nil {
+		return fail(fmt.Errorf("failed to upsert podcast: %w", err))
+	}
+	podcast.ID = podcastID
+
+	episodes, err := scraper.FetchEpisodes(ctx, podcast)
+	if err != nil {
+		return fail(fmt.Errorf("failed to fetch episodes: %w", err))
+	}
+
+	done := map[repository.CheckpointKey]bool{}
+	if b.checkpoints != nil {
+		done, err = b.checkpoints.CompletedCheckpoints(ctx, platform, podcastID, windows[0].Start, windows[len(windows)-1].End)
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	// checkQuota leaves the reserve for regular collections, counting what
+	// every run used today; the remaining windows stay unchecked and are
+	// picked up by the next backfill
+	checkQuota := func(ctx context.Context) error {
+		if remaining, ok := quota.remaining(ctx); ok && remaining <= opts.QuotaReserve {
+			return errQuotaExhausted
+		}
+		return nil
+	}
+
+	// Flush on early return too, so windows already fetched are not lost
+	batch := newMetricsBatch(b.store, opts.BatchSize, opts.FlushInterval)
+	defer batch.flush(context.WithoutCancel(ctx))
+
+	skipped := 0
+
+	for _, episode := range episodes {
+		ctx := logging.With(ctx, logging.KeyEpisodeID, episode.PlatformEpisodeID)
+		episode.PodcastID = podcastID
+
+		episodeID, err := b.store.UpsertEpisode(ctx, episode)
+		if err != nil {
+			ledger.record(ctx, episode, scrapers.StageUpsertEpisode, err)
+			continue
+		}
+		episode.ID = episodeID
+
+		for _, window := range windows {
+			// Nothing to fetch before the episode was published
+			if !episode.PublishDate.IsZero() && window.End.Before(truncateDay(episode.PublishDate)) {
+				continue
+			}
+
+			cp := &repository.BackfillCheckpoint{
+				Platform:    platform,
+				PodcastID:   podcastID,
+				EpisodeID:   episodeID,
+				WindowStart: window.Start,
+				WindowEnd:   window.End,
+				RunID:       runID,
+			}
+			if done[cp.Key()] {
+				skipped++
+				continue
+			}
+			ctx := withWindow(ctx, window)
+
+			if err := checkQuota(ctx); err != nil {
+				batch.flush(ctx)
+				return fail(fmt.Errorf("stopped after %d metrics: %w; rerun to resume", run.MetricsCollected, err))
+			}
+			if err := pace.wait(ctx); err != nil {
+				return fail(err)
+			}
+
+			metrics, err := scraper.FetchEpisodeMetrics(ctx, episode, window.Start, window.End)
+			if err != nil {
+				ledger.record(ctx, episode, scrapers.StageFetchMetrics, err)
+				b.saveCheckpoint(ctx, cp, err)
+				continue
+			}
+			metrics = checks.episodeMetrics(ctx, episode, metrics)
+
+			// The checkpoint is saved once the window's rows are written
+			for _, metric := range metrics {
+				metric.EpisodeID = episodeID
+				metric.RunID = runID
+			}
+			batch.add(ctx, metrics, func(ctx context.Context, written int, errs []error) {
+				cp.MetricsCollected += written
+				run.MetricsCollected += written
+				promMetrics.AddRowsUpserted(platform, "episode_metrics", written)
+
+				var storeErr error
+				for _, err := range errs {
+					ledger.record(ctx, episode, scrapers.StageStoreMetric, err)
+					storeErr = err
+				}
+				b.saveCheckpoint(ctx, cp, storeErr)
+			})
+		}
+
+		run.EpisodesProcessed++
+		heartbeat.progress(run)
+	}
+
+	batch.flush(ctx)
+
+	// Show-level metrics are checkpointed with episode ID 0
+	for _, window := range windows {
+		cp := &repository.BackfillCheckpoint{
+			Platform:    platform,
+			PodcastID:   podcastID,
+			WindowStart: window.Start,
+			WindowEnd:   window.End,
+			RunID:       runID,
+		}
+		if done[cp.Key()] {
+			skipped++
+			continue
+		}
+		ctx := withWindow(ctx, window)
+
+		if err := checkQuota(ctx); err != nil {
+			return fail(fmt.Errorf("stopped after %d metrics: %w; rerun to resume", run.MetricsCollected, err))
+		}
+		if err := pace.wait(ctx); err != nil {
+			return fail(err)
+		}
+
+		showMetrics, err := scraper.FetchShowMetrics(ctx, podcast, window.Start, window.End)
+		if err != nil {
+			ledger.record(ctx, nil, scrapers.StageFetchShowMetrics, err)
+			b.saveCheckpoint(ctx, cp, err)
+			continue
+		}
+
+		showMetrics = checks.showMetrics(ctx, podcastID, showMetrics)
+
+		var storeErr error
+		for _, metric := range showMetrics {
+			metric.PodcastID = podcastID
+			if err := b.store.UpsertShowMetrics(ctx, metric); err != nil {
+				ledger.record(ctx, nil, scrapers.StageStoreShowMetric, err)
+				storeErr = err
+				continue
+			}
+			cp.MetricsCollected++
+			promMetrics.AddRowsUpserted(platform, "show_metrics", 1)
+		}
+
+		b.saveCheckpoint(ctx, cp, storeErr)
+	}
+
+	ledger.finish(run)
+	slog.InfoContext(ctx, "Completed backfill", "status", run.Status, "episodes", run.EpisodesProcessed,
+		"metrics", run.MetricsCollected, "windows_done", skipped, "errors", ledger.count)
+
+	return nil
+}
+
+// saveCheckpoint records a window as completed, or as failed when err is set
+// so it is retried by the next backfill
+func (b *Backfiller) saveCheckpoint(ctx context.Context, cp *repository.BackfillCheckpoint, err error) {
+	if b.checkpoints == nil {
+		return
+	}
+
+	cp.Status = scrapers.RunStatusCompleted
+	if err != nil {
+		cp.Status = scrapers.RunStatusFailed
+		errMsg := err.Error()
+		cp.ErrorMessage = &errMsg
+	}
+
+	if err := b.checkpoints.SaveCheckpoint(ctx, cp); err != nil {
+		slog.ErrorContext(ctx, "Failed to save backfill checkpoint", logging.KeyError, err)
+	}
+}
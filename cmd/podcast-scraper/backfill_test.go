@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// memoryCheckpoints keeps backfill checkpoints in a map
+type memoryCheckpoints struct {
+	checkpoints map[repository.CheckpointKey]repository.BackfillCheckpoint
+}
+
+func newMemoryCheckpoints() *memoryCheckpoints {
+	return &memoryCheckpoints{checkpoints: make(map[repository.CheckpointKey]repository.BackfillCheckpoint)}
+}
+
+func (m *memoryCheckpoints) CompletedCheckpoints(ctx context.Context, platform scrapers.Platform, podcastID int64, startDate, endDate time.Time) (map[repository.CheckpointKey]bool, error) {
+	done := make(map[repository.CheckpointKey]bool)
+	for key, cp := range m.checkpoints {
+		if cp.Platform == platform && cp.PodcastID == podcastID && cp.Status == scrapers.RunStatusCompleted {
+			done[key] = true
+		}
+	}
+	return done, nil
+}
+
+func (m *memoryCheckpoints) SaveCheckpoint(ctx context.Context, cp *repository.BackfillCheckpoint) error {
+	m.checkpoints[cp.Key()] = *cp
+	return nil
+}
+
+// quotaScraper is a fakeScraper whose metrics requests cost quota units
+type quotaScraper struct {
+	*fakeScraper
+	daily, used, cost int64
+}
+
+func (q *quotaScraper) DailyQuota() int64 { return q.daily }
+func (q *quotaScraper) QuotaUsed() int64  { return q.used }
+
+func (q *quotaScraper) FetchEpisodeMetrics(ctx context.Context, episode *scrapers.Episode, startDate, endDate time.Time) ([]*scrapers.EpisodeMetrics, error) {
+	q.used += q.cost
+	return q.fakeScraper.FetchEpisodeMetrics(ctx, episode, startDate, endDate)
+}
+
+// withoutPacing lifts the Spotify request rate limit for the test
+func withoutPacing(t *testing.T) {
+	t.Helper()
+	limits := scrapers.DefaultBackfillLimits[scrapers.PlatformSpotify]
+	scrapers.DefaultBackfillLimits[scrapers.PlatformSpotify] = scrapers.BackfillLimits{WindowDays: limits.WindowDays}
+	t.Cleanup(func() { scrapers.DefaultBackfillLimits[scrapers.PlatformSpotify] = limits })
+}
+
+func testBackfillOptions() BackfillOptions {
+	return BackfillOptions{
+		ShowName:   "Test Show",
+		StartDate:  day(2026, 2, 1),
+		EndDate:    day(2026, 2, 4),
+		WindowDays: 2,
+	}
+}
+
+func TestSplitDateRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end time.Time
+		days       int
+		want       []dateWindow
+	}{
+		{
+			name:  "even split",
+			start: day(2026, 1, 1), end: day(2026, 1, 4), days: 2,
+			want: []dateWindow{{day(2026, 1, 1), day(2026, 1, 2)}, {day(2026, 1, 3), day(2026, 1, 4)}},
+		},
+		{
+			name:  "last window is shorter",
+			start: day(2026, 1, 1), end: day(2026, 1, 5), days: 2,
+			want: []dateWindow{{day(2026, 1, 1), day(2026, 1, 2)}, {day(2026, 1, 3), day(2026, 1, 4)}, {day(2026, 1, 5), day(2026, 1, 5)}},
+		},
+		{
+			name:  "times of day are dropped",
+			start: day(2026, 1, 1).Add(15 * time.Hour), end: day(2026, 1, 2).Add(3 * time.Hour), days: 30,
+			want: []dateWindow{{day(2026, 1, 1), day(2026, 1, 2)}},
+		},
+		{
+			name:  "non-positive window size means single days",
+			start: day(2026, 1, 1), end: day(2026, 1, 2), days: 0,
+			want: []dateWindow{{day(2026, 1, 1), day(2026, 1, 1)}, {day(2026, 1, 2), day(2026, 1, 2)}},
+		},
+		{
+			name:  "empty range",
+			start: day(2026, 1, 2), end: day(2026, 1, 1), days: 7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitDateRange(tt.start, tt.end, tt.days); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitDateRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackfillResumesFromCheckpoints(t *testing.T) {
+	withoutPacing(t)
+
+	store := repository.NewMemoryStore()
+	checkpoints := newMemoryCheckpoints()
+	scraper := newFakeScraper()
+	scraper.fetchErrs = map[string]error{"ep-2": errors.New("rate limited")}
+
+	backfiller := NewBackfiller(store, checkpoints, []scrapers.Scraper{scraper})
+	if err := backfiller.Backfill(context.Background(), testBackfillOptions()); err != nil {
+		t.Fatalf("Backfill() error = %v", err)
+	}
+	if got := len(scraper.fetches); got != 6 {
+		t.Fatalf("first backfill made %d requests, want 6: %v", got, scraper.fetches)
+	}
+
+	// The rerun only fetches the windows that failed
+	scraper.fetchErrs = nil
+	scraper.fetches = nil
+	if err := backfiller.Backfill(context.Background(), testBackfillOptions()); err != nil {
+		t.Fatalf("Backfill() error = %v", err)
+	}
+	want := []string{"ep-2 2026-02-01 2026-02-02", "ep-2 2026-02-03 2026-02-04"}
+	if !reflect.DeepEqual(scraper.fetches, want) {
+		t.Errorf("second backfill fetched %v, want %v", scraper.fetches, want)
+	}
+	for key, cp := range checkpoints.checkpoints {
+		if cp.Status != scrapers.RunStatusCompleted {
+			t.Errorf("checkpoint %v status = %s, want completed", key, cp.Status)
+		}
+	}
+}
+
+func TestBackfillStopsAtQuotaReserve(t *testing.T) {
+	withoutPacing(t)
+
+	store := repository.NewMemoryStore()
+	scraper := &quotaScraper{fakeScraper: newFakeScraper(), daily: 10, cost: 4}
+
+	opts := testBackfillOptions()
+	opts.QuotaReserve = 3
+	backfiller := NewBackfiller(store, nil, []scrapers.Scraper{scraper})
+	if err := backfiller.Backfill(context.Background(), opts); err != nil {
+		t.Fatalf("Backfill() error = %v", err)
+	}
+
+	// Two requests leave 2 units, below the reserve of 3
+	if got := len(scraper.fetches); got != 2 {
+		t.Errorf("backfill made %d requests, want 2: %v", got, scraper.fetches)
+	}
+
+	runs := store.Runs()
+	if len(runs) != 1 {
+		t.Fatalf("runs = %d, want 1", len(runs))
+	}
+	run := runs[0]
+	if run.Status != scrapers.RunStatusFailed {
+		t.Errorf("run status = %s, want failed", run.Status)
+	}
+	if run.ErrorMessage == nil || !strings.Contains(*run.ErrorMessage, errQuotaExhausted.Error()) {
+		t.Errorf("run error = %v, want %q", run.ErrorMessage, errQuotaExhausted)
+	}
+	// Rows fetched before the stop are still written
+	if got := len(store.EpisodeMetrics()); got != 2 {
+		t.Errorf("episode metrics = %d, want 2", got)
+	}
+}
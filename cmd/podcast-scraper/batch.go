@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// metricsGroup is a set of rows added together, typically one episode's
+// fetch, with the callback to run once they have been written
+type metricsGroup struct {
+	size int
+	done func(ctx context.Context, written int, errs []error)
+}
+
+// metricsBatch buffers episode metrics and writes them with a single batched
+// upsert once size rows are pending, or when rows are added after the oldest
+// pending row has waited interval. There is no timer: rows added last wait for
+// the caller's final flush. Callers learn the outcome of each group through
+// its callback, which runs on the goroutine that triggered the flush with the
+// flush's context.
+//
+// Backfill, import and reparse write through a batch. The collector writes
+// each episode in its own transaction and so upserts one episode's rows at a
+// time instead.
+type metricsBatch struct {
+	store    repository.Store
+	size     int
+	interval time.Duration
+
+	pending []*scrapers.EpisodeMetrics
+	groups  []metricsGroup
+	since   time.Time
+}
+
+// newMetricsBatch creates a batch. A size of 1 or less writes every group
+// immediately.
+func newMetricsBatch(store repository.Store, size int, interval time.Duration) *metricsBatch {
+	return &metricsBatch{
+		store:    store,
+		size:     size,
+		interval: interval,
+	}
+}
+
+// add queues metrics and flushes if the batch is full or has waited too long
+func (b *metricsBatch) add(ctx context.Context, metrics []*scrapers.EpisodeMetrics, done func(ctx context.Context, written int, errs []error)) {
+	if len(b.pending) == 0 {
+		b.since = time.Now()
+	}
+
+	b.pending = append(b.pending, metrics...)
+	b.groups = append(b.groups, metricsGroup{size: len(metrics), done: done})
+
+	if len(b.pending) >= b.size || (b.interval > 0 && time.Since(b.since) >= b.interval) {
+		b.flush(ctx)
+	}
+}
+
+// flush writes every pending row and reports the outcome to each group
+func (b *metricsBatch) flush(ctx context.Context) {
+	if len(b.groups) == 0 {
+		return
+	}
+
+	pending, groups := b.pending, b.groups
+	b.pending, b.groups = nil, nil
+
+	var rowErrs []error
+	if len(pending) > 0 {
+		rowErrs = b.store.UpsertEpisodeMetricsBatch(ctx, pending)
+	}
+
+	offset := 0
+	for _, g := range groups {
+		written := 0
+		var errs []error
+		for _, err := range rowErrs[offset : offset+g.size] {
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			written++
+		}
+		offset += g.size
+
+		g.done(ctx, written, errs)
+	}
+}
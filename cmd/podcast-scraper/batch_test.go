@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// batchStore counts batched writes and rejects rows with negative plays
+type batchStore struct {
+	*repository.MemoryStore
+	writes int
+	rows   int
+}
+
+func (s *batchStore) UpsertEpisodeMetricsBatch(ctx context.Context, metrics []*scrapers.EpisodeMetrics) []error {
+	s.writes++
+	rowErrs := make([]error, len(metrics))
+	for i, m := range metrics {
+		if m.Plays < 0 {
+			rowErrs[i] = errors.New("negative plays")
+			continue
+		}
+		s.rows++
+	}
+	return rowErrs
+}
+
+func metricRows(plays ...int64) []*scrapers.EpisodeMetrics {
+	var rows []*scrapers.EpisodeMetrics
+	for i, p := range plays {
+		rows = append(rows, &scrapers.EpisodeMetrics{EpisodeID: 1, MetricDate: day(2026, 1, i+1), Plays: p})
+	}
+	return rows
+}
+
+func TestMetricsBatchFlushesBySize(t *testing.T) {
+	store := &batchStore{MemoryStore: repository.NewMemoryStore()}
+	batch := newMetricsBatch(store, 3, 0)
+	ctx := context.Background()
+
+	var written []int
+	var failed int
+	done := func(ctx context.Context, n int, errs []error) {
+		written = append(written, n)
+		failed += len(errs)
+	}
+
+	batch.add(ctx, metricRows(1, 2), done)
+	if store.writes != 0 {
+		t.Fatalf("writes after 2 rows = %d, want 0", store.writes)
+	}
+
+	// The third row fills the batch; each group hears about its own rows
+	batch.add(ctx, metricRows(-1), done)
+	if store.writes != 1 {
+		t.Fatalf("writes after 3 rows = %d, want 1", store.writes)
+	}
+	if len(written) != 2 || written[0] != 2 || written[1] != 0 || failed != 1 {
+		t.Errorf("written = %v with %d failed, want [2 0] with 1 failed", written, failed)
+	}
+
+	batch.add(ctx, metricRows(4), done)
+	batch.flush(ctx)
+	batch.flush(ctx)
+	if store.writes != 2 || store.rows != 3 {
+		t.Errorf("after the final flush %d writes stored %d rows, want 2 writes of 3 rows", store.writes, store.rows)
+	}
+}
+
+func TestMetricsBatchFlushesByAge(t *testing.T) {
+	store := &batchStore{MemoryStore: repository.NewMemoryStore()}
+	batch := newMetricsBatch(store, 100, time.Millisecond)
+	ctx := context.Background()
+	done := func(ctx context.Context, n int, errs []error) {}
+
+	batch.add(ctx, metricRows(1), done)
+	time.Sleep(5 * time.Millisecond)
+	if store.writes != 0 {
+		t.Fatalf("writes before the next add = %d, want 0", store.writes)
+	}
+
+	batch.add(ctx, metricRows(2), done)
+	if store.writes != 1 {
+		t.Errorf("writes once the oldest row is due = %d, want 1", store.writes)
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/telemetry"
+)
+
+// errUnhealthy signals a command finished but found problems, so the
+// process should exit non-zero without printing an extra error
+var errUnhealthy = errors.New("unhealthy")
+
+// command is a podcast-scraper subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, config *Config, args []string) error
+}
+
+// commands lists every subcommand in the order shown by usage
+var commands = []*command{
+	{name: "collect", summary: "collect metrics for the lookback window or a date range", run: runCollect},
+	{name: "backfill", summary: "collect metrics for an explicit historical date range", run: runBackfill},
+	{name: "import", summary: "load episode metrics from a CSV file", run: runImport},
+	{name: "reparse", summary: "rebuild metrics from archived or stored responses with the current parsers", run: runReparse},
+	{name: "export", summary: "write episode metrics as CSV", run: runExport},
+	{name: "prune", summary: "apply data retention policies", run: runPrune},
+	{name: "doctor", summary: "check platform credentials", run: runDoctor},
+	{name: "migrate", summary: "manage database migrations", run: runMigrate},
+	{name: "runs list", summary: "list recent scraper runs", run: runRunsList},
+	{name: "runs show", summary: "show a scraper run and its per-item errors", run: runRunsShow},
+	{name: "restatements", summary: "show how much each platform revises metrics after the fact", run: runRestatements},
+	{name: "anomalies", summary: "list daily metrics that stood out from their baseline", run: runAnomalies},
+	{name: "serve", summary: "collect on a schedule until stopped", run: runServe},
+}
+
+// run parses global flags, dispatches to a subcommand and returns the exit code
+func run(ctx context.Context, args []string) int {
+	global := flag.NewFlagSet("podcast-scraper", flag.ContinueOnError)
+	configPath := global.String("config", "", "path to an env file with configuration (KEY=VALUE per line)")
+	logLevel := global.String("log-level", getEnv("LOG_LEVEL", "info"), "log level: debug, info, warn or error")
+	logFormat := global.String("log-format", getEnv("LOG_FORMAT", "json"), "log format: json or text")
+	global.Usage = func() { usage(global) }
+
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if err := setupLogging(*logFormat, *logLevel); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if *configPath != "" {
+		if err := loadEnvFile(*configPath); err != nil {
+			slog.Error("Failed to load config", logging.KeyError, err)
+			return 1
+		}
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		slog.Error("Invalid configuration", logging.KeyError, err)
+		return 1
+	}
+
+	cmd, cmdArgs, err := findCommand(global.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		usage(global)
+		return 2
+	}
+
+	shutdownTracing, err := telemetry.SetupTracing(ctx, config.Tracing)
+	if err != nil {
+		slog.Error("Failed to set up tracing", logging.KeyError, err)
+		return 1
+	}
+	defer func() {
+		// Flush spans even when the command was interrupted
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("Failed to flush traces", logging.KeyError, err)
+		}
+	}()
+
+	if err := cmd.run(ctx, config, cmdArgs); err != nil {
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errUnhealthy):
+			return 1
+		}
+		slog.ErrorContext(ctx, fmt.Sprintf("%s failed", cmd.name), logging.KeyError, err)
+		return 1
+	}
+
+	return 0
+}
+
+// findCommand resolves the subcommand named by the leading arguments. With no
+// arguments it falls back to RUN_MODE so existing deployments keep working.
+func findCommand(args []string) (*command, []string, error) {
+	if len(args) == 0 {
+		name := "collect"
+		if getEnv("RUN_MODE", "once") == "scheduled" {
+			name = "serve"
+		}
+		args = []string{name}
+	}
+
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) < len(words) {
+			continue
+		}
+		if strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("unknown command %q", strings.Join(args, " "))
+}
+
+// usage prints the global flags and available subcommands
+func usage(global *flag.FlagSet) {
+	out := global.Output()
+	fmt.Fprintln(out, "Usage: podcast-scraper [global flags] <command> [flags]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-13s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out, "\nGlobal flags:")
+	global.PrintDefaults()
+	fmt.Fprintln(out, "\nRun 'podcast-scraper <command> -h' for command flags.")
+}
+
+// setupLogging makes a redacting slog logger in the requested format and
+// level the default, which the standard logger also writes through
+func setupLogging(format, level string) error {
+	logger, err := logging.New(os.Stderr, format, level)
+	if err != nil {
+		return err
+	}
+
+	slog.SetDefault(logger)
+	return nil
+}
+
+// loadEnvFile sets environment variables from a KEY=VALUE file. Variables
+// already present in the environment take precedence over the file.
+func loadEnvFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNo)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// commandFlags holds the filter flags shared by subcommands. Each command
+// registers only the groups that make sense for it.
+type commandFlags struct {
+	platforms string
+	show      string
+	from      string
+	to        string
+	dryRun    bool
+}
+
+// registerPlatform adds the --platform flag
+func (f *commandFlags) registerPlatform(fs *flag.FlagSet) {
+	fs.StringVar(&f.platforms, "platform", "", "comma-separated platforms (default: all configured)")
+}
+
+// registerFilter adds the --platform and --show flags
+func (f *commandFlags) registerFilter(fs *flag.FlagSet) {
+	f.registerPlatform(fs)
+	fs.StringVar(&f.show, "show", "", "show name (default: SHOW_NAME)")
+}
+
+// registerDateRange adds the --from and --to flags
+func (f *commandFlags) registerDateRange(fs *flag.FlagSet) {
+	fs.StringVar(&f.from, "from", "", "start date YYYY-MM-DD (default: LOOKBACK_DAYS ago)")
+	fs.StringVar(&f.to, "to", "", "end date YYYY-MM-DD (default: today)")
+}
+
+// registerDryRun adds the --dry-run flag
+func (f *commandFlags) registerDryRun(fs *flag.FlagSet) {
+	fs.BoolVar(&f.dryRun, "dry-run", false, "report what would be written without writing")
+}
+
+// apply overlays the platform and show flags onto the configuration
+func (f *commandFlags) apply(config *Config) error {
+	if f.platforms != "" {
+		platforms, err := parsePlatforms(f.platforms)
+		if err != nil {
+			return fmt.Errorf("invalid --platform: %w", err)
+		}
+		config.Platforms = platforms
+	}
+	if f.show != "" {
+		config.ShowName = f.show
+	}
+	return nil
+}
+
+// dateRange returns the --from/--to range, defaulting to the trailing
+// lookbackDays ending now
+func (f *commandFlags) dateRange(lookbackDays int) (time.Time, time.Time, error) {
+	endDate := time.Now()
+	if f.to != "" {
+		t, err := time.Parse("2006-01-02", f.to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --to: %w", err)
+		}
+		endDate = t
+	}
+
+	startDate := endDate.AddDate(0, 0, -lookbackDays)
+	if f.from != "" {
+		t, err := time.Parse("2006-01-02", f.from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --from: %w", err)
+		}
+		startDate = t
+	}
+
+	if startDate.After(endDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--from %s is after --to %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	}
+
+	return startDate, endDate, nil
+}
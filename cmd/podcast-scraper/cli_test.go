@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFindCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		args     []string
+		wantName string
+		wantArgs []string
+	}{
+		{name: "no arguments collects once", wantName: "collect"},
+		{name: "no arguments serves when scheduled", env: "scheduled", wantName: "serve"},
+		{name: "flags follow the command", args: []string{"collect", "--from", "2026-01-01"}, wantName: "collect", wantArgs: []string{"--from", "2026-01-01"}},
+		{name: "two-word command", args: []string{"runs", "show", "42"}, wantName: "runs show", wantArgs: []string{"42"}},
+		{name: "second word of a two-word command", args: []string{"runs", "list"}, wantName: "runs list", wantArgs: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RUN_MODE", tt.env)
+
+			cmd, args, err := findCommand(tt.args)
+			if err != nil {
+				t.Fatalf("findCommand() error = %v", err)
+			}
+			if cmd.name != tt.wantName {
+				t.Errorf("command = %q, want %q", cmd.name, tt.wantName)
+			}
+			if len(args) != 0 || len(tt.wantArgs) != 0 {
+				if !reflect.DeepEqual(args, tt.wantArgs) {
+					t.Errorf("args = %q, want %q", args, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
+
+func TestFindCommandUnknown(t *testing.T) {
+	for _, args := range [][]string{{"scrape"}, {"runs"}, {"runs", "delete"}} {
+		if cmd, _, err := findCommand(args); err == nil {
+			t.Errorf("findCommand(%q) = %q, want an error", args, cmd.name)
+		}
+	}
+}
+
+func TestDateRange(t *testing.T) {
+	f := commandFlags{from: "2026-01-01", to: "2026-01-31"}
+	start, end, err := f.dateRange(30)
+	if err != nil {
+		t.Fatalf("dateRange() error = %v", err)
+	}
+	if !start.Equal(day(2026, 1, 1)) || !end.Equal(day(2026, 1, 31)) {
+		t.Errorf("dateRange() = %s to %s, want 2026-01-01 to 2026-01-31", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+
+	// Without --from the range reaches back lookbackDays from --to
+	f = commandFlags{to: "2026-01-31"}
+	start, _, err = f.dateRange(7)
+	if err != nil {
+		t.Fatalf("dateRange() error = %v", err)
+	}
+	if !start.Equal(day(2026, 1, 24)) {
+		t.Errorf("start = %s, want 2026-01-24", start.Format("2006-01-02"))
+	}
+
+	// Without either flag the range ends now
+	f = commandFlags{}
+	start, end, err = f.dateRange(7)
+	if err != nil {
+		t.Fatalf("dateRange() error = %v", err)
+	}
+	if time.Since(end) > time.Minute || !start.Equal(end.AddDate(0, 0, -7)) {
+		t.Errorf("dateRange() = %s to %s, want the last 7 days", start, end)
+	}
+}
+
+func TestDateRangeErrors(t *testing.T) {
+	for _, f := range []commandFlags{
+		{from: "01/01/2026"},
+		{to: "yesterday"},
+		{from: "2026-02-01", to: "2026-01-01"},
+	} {
+		if _, _, err := f.dateRange(30); err == nil {
+			t.Errorf("dateRange() with --from %q --to %q succeeded, want an error", f.from, f.to)
+		}
+	}
+}
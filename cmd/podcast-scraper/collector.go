@@ -0,0 +1,436 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/alert"
+	"github.com/soypete/eleduck-analytics-connector/internal/anomaly"
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/quality"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// runStager is implemented by stores that can hold a run's writes aside and
+// publish them atomically
+type runStager interface {
+	StageRun(ctx context.Context, runID int64) (repository.Store, error)
+	PublishRun(ctx context.Context, runID int64) error
+	DiscardRun(ctx context.Context, runID int64) error
+}
+
+// CollectOptions selects what a collection run fetches
+type CollectOptions struct {
+	ShowName  string
+	StartDate time.Time
+	EndDate   time.Time
+
+	// Incremental starts each episode at its high-water mark instead of StartDate
+	Incremental bool
+
+	// RestatementDays is how far before the high-water mark to refetch,
+	// picking up numbers a platform revised after the fact
+	RestatementDays int
+
+	// AdaptivePolling polls episodes without recent activity less often
+	AdaptivePolling bool
+
+	// Staged writes the run's metrics to staging tables and publishes them
+	// only if the whole run succeeds
+	Staged bool
+
+	// Lock is what to do when another process holds the run lock for the
+	// show and platform: lockSkip, lockWait for up to LockTimeout, or lockFail
+	Lock        string
+	LockTimeout time.Duration
+
+	// HeartbeatInterval is how often the run's row is marked alive
+	HeartbeatInterval time.Duration
+
+	// Quality checks metric rows before they are written; nil checks nothing
+	Quality *quality.Policy
+
+	// Anomalies compares the metric dates a run wrote with their baselines;
+	// nil detects nothing
+	Anomalies *anomaly.Config
+}
+
+// defaultCollectOptions collects the trailing lookback window, incrementally
+// when enabled in the configuration
+func defaultCollectOptions(config *Config, now time.Time) CollectOptions {
+	return CollectOptions{
+		ShowName:        config.ShowName,
+		StartDate:       now.AddDate(0, 0, -config.LookbackDays),
+		EndDate:         now,
+		Incremental:     config.Incremental,
+		RestatementDays: config.RestatementDays,
+		AdaptivePolling: config.AdaptivePolling,
+		Staged:          config.StagedWrites,
+		Lock:            config.RunLock,
+		LockTimeout:     config.RunLockTimeout,
+
+		HeartbeatInterval: config.HeartbeatInterval,
+		Quality:           config.Quality,
+		Anomalies:         config.Anomalies,
+	}
+}
+
+// Collector orchestrates metrics collection across all platforms
+type Collector struct {
+	store      repository.Store
+	watermarks watermarkStore
+	scrapers   []scrapers.Scraper
+
+	// alerts is notified when a run finishes; nil sends no alerts
+	alerts *alert.Notifier
+}
+
+// NewCollector creates a new collector. watermarks may be nil, in which case
+// incremental collection is unavailable and every run fetches the full window.
+func NewCollector(store repository.Store, watermarks watermarkStore, scrapers []scrapers.Scraper) *Collector {
+	return &Collector{
+		store:      store,
+		watermarks: watermarks,
+		scrapers:   scrapers,
+	}
+}
+
+// platforms returns the platforms c collects
+func (c *Collector) platforms() []scrapers.Platform {
+	platforms := make([]scrapers.Platform, len(c.scrapers))
+	for i, s := range c.scrapers {
+		platforms[i] = s.GetPlatform()
+	}
+	return platforms
+}
+
+// only returns a collector for platform alone, or false when c does not
+// collect it
+func (c *Collector) only(platform scrapers.Platform) (*Collector, bool) {
+	for _, s := range c.scrapers {
+		if s.GetPlatform() == platform {
+			only := NewCollector(c.store, c.watermarks, []scrapers.Scraper{s})
+			only.alerts = c.alerts
+			return only, true
+		}
+	}
+	return nil, false
+}
+
+// Collect runs collection for all scrapers
+func (c *Collector) Collect(ctx context.Context, opts CollectOptions) error {
+	for _, scraper := range c.scrapers {
+		if err := c.collectForPlatform(ctx, scraper, opts); err != nil {
+			slog.ErrorContext(ctx, "Collection failed", logging.KeyPlatform, scraper.GetPlatform(), logging.KeyError, err)
+			// Continue with other platforms even if one fails
+			continue
+		}
+	}
+
+	return nil
+}
+
+// collectForPlatform collects metrics for a single platform
+func (c *Collector) collectForPlatform(ctx context.Context, scraper scrapers.Scraper, opts CollectOptions) error {
+	showName, endDate := opts.ShowName, opts.EndDate
+	platform := scraper.GetPlatform()
+	ctx = logging.With(ctx, logging.KeyPlatform, platform, logging.KeyShow, showName)
+	slog.InfoContext(ctx, "Starting collection")
+
+	ctx, span := startRunSpan(ctx, "collect", platform, showName)
+
+	// Record scraper run
+	run := &scrapers.ScraperRun{
+		Platform:     platform,
+		RunStartedAt: time.Now(),
+		Status:       scrapers.RunStatusRunning,
+	}
+	defer endRunSpan(span, run)
+
+	runID, err := c.store.RecordScraperRun(ctx, run)
+	if err != nil {
+		return fmt.Errorf("failed to record scraper run: %w", err)
+	}
+	run.ID = runID
+
+	// Archived responses are filed under the run, and responses are checked
+	// against their declared shapes
+	ctx = scrapers.WithRunID(ctx, runID)
+	ctx = logging.With(ctx, logging.KeyRunID, runID)
+	span.SetAttributes(scrapers.AttrRunID.Int64(runID))
+	drift := newRunDrift(c.store, runID)
+	ctx = scrapers.WithDriftReporter(ctx, drift)
+	heartbeat := startHeartbeat(ctx, c.store, runID, opts.HeartbeatInterval)
+	checks := newRunQuality(opts.Quality, c.store, runID, platform)
+	quota := newRunQuota(scraper, c.store, runID)
+	ledger := newRunLedger(c.store, runID, platform)
+	ctx = scrapers.WithArchiveFailureReporter(ctx, ledger)
+
+	// In staged mode metrics go to per-run tables, published on success
+	store := c.store
+	var stager runStager
+	var runErr error
+	var anomalies []*scrapers.MetricAnomaly
+
+	defer func() {
+		heartbeat.end()
+
+		if stager != nil && run.Status != scrapers.RunStatusCompleted {
+			if err := stager.DiscardRun(context.WithoutCancel(ctx), runID); err != nil {
+				slog.ErrorContext(ctx, "Failed to discard staged data", logging.KeyError, err)
+			}
+		}
+
+		completedAt := time.Now()
+		run.RunCompletedAt = &completedAt
+		drift.flag(run)
+		checks.flag(run)
+		quota.save(context.WithoutCancel(ctx))
+
+		if err := c.store.UpdateScraperRun(ctx, runID, run); err != nil {
+			slog.ErrorContext(ctx, "Failed to update scraper run", logging.KeyError, err)
+		}
+		observeRun(scraper, run)
+		notifyRun(ctx, c.alerts, runOutcome{
+			run:         run,
+			show:        showName,
+			err:         runErr,
+			driftFields: drift.count(),
+			heldRows:    checks.held(),
+			anomalies:   anomalies,
+		})
+	}()
+
+	fail := func(err error) error {
+		runErr = err
+		run.Status = scrapers.RunStatusFailed
+		errMsg := err.Error()
+		run.ErrorMessage = &errMsg
+		return err
+	}
+
+	// Two collectors of the same show and platform would race on upserts
+	if locker, ok := c.store.(runLocker); ok {
+		lock, err := lockRun(ctx, locker, showName, platform, opts.Lock, opts.LockTimeout)
+		if errors.Is(err, repository.ErrLockHeld) && opts.Lock == lockSkip {
+			slog.WarnContext(ctx, "Skipping collection, another process holds the run lock")
+			run.Status = scrapers.RunStatusSkipped
+			msg := err.Error()
+			run.ErrorMessage = &msg
+			return nil
+		}
+		if err != nil {
+			return fail(err)
+		}
+		defer func() {
+			if err := lock.Release(context.WithoutCancel(ctx)); err != nil {
+				slog.ErrorContext(ctx, "Failed to release run lock", logging.KeyError, err)
+			}
+		}()
+	}
+
+	if opts.Staged {
+		s, ok := c.store.(runStager)
+		if !ok {
+			return fail(fmt.Errorf("staged writes are not supported by this store"))
+		}
+		staged, err := s.StageRun(ctx, runID)
+		if err != nil {
+			return fail(fmt.Errorf("failed to stage run: %w", err))
+		}
+		store, stager = staged, s
+	}
+
+	// Fetch podcast info
+	podcast, err := scraper.FetchPodcastInfo(ctx, showName)
+	if err != nil {
+		return fail(fmt.Errorf("failed to fetch podcast info: %w", err))
+	}
+
+	// Upsert podcast to database
+	podcastID, err := store.UpsertPodcast(ctx, podcast)
+	if err != nil {
+		return fail(fmt.Errorf("failed to upsert podcast: %w", err))
+	}
+	podcast.ID = podcastID
+
+	// Fetch episodes
+	episodes, err := scraper.FetchEpisodes(ctx, podcast)
+	if err != nil {
+		return fail(fmt.Errorf("failed to fetch episodes: %w", err))
+	}
+
+	slog.InfoContext(ctx, "Found episodes", "episodes", len(episodes))
+
+	// Load high-water marks for incremental collection
+	plan := newIncrementalPlan(platform, podcastID, opts, time.Now())
+	if opts.Incremental && c.watermarks != nil {
+		marks, err := c.watermarks.Watermarks(ctx, platform, podcastID)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to load watermarks, collecting full window", logging.KeyError, err)
+		} else {
+			plan.setMarks(marks)
+		}
+	}
+
+	var written metricSpan
+
+	// Watermarks of a staged run are saved only once it is published
+	var staged []*repository.Watermark
+	saveWatermark := func(wm *repository.Watermark) {
+		if wm == nil || c.watermarks == nil {
+			return
+		}
+		if stager != nil {
+			staged = append(staged, wm)
+			return
+		}
+		if err := c.watermarks.SaveWatermark(ctx, wm); err != nil {
+			slog.ErrorContext(ctx, "Failed to save watermark", "episode_row_id", wm.EpisodeID, logging.KeyError, err)
+		}
+	}
+
+	// Process each episode
+	for _, episode := range episodes {
+		ctx := logging.With(ctx, logging.KeyEpisodeID, episode.PlatformEpisodeID)
+		episode.PodcastID = podcastID
+
+		// Existing episodes keep their ID; new ones get one when written below
+		episodeID, err := store.LookupEpisodeID(ctx, podcastID, episode.PlatformEpisodeID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			ledger.record(ctx, episode, scrapers.StageUpsertEpisode, err)
+			continue
+		}
+		episode.ID = episodeID
+
+		episodeStart, due := plan.window(episodeID, episode.PublishDate)
+		if !due {
+			// Keep the episode's details current even when its metrics are not due
+			if _, err := store.UpsertEpisode(ctx, episode); err != nil {
+				ledger.record(ctx, episode, scrapers.StageUpsertEpisode, err)
+			}
+			plan.idle++
+			continue
+		}
+
+		// Fetch episode metrics
+		episodeMetrics, err := scraper.FetchEpisodeMetrics(ctx, episode, episodeStart, endDate)
+		if err != nil {
+			ledger.record(ctx, episode, scrapers.StageFetchMetrics, err)
+			continue
+		}
+		episodeMetrics = checks.episodeMetrics(ctx, episode, episodeMetrics)
+
+		// Fetch comments (if platform supports it)
+		comments, err := scraper.FetchComments(ctx, episode)
+		if err != nil {
+			ledger.record(ctx, episode, scrapers.StageFetchComments, err)
+			comments = nil
+		}
+
+		// Write the episode, its metrics and its comments in one transaction
+		// so an episode is never left half-loaded
+		stage := scrapers.StageUpsertEpisode
+		err = store.InTx(ctx, func(tx repository.Store) error {
+			id, err := tx.UpsertEpisode(ctx, episode)
+			if err != nil {
+				return err
+			}
+			episodeID = id
+
+			stage = scrapers.StageStoreMetric
+
+			for _, metric := range episodeMetrics {
+				metric.EpisodeID = id
+				metric.RunID = runID
+			}
+			for _, err := range tx.UpsertEpisodeMetricsBatch(ctx, episodeMetrics) {
+				if err != nil {
+					return err
+				}
+			}
+
+			stage = scrapers.StageStoreComment
+			for _, comment := range comments {
+				comment.EpisodeID = id
+				if err := tx.InsertComment(ctx, comment); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			ledger.record(ctx, episode, stage, err)
+			continue
+		}
+		episode.ID = episodeID
+		run.MetricsCollected += len(episodeMetrics)
+		for _, metric := range episodeMetrics {
+			written.add(metric.MetricDate)
+		}
+		promMetrics.AddRowsUpserted(platform, "episode_metrics", len(episodeMetrics))
+		promMetrics.AddRowsUpserted(platform, "comments", len(comments))
+
+		saveWatermark(plan.advanceEpisode(episodeID, episodeMetrics, true))
+
+		run.EpisodesProcessed++
+		heartbeat.progress(run)
+	}
+
+	// Fetch show-level metrics
+	showStart := plan.showWindow()
+	showMetrics, err := scraper.FetchShowMetrics(ctx, podcast, showStart, endDate)
+	if err != nil {
+		ledger.record(ctx, nil, scrapers.StageFetchShowMetrics, err)
+	} else {
+		showMetrics = checks.showMetrics(ctx, podcastID, showMetrics)
+		err := store.InTx(ctx, func(tx repository.Store) error {
+			for _, metric := range showMetrics {
+				metric.PodcastID = podcastID
+				if err := tx.UpsertShowMetrics(ctx, metric); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			ledger.record(ctx, nil, scrapers.StageStoreShowMetric, err)
+		} else {
+			promMetrics.AddRowsUpserted(platform, "show_metrics", len(showMetrics))
+			for _, metric := range showMetrics {
+				written.add(metric.MetricDate)
+			}
+		}
+
+		saveWatermark(plan.advanceShow(showMetrics, err == nil))
+	}
+
+	// A staged run is all or nothing
+	if stager != nil {
+		if ledger.count > 0 {
+			return fail(fmt.Errorf("discarded staged data after %d item errors; see 'podcast-scraper runs show %d'", ledger.count, runID))
+		}
+		if err := stager.PublishRun(ctx, runID); err != nil {
+			return fail(err)
+		}
+
+		stager = nil
+		for _, wm := range staged {
+			saveWatermark(wm)
+		}
+	}
+
+	ledger.finish(run)
+
+	// Written metrics are compared with their history once they are published
+	anomalies = detectRunAnomalies(ctx, c.store, opts.Anomalies, run, podcastID, written, episodes)
+
+	slog.InfoContext(ctx, "Completed collection", "status", run.Status, "episodes", run.EpisodesProcessed,
+		"metrics", run.MetricsCollected, "idle_episodes", plan.idle, "errors", ledger.count, "anomalies", len(anomalies))
+
+	return nil
+}
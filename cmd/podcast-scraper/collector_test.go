@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// fakeScraper serves canned podcasts, episodes and metrics
+type fakeScraper struct {
+	episodes       []*scrapers.Episode
+	episodeMetrics map[string][]*scrapers.EpisodeMetrics // by platform episode ID
+	showMetrics    []*scrapers.ShowMetrics
+	comments       map[string][]*scrapers.Comment
+	fetchErrs      map[string]error // FetchEpisodeMetrics errors by platform episode ID
+
+	// windows records the start date each episode's metrics were fetched from
+	windows map[string]time.Time
+
+	// fetches lists every metrics request as "<episode or show> <start> <end>"
+	fetches []string
+}
+
+func (f *fakeScraper) GetPlatform() scrapers.Platform { return scrapers.PlatformSpotify }
+
+func (f *fakeScraper) FetchPodcastInfo(ctx context.Context, showName string) (*scrapers.Podcast, error) {
+	return &scrapers.Podcast{ShowName: showName, Platform: scrapers.PlatformSpotify, PlatformID: "show-1"}, nil
+}
+
+func (f *fakeScraper) FetchEpisodes(ctx context.Context, podcast *scrapers.Podcast) ([]*scrapers.Episode, error) {
+	// Each run gets fresh episodes, as a real scraper decodes them anew
+	episodes := make([]*scrapers.Episode, len(f.episodes))
+	for i, e := range f.episodes {
+		copied := *e
+		episodes[i] = &copied
+	}
+	return episodes, nil
+}
+
+func (f *fakeScraper) FetchEpisodeMetrics(ctx context.Context, episode *scrapers.Episode, startDate, endDate time.Time) ([]*scrapers.EpisodeMetrics, error) {
+	if f.windows == nil {
+		f.windows = make(map[string]time.Time)
+	}
+	f.windows[episode.PlatformEpisodeID] = startDate
+	f.fetches = append(f.fetches, fetchKey(episode.PlatformEpisodeID, startDate, endDate))
+
+	if err := f.fetchErrs[episode.PlatformEpisodeID]; err != nil {
+		return nil, err
+	}
+	var metrics []*scrapers.EpisodeMetrics
+	for _, m := range f.episodeMetrics[episode.PlatformEpisodeID] {
+		copied := *m
+		metrics = append(metrics, &copied)
+	}
+	return metrics, nil
+}
+
+func (f *fakeScraper) FetchShowMetrics(ctx context.Context, podcast *scrapers.Podcast, startDate, endDate time.Time) ([]*scrapers.ShowMetrics, error) {
+	f.fetches = append(f.fetches, fetchKey("show", startDate, endDate))
+	return f.showMetrics, nil
+}
+
+func (f *fakeScraper) FetchComments(ctx context.Context, episode *scrapers.Episode) ([]*scrapers.Comment, error) {
+	return f.comments[episode.PlatformEpisodeID], nil
+}
+
+func (f *fakeScraper) CheckCredentials(ctx context.Context) *scrapers.CredentialCheck {
+	return &scrapers.CredentialCheck{Platform: scrapers.PlatformSpotify, Status: scrapers.CredentialValid}
+}
+
+func fetchKey(target string, startDate, endDate time.Time) string {
+	return target + " " + startDate.Format("2006-01-02") + " " + endDate.Format("2006-01-02")
+}
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func newFakeScraper() *fakeScraper {
+	return &fakeScraper{
+		episodes: []*scrapers.Episode{
+			{PlatformEpisodeID: "ep-1", EpisodeTitle: "Pilot", PublishDate: day(2026, 1, 1)},
+			{PlatformEpisodeID: "ep-2", EpisodeTitle: "Second", PublishDate: day(2026, 1, 8)},
+		},
+		episodeMetrics: map[string][]*scrapers.EpisodeMetrics{
+			"ep-1": {
+				{MetricDate: day(2026, 2, 1), Plays: 10, Listeners: 8},
+				{MetricDate: day(2026, 2, 2), Plays: 12, Listeners: 9},
+			},
+			"ep-2": {
+				{MetricDate: day(2026, 2, 1), Plays: 5, Listeners: 5},
+			},
+		},
+		showMetrics: []*scrapers.ShowMetrics{
+			{MetricDate: day(2026, 2, 1), TotalPlays: 15, TotalListeners: 13},
+		},
+		comments: map[string][]*scrapers.Comment{
+			"ep-1": {{PlatformCommentID: "c-1", CommentText: "great show"}},
+		},
+	}
+}
+
+func testCollectOptions() CollectOptions {
+	return CollectOptions{
+		ShowName:  "Test Show",
+		StartDate: day(2026, 2, 1),
+		EndDate:   day(2026, 2, 2),
+	}
+}
+
+func TestCollectorWritesRun(t *testing.T) {
+	store := repository.NewMemoryStore()
+	collector := NewCollector(store, nil, []scrapers.Scraper{newFakeScraper()})
+
+	if err := collector.Collect(context.Background(), testCollectOptions()); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	if got := len(store.Podcasts()); got != 1 {
+		t.Errorf("podcasts = %d, want 1", got)
+	}
+	if got := len(store.Episodes()); got != 2 {
+		t.Errorf("episodes = %d, want 2", got)
+	}
+	if got := len(store.EpisodeMetrics()); got != 3 {
+		t.Errorf("episode metrics = %d, want 3", got)
+	}
+	if got := len(store.ShowMetrics()); got != 1 {
+		t.Errorf("show metrics = %d, want 1", got)
+	}
+	if got := len(store.Comments()); got != 1 {
+		t.Errorf("comments = %d, want 1", got)
+	}
+
+	runs := store.Runs()
+	if len(runs) != 1 {
+		t.Fatalf("runs = %d, want 1", len(runs))
+	}
+	run := runs[0]
+	if run.Status != scrapers.RunStatusCompleted {
+		t.Errorf("run status = %q, want %q", run.Status, scrapers.RunStatusCompleted)
+	}
+	if run.EpisodesProcessed != 2 || run.MetricsCollected != 3 {
+		t.Errorf("run counted %d episodes and %d metrics, want 2 and 3", run.EpisodesProcessed, run.MetricsCollected)
+	}
+	for _, m := range store.EpisodeMetrics() {
+		if m.RunID != run.ID {
+			t.Errorf("metric run ID = %d, want %d", m.RunID, run.ID)
+		}
+	}
+}
+
+func TestCollectorRerunUpserts(t *testing.T) {
+	store := repository.NewMemoryStore()
+	collector := NewCollector(store, nil, []scrapers.Scraper{newFakeScraper()})
+
+	for i := 0; i < 2; i++ {
+		if err := collector.Collect(context.Background(), testCollectOptions()); err != nil {
+			t.Fatalf("Collect() error = %v", err)
+		}
+	}
+
+	if got := len(store.Episodes()); got != 2 {
+		t.Errorf("episodes = %d, want 2", got)
+	}
+	if got := len(store.EpisodeMetrics()); got != 3 {
+		t.Errorf("episode metrics = %d, want 3", got)
+	}
+	if got := len(store.Runs()); got != 2 {
+		t.Errorf("runs = %d, want 2", got)
+	}
+}
+
+func TestCollectorRecordsItemErrors(t *testing.T) {
+	store := repository.NewMemoryStore()
+	scraper := newFakeScraper()
+	scraper.fetchErrs = map[string]error{
+		"ep-2": &scrapers.HTTPError{StatusCode: 503, Body: "unavailable, retry with access_token=secret"},
+	}
+	collector := NewCollector(store, nil, []scrapers.Scraper{scraper})
+
+	if err := collector.Collect(context.Background(), testCollectOptions()); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	run := store.Runs()[0]
+	if run.Status != scrapers.RunStatusPartial {
+		t.Errorf("run status = %q, want %q", run.Status, scrapers.RunStatusPartial)
+	}
+	if run.EpisodesProcessed != 1 {
+		t.Errorf("episodes processed = %d, want 1", run.EpisodesProcessed)
+	}
+
+	errs := store.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("errors = %d, want 1", len(errs))
+	}
+	if errs[0].Stage != scrapers.StageFetchMetrics {
+		t.Errorf("error stage = %q, want %q", errs[0].Stage, scrapers.StageFetchMetrics)
+	}
+	if errs[0].HTTPStatus == nil || *errs[0].HTTPStatus != 503 {
+		t.Errorf("error HTTP status = %v, want 503", errs[0].HTTPStatus)
+	}
+	if strings.Contains(errs[0].Message, "secret") {
+		t.Errorf("error message %q was not redacted", errs[0].Message)
+	}
+}
+
+func TestCollectorFailsWithoutPodcast(t *testing.T) {
+	store := repository.NewMemoryStore()
+	collector := NewCollector(store, nil, []scrapers.Scraper{&failingScraper{fakeScraper: newFakeScraper()}})
+
+	if err := collector.Collect(context.Background(), testCollectOptions()); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	run := store.Runs()[0]
+	if run.Status != scrapers.RunStatusFailed {
+		t.Errorf("run status = %q, want %q", run.Status, scrapers.RunStatusFailed)
+	}
+	if len(store.Episodes()) != 0 {
+		t.Errorf("episodes = %d, want 0", len(store.Episodes()))
+	}
+}
+
+// failingScraper cannot find the podcast
+type failingScraper struct {
+	*fakeScraper
+}
+
+func (f *failingScraper) FetchPodcastInfo(ctx context.Context, showName string) (*scrapers.Podcast, error) {
+	return nil, errors.New("show not found")
+}
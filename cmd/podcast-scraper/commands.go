@@ -0,0 +1,539 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/alert"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// runCollect implements `podcast-scraper collect`
+func runCollect(ctx context.Context, config *Config, args []string) error {
+	fs := flag.NewFlagSet("collect", flag.ContinueOnError)
+	var f commandFlags
+	f.registerFilter(fs)
+	f.registerDateRange(fs)
+	f.registerDryRun(fs)
+	full := fs.Bool("full", false, "ignore high-water marks and fetch the whole window for every episode")
+	staged := fs.Bool("staged", config.StagedWrites, "publish each platform's data only if its whole run succeeds")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := f.apply(config); err != nil {
+		return err
+	}
+
+	opts := defaultCollectOptions(config, time.Now())
+	opts.Staged = *staged
+	if *full {
+		opts.Incremental = false
+	}
+
+	// An explicit range is fetched exactly as asked
+	if f.from != "" || f.to != "" {
+		startDate, endDate, err := f.dateRange(config.LookbackDays)
+		if err != nil {
+			return err
+		}
+		opts.StartDate, opts.EndDate = startDate, endDate
+		opts.Incremental = false
+	}
+
+	return collect(ctx, config, opts, f.dryRun)
+}
+
+// runBackfill implements `podcast-scraper backfill`, which requires an
+// explicit start date so a long historical range is never fetched by accident
+func runBackfill(ctx context.Context, config *Config, args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	var f commandFlags
+	f.registerFilter(fs)
+	f.registerDateRange(fs)
+	f.registerDryRun(fs)
+	windowDays := fs.Int("window-days", 0, "days per request (default: per-platform limit)")
+	quotaReserve := fs.Int64("quota-reserve", config.BackfillQuotaReserve, "quota units to leave for regular collections")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := f.apply(config); err != nil {
+		return err
+	}
+	if f.from == "" {
+		return errors.New("backfill requires --from")
+	}
+
+	startDate, endDate, err := f.dateRange(config.LookbackDays)
+	if err != nil {
+		return err
+	}
+
+	opts := BackfillOptions{
+		ShowName:      config.ShowName,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		WindowDays:    *windowDays,
+		QuotaReserve:  *quotaReserve,
+		BatchSize:     config.MetricsBatchSize,
+		FlushInterval: config.MetricsFlushInterval,
+
+		HeartbeatInterval: config.HeartbeatInterval,
+		Quality:           config.Quality,
+	}
+
+	store, closeStore, err := openStore(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	archiver, err := newArchiver(config, store)
+	if err != nil {
+		return err
+	}
+
+	// Stores without checkpoints refetch every window on each backfill
+	checkpoints, _ := store.(checkpointStore)
+	var report *dryRunStore
+
+	// A dry run reads the current rows to diff against but writes nothing
+	if f.dryRun {
+		source, ok := store.(dryRunSource)
+		if !ok {
+			return fmt.Errorf("--dry-run is not supported with STORE=%s", config.Store)
+		}
+		report = newDryRunStore(source)
+		store, checkpoints, archiver = report, report, nil
+	}
+
+	// Close out runs left behind by a killed process before starting new
+	// ones. Dry runs alert on nothing.
+	var notifier *alert.Notifier
+	if report == nil {
+		reapStaleRuns(ctx, store, config.StaleRunAfter)
+		notifier = newNotifier(config, store)
+	}
+
+	// Dry runs write nothing, so they report no metrics either
+	if report == nil {
+		if err := serveMetrics(ctx, config); err != nil {
+			return err
+		}
+		defer pushMetrics(ctx, config)
+	}
+
+	scraperInstances, err := initializeScrapers(ctx, config, store, archiver, notifier)
+	if err != nil {
+		return fmt.Errorf("failed to initialize scrapers: %w", err)
+	}
+
+	if err := NewBackfiller(store, checkpoints, scraperInstances).Backfill(ctx, opts); err != nil {
+		return fmt.Errorf("backfill failed: %w", err)
+	}
+
+	if report != nil {
+		return report.print(os.Stdout)
+	}
+
+	slog.InfoContext(ctx, "Backfill completed successfully")
+	return nil
+}
+
+// collect runs a single collection, writing to the configured store unless dryRun is set
+func collect(ctx context.Context, config *Config, opts CollectOptions, dryRun bool) error {
+	store, closeStore, err := openStore(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	archiver, err := newArchiver(config, store)
+	if err != nil {
+		return err
+	}
+
+	// Stores without watermarks always collect the full window
+	watermarks, _ := store.(watermarkStore)
+	var report *dryRunStore
+
+	// A dry run reads the current rows to diff against but writes nothing
+	if dryRun {
+		source, ok := store.(dryRunSource)
+		if !ok {
+			return fmt.Errorf("--dry-run is not supported with STORE=%s", config.Store)
+		}
+		report = newDryRunStore(source)
+		store, watermarks, archiver = report, report, nil
+		opts.Staged = false
+	}
+
+	// Close out runs left behind by a killed process before starting new
+	// ones. Dry runs alert on nothing.
+	var notifier *alert.Notifier
+	if report == nil {
+		reapStaleRuns(ctx, store, config.StaleRunAfter)
+		notifier = newNotifier(config, store)
+	}
+
+	// Dry runs write nothing, so they report no metrics either
+	if report == nil {
+		if err := serveMetrics(ctx, config); err != nil {
+			return err
+		}
+		defer pushMetrics(ctx, config)
+	}
+
+	scraperInstances, err := initializeScrapers(ctx, config, store, archiver, notifier)
+	if err != nil {
+		return fmt.Errorf("failed to initialize scrapers: %w", err)
+	}
+
+	collector := NewCollector(store, watermarks, scraperInstances)
+	collector.alerts = notifier
+	if err := collector.Collect(ctx, opts); err != nil {
+		return fmt.Errorf("collection failed: %w", err)
+	}
+
+	if report != nil {
+		return report.print(os.Stdout)
+	}
+
+	slog.InfoContext(ctx, "Collection completed successfully")
+	return nil
+}
+
+// runServe implements `podcast-scraper serve`
+func runServe(ctx context.Context, config *Config, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	var f commandFlags
+	f.registerFilter(fs)
+	fs.DurationVar(&config.ScheduleInterval, "interval", config.ScheduleInterval, "time between collections when no schedule is set")
+	fs.StringVar(&config.Schedule, "schedule", config.Schedule, "cron expression to collect on, e.g. \"0 6 * * *\"")
+	fs.StringVar(&config.ServeAddr, "addr", config.ServeAddr, "address to serve health checks and run triggers on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := f.apply(config); err != nil {
+		return err
+	}
+
+	store, closeStore, err := openStore(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	archiver, err := newArchiver(config, store)
+	if err != nil {
+		return err
+	}
+
+	notifier := newNotifier(config, store)
+	scraperInstances, err := initializeScrapers(ctx, config, store, archiver, notifier)
+	if err != nil {
+		return fmt.Errorf("failed to initialize scrapers: %w", err)
+	}
+
+	// Metrics are also served on the serve address; METRICS_ADDR adds a separate listener
+	if err := serveMetrics(ctx, config); err != nil {
+		return err
+	}
+
+	watermarks, _ := store.(watermarkStore)
+	collector := NewCollector(store, watermarks, scraperInstances)
+	collector.alerts = notifier
+	srv, err := newServer(config, store, collector)
+	if err != nil {
+		return err
+	}
+	return srv.run(ctx, config.ServeAddr)
+}
+
+// runRunsList implements `podcast-scraper runs list`
+func runRunsList(ctx context.Context, config *Config, args []string) error {
+	fs := flag.NewFlagSet("runs list", flag.ContinueOnError)
+	var f commandFlags
+	f.registerPlatform(fs)
+	f.registerDateRange(fs)
+	limit := fs.Int("limit", 50, "maximum number of runs to show")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := f.apply(config); err != nil {
+		return err
+	}
+
+	filter := repository.RunFilter{
+		Platforms: config.Platforms,
+		Limit:     *limit,
+	}
+	if f.from != "" || f.to != "" {
+		startDate, endDate, err := f.dateRange(config.LookbackDays)
+		if err != nil {
+			return err
+		}
+		filter.Since = startDate
+		filter.Until = endDate.AddDate(0, 0, 1)
+	}
+
+	db, err := connectDatabase(config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	runs, err := repository.NewPodcastRepository(db).ListScraperRuns(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPLATFORM\tSTARTED\tDURATION\tSTATUS\tEPISODES\tMETRICS\tDRIFT\tERROR")
+	for _, run := range runs {
+		duration := "-"
+		if run.RunCompletedAt != nil {
+			duration = run.RunCompletedAt.Sub(run.RunStartedAt).Round(time.Second).String()
+		}
+		errMsg := ""
+		if run.ErrorMessage != nil {
+			errMsg = *run.ErrorMessage
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\n",
+			run.ID,
+			run.Platform,
+			run.RunStartedAt.Format(time.RFC3339),
+			duration,
+			run.Status,
+			run.EpisodesProcessed,
+			run.MetricsCollected,
+			run.SchemaDrift,
+			errMsg,
+		)
+	}
+
+	return w.Flush()
+}
+
+// runRunsShow implements `podcast-scraper runs show <id>`, summarising a run
+// and the per-item errors in its ledger
+func runRunsShow(ctx context.Context, config *Config, args []string) error {
+	fs := flag.NewFlagSet("runs show", flag.ContinueOnError)
+	limit := fs.Int("limit", 50, "maximum number of errors to list (0 for all)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: runs show [flags] <run id>")
+	}
+	runID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid run id %q", fs.Arg(0))
+	}
+
+	db, err := connectDatabase(config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewPodcastRepository(db)
+
+	run, err := repo.GetScraperRun(ctx, runID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("run %d not found", runID)
+	}
+	if err != nil {
+		return err
+	}
+
+	ledger, err := repo.ListScraperErrors(ctx, runID)
+	if err != nil {
+		return err
+	}
+
+	drift, err := repo.ListSchemaDrift(ctx, runID)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Run:\t%d\n", run.ID)
+	fmt.Fprintf(w, "Platform:\t%s\n", run.Platform)
+	fmt.Fprintf(w, "Status:\t%s\n", run.Status)
+	fmt.Fprintf(w, "Started:\t%s\n", run.RunStartedAt.Format(time.RFC3339))
+	if run.RunCompletedAt != nil {
+		fmt.Fprintf(w, "Completed:\t%s (%s)\n", run.RunCompletedAt.Format(time.RFC3339), run.RunCompletedAt.Sub(run.RunStartedAt).Round(time.Second))
+	}
+	fmt.Fprintf(w, "Episodes:\t%d\n", run.EpisodesProcessed)
+	fmt.Fprintf(w, "Metrics:\t%d\n", run.MetricsCollected)
+	if run.ErrorMessage != nil {
+		fmt.Fprintf(w, "Error:\t%s\n", *run.ErrorMessage)
+	}
+	fmt.Fprintf(w, "Item errors:\t%d\n", len(ledger))
+	fmt.Fprintf(w, "Schema drift:\t%d\n", len(drift))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(drift) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ENDPOINT\tFIELD\tDRIFT\tEXPECTED\tOBSERVED\tSAMPLE")
+		for _, d := range drift {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				d.Endpoint,
+				d.Path,
+				d.Kind,
+				typeValue(d.ExpectedType),
+				typeValue(d.ObservedType),
+				truncate(d.Sample, 80),
+			)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(ledger) == 0 {
+		return nil
+	}
+
+	// Summary by stage, class and HTTP status, most frequent first
+	type errorGroup struct {
+		stage, class, status string
+		count                int
+	}
+	groups := make(map[string]*errorGroup)
+	var order []*errorGroup
+	for _, e := range ledger {
+		g := &errorGroup{stage: e.Stage, class: string(e.ErrorClass), status: httpStatusValue(e.HTTPStatus)}
+		key := g.stage + "/" + g.class + "/" + g.status
+		if existing, ok := groups[key]; ok {
+			g = existing
+		} else {
+			groups[key] = g
+			order = append(order, g)
+		}
+		g.count++
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].count > order[j].count })
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STAGE\tCLASS\tHTTP\tCOUNT")
+	for _, g := range order {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", g.stage, g.class, g.status, g.count)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tEPISODE\tSTAGE\tCLASS\tHTTP\tMESSAGE")
+	for i, e := range ledger {
+		if *limit > 0 && i == *limit {
+			fmt.Fprintf(w, "... %d more\n", len(ledger)-i)
+			break
+		}
+		episode := e.EpisodeTitle
+		if episode == "" {
+			episode = "(show)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.OccurredAt.Format(time.RFC3339),
+			episode,
+			e.Stage,
+			e.ErrorClass,
+			httpStatusValue(e.HTTPStatus),
+			e.Message,
+		)
+	}
+
+	return w.Flush()
+}
+
+// httpStatusValue renders an optional HTTP status for tables
+func httpStatusValue(status *int) string {
+	if status == nil {
+		return "-"
+	}
+	return strconv.Itoa(*status)
+}
+
+// typeValue renders an optional JSON type for tables
+func typeValue(t scrapers.JSONType) string {
+	if t == "" {
+		return "-"
+	}
+	return string(t)
+}
+
+// truncate shortens s to at most n bytes for tables, cutting on a rune boundary
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	n -= 3
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
+
+// runRestatements implements `podcast-scraper restatements`, showing how much
+// each platform revises episode metrics after first reporting them
+func runRestatements(ctx context.Context, config *Config, args []string) error {
+	fs := flag.NewFlagSet("restatements", flag.ContinueOnError)
+	var f commandFlags
+	f.registerPlatform(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := f.apply(config); err != nil {
+		return err
+	}
+
+	db, err := connectDatabase(config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	summaries, err := repository.NewPodcastRepository(db).RestatementSummaries(ctx, config.Platforms)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLATFORM\tDAYS\tRESTATED\tRESTATED %\tREVISIONS\tAVG DAYS AFTER\tMAX DAYS AFTER\tABS DELTA\tAVG CHANGE %")
+	for _, s := range summaries {
+		maxDays := "-"
+		if s.MaxDaysAfter != nil {
+			maxDays = strconv.FormatInt(*s.MaxDaysAfter, 10)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%d\t%s\t%s\t%d\t%s\n",
+			s.Platform,
+			s.MetricDays,
+			s.RestatedDays,
+			floatPtrValue(s.RestatedPct),
+			s.Restatements,
+			floatPtrValue(s.AvgDaysAfter),
+			maxDays,
+			s.TotalAbsDelta,
+			floatPtrValue(s.AvgAbsChangePct),
+		)
+	}
+
+	return w.Flush()
+}
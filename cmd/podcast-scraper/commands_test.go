@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"Pilot", 10, "Pilot"},
+		{"The very long episode title", 10, "The ver..."},
+		// "ñ" is two bytes and would be split at byte 5
+		{"Españolísimo", 8, "Espa..."},
+	}
+
+	for _, tt := range tests {
+		got := truncate(tt.s, tt.n)
+		if got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+		if len(got) > tt.n || !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) = %q, want valid UTF-8 of at most %d bytes", tt.s, tt.n, got, tt.n)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// runDoctor implements `podcast-scraper doctor`. It checks the credentials of
+// every configured platform, prints a report and fails if any are unhealthy.
+func runDoctor(ctx context.Context, config *Config, args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	var f commandFlags
+	f.registerPlatform(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := f.apply(config); err != nil {
+		return err
+	}
+
+	scraperList, err := newScrapers(config, nil)
+	if err != nil {
+		return err
+	}
+
+	// Remaining quota comes from the usage runs record in the database; a
+	// fresh process has made no calls of its own to count
+	var store repository.Store
+	if hasQuota(scraperList) {
+		s, closeStore, err := openStore(ctx, config)
+		if err != nil {
+			slog.WarnContext(ctx, "Cannot read API quota usage", logging.KeyError, err)
+		} else {
+			defer closeStore()
+			store = s
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLATFORM\tSTATUS\tEXPIRES\tQUOTA REMAINING\tDETAIL")
+
+	healthy := true
+	for _, scraper := range scraperList {
+		check := scraper.CheckCredentials(ctx)
+		if !check.OK() {
+			healthy = false
+		}
+
+		expires := "-"
+		if check.ExpiresAt != nil {
+			expires = check.ExpiresAt.Format(time.RFC3339)
+			if time.Until(*check.ExpiresAt) < 7*24*time.Hour {
+				expires += " (soon)"
+			}
+		}
+
+		quota := "-"
+		if _, ok := scraper.(scrapers.QuotaReporter); ok {
+			quota = "not tracked"
+			if remaining, ok := quotaRemainingToday(ctx, store, scraper); ok {
+				check.QuotaRemaining = &remaining
+			}
+		}
+		if check.QuotaRemaining != nil {
+			quota = fmt.Sprintf("%d", *check.QuotaRemaining)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", check.Platform, check.Status, expires, quota, check.Message)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	if !healthy {
+		return errUnhealthy
+	}
+	return nil
+}
+
+// hasQuota reports whether any scraper has a metered API quota
+func hasQuota(scraperList []scrapers.Scraper) bool {
+	for _, scraper := range scraperList {
+		if _, ok := scraper.(scrapers.QuotaReporter); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// driftStore persists schema drift found in platform responses
+type driftStore interface {
+	RecordSchemaDrift(ctx context.Context, d *scrapers.SchemaDrift) error
+}
+
+// runDrift collects the schema drift found in one run's responses. Each
+// field and kind is logged and recorded once per run.
+type runDrift struct {
+	store driftStore // nil when the store cannot record drift
+	runID int64
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func newRunDrift(store repository.Store, runID int64) *runDrift {
+	d := &runDrift{runID: runID, seen: make(map[string]bool)}
+	if s, ok := store.(driftStore); ok {
+		d.store = s
+	}
+	return d
+}
+
+// ReportDrift implements scrapers.DriftReporter
+func (d *runDrift) ReportDrift(ctx context.Context, drift []*scrapers.SchemaDrift) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, sd := range drift {
+		key := fmt.Sprintf("%s/%s/%s/%s", sd.Platform, sd.Endpoint, sd.Path, sd.Kind)
+		if d.seen[key] {
+			continue
+		}
+		d.seen[key] = true
+
+		slog.WarnContext(ctx, "Schema drift", logging.KeyPlatform, sd.Platform, "endpoint", sd.Endpoint,
+			"drift", sd.Kind, "field", sd.Path, "expected", typeValue(sd.ExpectedType), "observed", typeValue(sd.ObservedType))
+
+		if d.store == nil {
+			continue
+		}
+		if err := d.store.RecordSchemaDrift(context.WithoutCancel(ctx), sd); err != nil {
+			slog.ErrorContext(ctx, "Failed to record schema drift", logging.KeyError, err)
+		}
+	}
+}
+
+// count returns the number of drifted fields found so far
+func (d *runDrift) count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.seen)
+}
+
+// flag marks a run that found drift as partial, since its metrics may have
+// been parsed from fields that moved, and notes the drift on its message so
+// it shows up in run listings
+func (d *runDrift) flag(run *scrapers.ScraperRun) {
+	count := d.count()
+	if count == 0 {
+		return
+	}
+
+	if run.Status == scrapers.RunStatusCompleted {
+		run.Status = scrapers.RunStatusPartial
+	}
+
+	msg := fmt.Sprintf("schema drift in %d fields; see 'podcast-scraper runs show %d'", count, d.runID)
+	if run.ErrorMessage != nil {
+		msg = *run.ErrorMessage + "; " + msg
+	}
+	run.ErrorMessage = &msg
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// driftMemoryStore records schema drift in memory
+type driftMemoryStore struct {
+	*repository.MemoryStore
+	drift []*scrapers.SchemaDrift
+}
+
+func (s *driftMemoryStore) RecordSchemaDrift(ctx context.Context, d *scrapers.SchemaDrift) error {
+	s.drift = append(s.drift, d)
+	return nil
+}
+
+// driftingScraper reads every metrics response through ReadResponse, where
+// it is checked against a shape it does not match
+type driftingScraper struct {
+	*fakeScraper
+}
+
+func (d *driftingScraper) FetchEpisodeMetrics(ctx context.Context, episode *scrapers.Episode, startDate, endDate time.Time) ([]*scrapers.EpisodeMetrics, error) {
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader(`{"starts": 10, "plays": "12"}`))}
+	_, _, err := scrapers.ReadResponse(ctx, nil, resp, &scrapers.Response{
+		Platform: scrapers.PlatformSpotify,
+		Endpoint: scrapers.EndpointEpisodeMetrics,
+		Shape:    scrapers.Shape{"plays": scrapers.Required(scrapers.JSONNumber)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.fakeScraper.FetchEpisodeMetrics(ctx, episode, startDate, endDate)
+}
+
+func TestCollectorRecordsSchemaDrift(t *testing.T) {
+	store := &driftMemoryStore{MemoryStore: repository.NewMemoryStore()}
+	collector := NewCollector(store, nil, []scrapers.Scraper{&driftingScraper{newFakeScraper()}})
+
+	if err := collector.Collect(context.Background(), testCollectOptions()); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	// Both episodes' responses drift the same way; each field is recorded once
+	var got []string
+	for _, d := range store.drift {
+		got = append(got, d.Path+" "+d.Kind)
+	}
+	want := "plays retyped, starts new"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("recorded drift = %v, want %s", got, want)
+	}
+
+	run := store.Runs()[0]
+	if run.Status != scrapers.RunStatusPartial {
+		t.Errorf("run status = %s, want partial", run.Status)
+	}
+	if run.ErrorMessage == nil || !strings.Contains(*run.ErrorMessage, "schema drift in 2 fields") {
+		t.Errorf("run error = %v, want the drift noted", run.ErrorMessage)
+	}
+	for _, d := range store.drift {
+		if d.RunID == 0 || d.RunID != store.drift[0].RunID {
+			t.Errorf("drift run = %d, want the collection's run", d.RunID)
+		}
+	}
+}
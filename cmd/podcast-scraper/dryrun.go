@@ -0,0 +1,441 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// dryRunSource is the read side of the repository a dry run diffs against
+type dryRunSource interface {
+	GetPodcast(ctx context.Context, platform scrapers.Platform, platformID string) (*scrapers.Podcast, error)
+	GetEpisode(ctx context.Context, podcastID int64, platformEpisodeID string) (*scrapers.Episode, error)
+	GetEpisodeMetrics(ctx context.Context, episodeID int64, metricDate time.Time) (*scrapers.EpisodeMetrics, error)
+	GetShowMetrics(ctx context.Context, podcastID int64, metricDate time.Time) (*scrapers.ShowMetrics, error)
+	CommentExists(ctx context.Context, episodeID int64, platformCommentID string) (bool, error)
+	Watermarks(ctx context.Context, platform scrapers.Platform, podcastID int64) (map[int64]*repository.Watermark, error)
+	CompletedCheckpoints(ctx context.Context, platform scrapers.Platform, podcastID int64, startDate, endDate time.Time) (map[repository.CheckpointKey]bool, error)
+}
+
+// What a write would have done to the current row
+const (
+	actionInsert    = "insert"
+	actionUpdate    = "update"
+	actionUnchanged = "unchanged"
+)
+
+// Kinds of rows a dry run reports on, in report order
+var dryRunKinds = []string{"podcast", "episode", "episode_metrics", "show_metrics", "comment"}
+
+// field is a named column value rendered for comparison
+type field struct {
+	name  string
+	value string
+}
+
+// fieldDiff is a column whose value would change
+type fieldDiff struct {
+	name string
+	old  string
+	new  string
+}
+
+// dryRunChange is one row a dry run would have written
+type dryRunChange struct {
+	kind   string
+	label  string
+	action string
+	diffs  []fieldDiff
+}
+
+// dryRunStore satisfies repository.Store without writing anything. Each write is
+// compared with the current row from source and classified as an insert,
+// update (with field-level diffs) or no-op. Rows that do not exist yet get
+// negative synthetic IDs so the collector can carry on.
+type dryRunStore struct {
+	source          dryRunSource
+	nextID          int64
+	podcastPlatform map[int64]scrapers.Platform
+	episodePlatform map[int64]scrapers.Platform
+	labels          map[int64]string
+	changes         map[scrapers.Platform][]*dryRunChange
+}
+
+// newDryRunStore creates a dry run store that diffs against source
+func newDryRunStore(source dryRunSource) *dryRunStore {
+	return &dryRunStore{
+		source:          source,
+		podcastPlatform: make(map[int64]scrapers.Platform),
+		episodePlatform: make(map[int64]scrapers.Platform),
+		labels:          make(map[int64]string),
+		changes:         make(map[scrapers.Platform][]*dryRunChange),
+	}
+}
+
+func (s *dryRunStore) syntheticID() int64 {
+	s.nextID--
+	return s.nextID
+}
+
+// record classifies a write by comparing the current and proposed fields;
+// current is nil when the row does not exist
+func (s *dryRunStore) record(platform scrapers.Platform, kind, label string, current, proposed []field) {
+	change := &dryRunChange{kind: kind, label: label, action: actionInsert}
+	if current != nil {
+		change.diffs = diffFields(current, proposed)
+		change.action = actionUnchanged
+		if len(change.diffs) > 0 {
+			change.action = actionUpdate
+		}
+	}
+	s.changes[platform] = append(s.changes[platform], change)
+}
+
+// UpsertPodcast diffs a podcast against the stored row
+func (s *dryRunStore) UpsertPodcast(ctx context.Context, podcast *scrapers.Podcast) (int64, error) {
+	var current []field
+	id := s.syntheticID()
+
+	existing, err := s.source.GetPodcast(ctx, podcast.Platform, podcast.PlatformID)
+	switch {
+	case err == nil:
+		id = existing.ID
+		current = podcastFields(existing)
+	case !errors.Is(err, repository.ErrNotFound):
+		return 0, err
+	}
+
+	s.podcastPlatform[id] = podcast.Platform
+	s.record(podcast.Platform, "podcast", podcast.ShowName, current, podcastFields(podcast))
+	return id, nil
+}
+
+// UpsertEpisode diffs an episode against the stored row
+func (s *dryRunStore) UpsertEpisode(ctx context.Context, episode *scrapers.Episode) (int64, error) {
+	var current []field
+	id := s.syntheticID()
+
+	if episode.PodcastID > 0 {
+		existing, err := s.source.GetEpisode(ctx, episode.PodcastID, episode.PlatformEpisodeID)
+		switch {
+		case err == nil:
+			id = existing.ID
+			current = episodeFields(existing)
+		case !errors.Is(err, repository.ErrNotFound):
+			return 0, err
+		}
+	}
+
+	platform := s.podcastPlatform[episode.PodcastID]
+	s.episodePlatform[id] = platform
+	s.labels[id] = episode.EpisodeTitle
+	s.record(platform, "episode", episode.EpisodeTitle, current, episodeFields(episode))
+	return id, nil
+}
+
+// RecordScraperError is a no-op; errors are logged as they happen
+func (s *dryRunStore) RecordScraperError(ctx context.Context, e *scrapers.ScraperError) error {
+	return nil
+}
+
+// LookupEpisodeID returns the stored episode's ID
+func (s *dryRunStore) LookupEpisodeID(ctx context.Context, podcastID int64, platformEpisodeID string) (int64, error) {
+	if podcastID <= 0 {
+		return 0, repository.ErrNotFound
+	}
+
+	existing, err := s.source.GetEpisode(ctx, podcastID, platformEpisodeID)
+	if err != nil {
+		return 0, err
+	}
+	return existing.ID, nil
+}
+
+// InTx runs fn directly; nothing is written to roll back
+func (s *dryRunStore) InTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	return fn(s)
+}
+
+// UpsertEpisodeMetrics diffs episode metrics against the stored row
+func (s *dryRunStore) UpsertEpisodeMetrics(ctx context.Context, metrics *scrapers.EpisodeMetrics) error {
+	var current []field
+
+	if metrics.EpisodeID > 0 {
+		existing, err := s.source.GetEpisodeMetrics(ctx, metrics.EpisodeID, metrics.MetricDate)
+		switch {
+		case err == nil:
+			current = episodeMetricsFields(existing)
+		case !errors.Is(err, repository.ErrNotFound):
+			return err
+		}
+	}
+
+	label := fmt.Sprintf("%s @ %s", s.labels[metrics.EpisodeID], metrics.MetricDate.Format("2006-01-02"))
+	s.record(s.episodePlatform[metrics.EpisodeID], "episode_metrics", label, current, episodeMetricsFields(metrics))
+	return nil
+}
+
+// UpsertEpisodeMetricsBatch diffs each row in turn
+func (s *dryRunStore) UpsertEpisodeMetricsBatch(ctx context.Context, metrics []*scrapers.EpisodeMetrics) []error {
+	rowErrs := make([]error, len(metrics))
+	for i, m := range metrics {
+		rowErrs[i] = s.UpsertEpisodeMetrics(ctx, m)
+	}
+	return rowErrs
+}
+
+// UpsertShowMetrics diffs show metrics against the stored row
+func (s *dryRunStore) UpsertShowMetrics(ctx context.Context, metrics *scrapers.ShowMetrics) error {
+	var current []field
+
+	if metrics.PodcastID > 0 {
+		existing, err := s.source.GetShowMetrics(ctx, metrics.PodcastID, metrics.MetricDate)
+		switch {
+		case err == nil:
+			current = showMetricsFields(existing)
+		case !errors.Is(err, repository.ErrNotFound):
+			return err
+		}
+	}
+
+	label := metrics.MetricDate.Format("2006-01-02")
+	s.record(s.podcastPlatform[metrics.PodcastID], "show_metrics", label, current, showMetricsFields(metrics))
+	return nil
+}
+
+// InsertComment checks whether a comment is new. Existing comments are
+// never updated, so they always count as unchanged.
+func (s *dryRunStore) InsertComment(ctx context.Context, comment *scrapers.Comment) error {
+	var current []field
+
+	if comment.EpisodeID > 0 {
+		exists, err := s.source.CommentExists(ctx, comment.EpisodeID, comment.PlatformCommentID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			current = []field{}
+		}
+	}
+
+	label := fmt.Sprintf("%s: %s", s.labels[comment.EpisodeID], comment.PlatformCommentID)
+	s.record(s.episodePlatform[comment.EpisodeID], "comment", label, current, []field{})
+	return nil
+}
+
+// RecordScraperRun hands out an ID; run bookkeeping is not reported
+func (s *dryRunStore) RecordScraperRun(ctx context.Context, run *scrapers.ScraperRun) (int64, error) {
+	return s.syntheticID(), nil
+}
+
+// UpdateScraperRun is a no-op
+func (s *dryRunStore) UpdateScraperRun(ctx context.Context, runID int64, run *scrapers.ScraperRun) error {
+	return nil
+}
+
+// Watermarks reads the real high-water marks so a dry run plans the same
+// windows a real run would
+func (s *dryRunStore) Watermarks(ctx context.Context, platform scrapers.Platform, podcastID int64) (map[int64]*repository.Watermark, error) {
+	if podcastID < 0 {
+		return map[int64]*repository.Watermark{}, nil
+	}
+	return s.source.Watermarks(ctx, platform, podcastID)
+}
+
+// SaveWatermark is a no-op; watermarks are bookkeeping, not data
+func (s *dryRunStore) SaveWatermark(ctx context.Context, wm *repository.Watermark) error {
+	return nil
+}
+
+// CompletedCheckpoints reads the real checkpoints so a dry run skips the
+// windows a real backfill would skip
+func (s *dryRunStore) CompletedCheckpoints(ctx context.Context, platform scrapers.Platform, podcastID int64, startDate, endDate time.Time) (map[repository.CheckpointKey]bool, error) {
+	if podcastID < 0 {
+		return map[repository.CheckpointKey]bool{}, nil
+	}
+	return s.source.CompletedCheckpoints(ctx, platform, podcastID, startDate, endDate)
+}
+
+// SaveCheckpoint is a no-op; checkpoints are bookkeeping, not data
+func (s *dryRunStore) SaveCheckpoint(ctx context.Context, cp *repository.BackfillCheckpoint) error {
+	return nil
+}
+
+// print writes every insert and update, then per-platform totals
+func (s *dryRunStore) print(out io.Writer) error {
+	for _, platform := range scrapers.AllPlatforms {
+		changes, ok := s.changes[platform]
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(out, "== %s ==\n", platform)
+		for _, change := range changes {
+			if change.action == actionUnchanged {
+				continue
+			}
+			fmt.Fprintf(out, "%-6s %-15s %s\n", change.action, change.kind, change.label)
+			for _, d := range change.diffs {
+				fmt.Fprintf(out, "         %s: %s -> %s\n", d.name, quoteValue(d.old), quoteValue(d.new))
+			}
+		}
+		fmt.Fprintln(out)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLATFORM\tKIND\tINSERT\tUPDATE\tUNCHANGED")
+	for _, platform := range scrapers.AllPlatforms {
+		changes, ok := s.changes[platform]
+		if !ok {
+			continue
+		}
+		for _, kind := range dryRunKinds {
+			counts := map[string]int{}
+			for _, change := range changes {
+				if change.kind == kind {
+					counts[change.action]++
+				}
+			}
+			if len(counts) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n",
+				platform, kind, counts[actionInsert], counts[actionUpdate], counts[actionUnchanged])
+		}
+	}
+	return w.Flush()
+}
+
+// diffFields returns the fields whose values differ
+func diffFields(current, proposed []field) []fieldDiff {
+	old := make(map[string]string, len(current))
+	for _, f := range current {
+		old[f.name] = f.value
+	}
+
+	var diffs []fieldDiff
+	for _, f := range proposed {
+		if old[f.name] != f.value {
+			diffs = append(diffs, fieldDiff{name: f.name, old: old[f.name], new: f.value})
+		}
+	}
+	return diffs
+}
+
+// quoteValue quotes a value for display, shortening long text
+func quoteValue(v string) string {
+	return strconv.Quote(truncate(v, 80))
+}
+
+// podcastFields lists the podcast columns UpsertPodcast writes
+func podcastFields(p *scrapers.Podcast) []field {
+	return []field{
+		{"show_name", p.ShowName},
+		{"description", p.Description},
+		{"author", p.Author},
+		{"categories", jsonValue(p.Categories)},
+		{"language", p.Language},
+	}
+}
+
+// episodeFields lists the episode columns UpsertEpisode writes
+func episodeFields(e *scrapers.Episode) []field {
+	publishDate := ""
+	if !e.PublishDate.IsZero() {
+		publishDate = e.PublishDate.UTC().Format(time.RFC3339)
+	}
+	return []field{
+		{"episode_title", e.EpisodeTitle},
+		{"description", e.Description},
+		{"duration_seconds", strconv.Itoa(e.DurationSeconds)},
+		{"publish_date", publishDate},
+		{"season_number", intPtrValue(e.SeasonNumber)},
+		{"episode_number", intPtrValue(e.EpisodeNumber)},
+	}
+}
+
+// episodeMetricsFields lists the typed and JSON columns UpsertEpisodeMetrics
+// writes. raw_data is left out: it is a debugging copy of the response and
+// would flag every row as changed.
+func episodeMetricsFields(m *scrapers.EpisodeMetrics) []field {
+	rec := &repository.EpisodeMetricsRecord{Metrics: m}
+
+	var fields []field
+	for _, col := range metricsColumns {
+		switch col.name {
+		case "show_name", "platform", "podcast_platform_id", "platform_episode_id", "episode_title", "metric_date":
+			continue
+		}
+		fields = append(fields, field{col.name, col.get(rec)})
+	}
+
+	return append(fields,
+		field{"top_countries", jsonValue(m.TopCountries)},
+		field{"top_cities", jsonValue(m.TopCities)},
+		field{"device_breakdown", jsonValue(m.DeviceBreakdown)},
+	)
+}
+
+// showMetricsFields lists the typed and JSON columns UpsertShowMetrics writes
+func showMetricsFields(m *scrapers.ShowMetrics) []field {
+	return []field{
+		{"total_plays", strconv.FormatInt(m.TotalPlays, 10)},
+		{"total_listeners", strconv.FormatInt(m.TotalListeners, 10)},
+		{"total_engaged_listeners", strconv.FormatInt(m.TotalEngagedListeners, 10)},
+		{"total_views", strconv.FormatInt(m.TotalViews, 10)},
+		{"total_downloads", strconv.FormatInt(m.TotalDownloads, 10)},
+		{"followers_total", int64PtrValue(m.FollowersTotal)},
+		{"followers_gained", strconv.Itoa(m.FollowersGained)},
+		{"followers_lost", strconv.Itoa(m.FollowersLost)},
+		{"subscribers_total", int64PtrValue(m.SubscribersTotal)},
+		{"subscribers_gained", strconv.Itoa(m.SubscribersGained)},
+		{"subscribers_lost", strconv.Itoa(m.SubscribersLost)},
+		{"average_completion_rate", floatPtrValue(m.AverageCompletionRate)},
+		{"total_comments", strconv.FormatInt(m.TotalComments, 10)},
+		{"total_likes", strconv.FormatInt(m.TotalLikes, 10)},
+		{"total_shares", strconv.FormatInt(m.TotalShares, 10)},
+		{"top_countries", jsonValue(m.TopCountries)},
+		{"top_cities", jsonValue(m.TopCities)},
+	}
+}
+
+func jsonValue(v map[string]interface{}) string {
+	if len(v) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return string(data)
+}
+
+func intPtrValue(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func int64PtrValue(v *int64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(*v, 10)
+}
+
+// floatPtrValue renders with two decimals to match the DECIMAL(5,2) columns
+func floatPtrValue(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', 2, 64)
+}
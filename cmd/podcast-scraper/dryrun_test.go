@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// fakeDryRunSource serves stored rows from maps
+type fakeDryRunSource struct {
+	podcasts       map[string]*scrapers.Podcast        // by platform ID
+	episodes       map[string]*scrapers.Episode        // by platform episode ID
+	episodeMetrics map[string]*scrapers.EpisodeMetrics // by "<episode ID> <date>"
+	showMetrics    map[string]*scrapers.ShowMetrics    // by "<podcast ID> <date>"
+	comments       map[string]bool                     // by platform comment ID
+}
+
+func metricsKey(id int64, date time.Time) string {
+	return fmt.Sprintf("%d %s", id, date.Format("2006-01-02"))
+}
+
+func (f *fakeDryRunSource) GetPodcast(ctx context.Context, platform scrapers.Platform, platformID string) (*scrapers.Podcast, error) {
+	if p, ok := f.podcasts[platformID]; ok {
+		return p, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeDryRunSource) GetEpisode(ctx context.Context, podcastID int64, platformEpisodeID string) (*scrapers.Episode, error) {
+	if e, ok := f.episodes[platformEpisodeID]; ok && e.PodcastID == podcastID {
+		return e, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeDryRunSource) GetEpisodeMetrics(ctx context.Context, episodeID int64, metricDate time.Time) (*scrapers.EpisodeMetrics, error) {
+	if m, ok := f.episodeMetrics[metricsKey(episodeID, metricDate)]; ok {
+		return m, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeDryRunSource) GetShowMetrics(ctx context.Context, podcastID int64, metricDate time.Time) (*scrapers.ShowMetrics, error) {
+	if m, ok := f.showMetrics[metricsKey(podcastID, metricDate)]; ok {
+		return m, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeDryRunSource) CommentExists(ctx context.Context, episodeID int64, platformCommentID string) (bool, error) {
+	return f.comments[platformCommentID], nil
+}
+
+func (f *fakeDryRunSource) Watermarks(ctx context.Context, platform scrapers.Platform, podcastID int64) (map[int64]*repository.Watermark, error) {
+	return map[int64]*repository.Watermark{}, nil
+}
+
+func (f *fakeDryRunSource) CompletedCheckpoints(ctx context.Context, platform scrapers.Platform, podcastID int64, startDate, endDate time.Time) (map[repository.CheckpointKey]bool, error) {
+	return map[repository.CheckpointKey]bool{}, nil
+}
+
+func TestDryRunClassifiesWrites(t *testing.T) {
+	// ep-1 and its first day are stored as the fake scraper serves them, the
+	// second day with fewer plays; ep-2 and the show metrics are new
+	source := &fakeDryRunSource{
+		podcasts: map[string]*scrapers.Podcast{
+			"show-1": {ID: 1, ShowName: "Test Show", Platform: scrapers.PlatformSpotify, PlatformID: "show-1"},
+		},
+		episodes: map[string]*scrapers.Episode{
+			"ep-1": {ID: 10, PodcastID: 1, PlatformEpisodeID: "ep-1", EpisodeTitle: "Pilot", PublishDate: day(2026, 1, 1)},
+		},
+		episodeMetrics: map[string]*scrapers.EpisodeMetrics{
+			metricsKey(10, day(2026, 2, 1)): {EpisodeID: 10, MetricDate: day(2026, 2, 1), Plays: 10, Listeners: 8},
+			metricsKey(10, day(2026, 2, 2)): {EpisodeID: 10, MetricDate: day(2026, 2, 2), Plays: 11, Listeners: 9},
+		},
+		comments: map[string]bool{"c-1": true},
+	}
+
+	report := newDryRunStore(source)
+	collector := NewCollector(report, report, []scrapers.Scraper{newFakeScraper()})
+	if err := collector.Collect(context.Background(), testCollectOptions()); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	got := map[string]int{}
+	for _, change := range report.changes[scrapers.PlatformSpotify] {
+		got[change.kind+" "+change.action]++
+	}
+	want := map[string]int{
+		"podcast unchanged":         1,
+		"episode unchanged":         1,
+		"episode insert":            1,
+		"episode_metrics unchanged": 1,
+		"episode_metrics update":    1,
+		"episode_metrics insert":    1,
+		"show_metrics insert":       1,
+		"comment unchanged":         1,
+	}
+	for key, n := range want {
+		if got[key] != n {
+			t.Errorf("%s = %d, want %d", key, got[key], n)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+
+	var out strings.Builder
+	if err := report.print(&out); err != nil {
+		t.Fatalf("print() error = %v", err)
+	}
+	printed := out.String()
+	for _, line := range []string{"update episode_metrics Pilot @ 2026-02-02", `plays: "11" -> "12"`} {
+		if !strings.Contains(printed, line) {
+			t.Errorf("report = %q, want it to contain %q", printed, line)
+		}
+	}
+	if strings.Contains(printed, "listeners:") {
+		t.Errorf("report = %q, want unchanged fields left out", printed)
+	}
+}
+
+func TestDiffFields(t *testing.T) {
+	current := []field{{"plays", "10"}, {"listeners", "8"}, {"top_countries", ""}}
+	proposed := []field{{"plays", "12"}, {"listeners", "8"}, {"top_countries", `{"US":3}`}}
+
+	diffs := diffFields(current, proposed)
+	want := []fieldDiff{{"plays", "10", "12"}, {"top_countries", "", `{"US":3}`}}
+	if len(diffs) != len(want) {
+		t.Fatalf("diffFields() = %v, want %v", diffs, want)
+	}
+	for i := range want {
+		if diffs[i] != want[i] {
+			t.Errorf("diffFields()[%d] = %v, want %v", i, diffs[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// metricsColumn maps one CSV column to a field of an episode metrics record.
+// The same column list drives export and import so the two stay compatible.
+type metricsColumn struct {
+	name string
+	get  func(rec *repository.EpisodeMetricsRecord) string
+	set  func(rec *repository.EpisodeMetricsRecord, value string) error
+}
+
+// metricsColumns is the CSV layout used by export and import
+var metricsColumns = []metricsColumn{
+	stringColumn("show_name", func(r *repository.EpisodeMetricsRecord) *string { return &r.ShowName }),
+	{
+		name: "platform",
+		get:  func(r *repository.EpisodeMetricsRecord) string { return string(r.Platform) },
+		set: func(r *repository.EpisodeMetricsRecord, v string) error {
+			p, err := scrapers.ParsePlatform(v)
+			r.Platform = p
+			return err
+		},
+	},
+	stringColumn("podcast_platform_id", func(r *repository.EpisodeMetricsRecord) *string { return &r.PodcastPlatformID }),
+	stringColumn("platform_episode_id", func(r *repository.EpisodeMetricsRecord) *string { return &r.PlatformEpisodeID }),
+	stringColumn("episode_title", func(r *repository.EpisodeMetricsRecord) *string { return &r.EpisodeTitle }),
+	{
+		name: "metric_date",
+		get:  func(r *repository.EpisodeMetricsRecord) string { return r.Metrics.MetricDate.Format("2006-01-02") },
+		set: func(r *repository.EpisodeMetricsRecord, v string) error {
+			t, err := time.Parse("2006-01-02", v)
+			r.Metrics.MetricDate = t
+			return err
+		},
+	},
+	int64Column("plays", func(m *scrapers.EpisodeMetrics) *int64 { return &m.Plays }),
+	int64Column("listeners", func(m *scrapers.EpisodeMetrics) *int64 { return &m.Listeners }),
+	int64Column("engaged_listeners", func(m *scrapers.EpisodeMetrics) *int64 { return &m.EngagedListeners }),
+	int64Column("views", func(m *scrapers.EpisodeMetrics) *int64 { return &m.Views }),
+	int64Column("likes", func(m *scrapers.EpisodeMetrics) *int64 { return &m.Likes }),
+	int64Column("dislikes", func(m *scrapers.EpisodeMetrics) *int64 { return &m.Dislikes }),
+	int64Column("comments_count", func(m *scrapers.EpisodeMetrics) *int64 { return &m.CommentsCount }),
+	int64Column("shares", func(m *scrapers.EpisodeMetrics) *int64 { return &m.Shares }),
+	int64Column("watch_time_minutes", func(m *scrapers.EpisodeMetrics) *int64 { return &m.WatchTimeMinutes }),
+	intColumn("average_view_duration_seconds", func(m *scrapers.EpisodeMetrics) *int { return &m.AverageViewDuration }),
+	intColumn("subscribers_gained", func(m *scrapers.EpisodeMetrics) *int { return &m.SubscribersGained }),
+	intColumn("subscribers_lost", func(m *scrapers.EpisodeMetrics) *int { return &m.SubscribersLost }),
+	int64Column("downloads", func(m *scrapers.EpisodeMetrics) *int64 { return &m.Downloads }),
+	int64Column("streams", func(m *scrapers.EpisodeMetrics) *int64 { return &m.Streams }),
+	{
+		name: "completion_rate",
+		get: func(r *repository.EpisodeMetricsRecord) string {
+			if r.Metrics.CompletionRate == nil {
+				return ""
+			}
+			return strconv.FormatFloat(*r.Metrics.CompletionRate, 'f', 2, 64)
+		},
+		set: func(r *repository.EpisodeMetricsRecord, v string) error {
+			if v == "" {
+				return nil
+			}
+			f, err := strconv.ParseFloat(v, 64)
+			r.Metrics.CompletionRate = &f
+			return err
+		},
+	},
+	{
+		name: "average_listen_time_seconds",
+		get: func(r *repository.EpisodeMetricsRecord) string {
+			if r.Metrics.AverageListenTime == nil {
+				return ""
+			}
+			return strconv.Itoa(*r.Metrics.AverageListenTime)
+		},
+		set: func(r *repository.EpisodeMetricsRecord, v string) error {
+			if v == "" {
+				return nil
+			}
+			n, err := strconv.Atoi(v)
+			r.Metrics.AverageListenTime = &n
+			return err
+		},
+	},
+	{
+		name: "followers_total",
+		get: func(r *repository.EpisodeMetricsRecord) string {
+			if r.Metrics.FollowersTotal == nil {
+				return ""
+			}
+			return strconv.FormatInt(*r.Metrics.FollowersTotal, 10)
+		},
+		set: func(r *repository.EpisodeMetricsRecord, v string) error {
+			if v == "" {
+				return nil
+			}
+			n, err := strconv.ParseInt(v, 10, 64)
+			r.Metrics.FollowersTotal = &n
+			return err
+		},
+	},
+	intColumn("followers_gained", func(m *scrapers.EpisodeMetrics) *int { return &m.FollowersGained }),
+	intColumn("followers_lost", func(m *scrapers.EpisodeMetrics) *int { return &m.FollowersLost }),
+}
+
+func stringColumn(name string, field func(r *repository.EpisodeMetricsRecord) *string) metricsColumn {
+	return metricsColumn{
+		name: name,
+		get:  func(r *repository.EpisodeMetricsRecord) string { return *field(r) },
+		set: func(r *repository.EpisodeMetricsRecord, v string) error {
+			*field(r) = v
+			return nil
+		},
+	}
+}
+
+func int64Column(name string, field func(m *scrapers.EpisodeMetrics) *int64) metricsColumn {
+	return metricsColumn{
+		name: name,
+		get:  func(r *repository.EpisodeMetricsRecord) string { return strconv.FormatInt(*field(r.Metrics), 10) },
+		set: func(r *repository.EpisodeMetricsRecord, v string) error {
+			if v == "" {
+				return nil
+			}
+			n, err := strconv.ParseInt(v, 10, 64)
+			*field(r.Metrics) = n
+			return err
+		},
+	}
+}
+
+func intColumn(name string, field func(m *scrapers.EpisodeMetrics) *int) metricsColumn {
+	return metricsColumn{
+		name: name,
+		get:  func(r *repository.EpisodeMetricsRecord) string { return strconv.Itoa(*field(r.Metrics)) },
+		set: func(r *repository.EpisodeMetricsRecord, v string) error {
+			if v == "" {
+				return nil
+			}
+			n, err := strconv.Atoi(v)
+			*field(r.Metrics) = n
+			return err
+		},
+	}
+}
+
+// runExport implements `podcast-scraper export`
+func runExport(ctx context.Context, config *Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	var f commandFlags
+	f.registerFilter(fs)
+	f.registerDateRange(fs)
+	output := fs.String("output", "-", "output file, or - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := f.apply(config); err != nil {
+		return err
+	}
+
+	startDate, endDate, err := f.dateRange(config.LookbackDays)
+	if err != nil {
+		return err
+	}
+
+	db, err := connectDatabase(config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	records, err := repository.NewPodcastRepository(db).ListEpisodeMetrics(ctx, repository.MetricsFilter{
+		ShowName:  config.ShowName,
+		Platforms: config.Platforms,
+		StartDate: startDate,
+		EndDate:   endDate,
+	})
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *output, err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := writeMetricsCSV(out, records); err != nil {
+		return err
+	}
+
+	if *output != "-" {
+		fmt.Fprintf(os.Stderr, "Exported %d rows to %s\n", len(records), *output)
+	}
+	return nil
+}
+
+// writeMetricsCSV writes records using the metricsColumns layout
+func writeMetricsCSV(out io.Writer, records []*repository.EpisodeMetricsRecord) error {
+	w := csv.NewWriter(out)
+
+	header := make([]string, len(metricsColumns))
+	for i, col := range metricsColumns {
+		header[i] = col.name
+	}
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	row := make([]string, len(metricsColumns))
+	for _, rec := range records {
+		for i, col := range metricsColumns {
+			row[i] = col.get(rec)
+		}
+		if err := w.Write(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
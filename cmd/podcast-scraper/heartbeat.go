@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// heartbeatStore is implemented by stores that track whether running runs
+// are still alive
+type heartbeatStore interface {
+	HeartbeatScraperRun(ctx context.Context, runID int64, episodesProcessed, metricsCollected int) error
+}
+
+// staleRunStore is implemented by stores that can mark runs whose process
+// died as abandoned
+type staleRunStore interface {
+	AbandonStaleRuns(ctx context.Context, staleAfter time.Duration) ([]*scrapers.ScraperRun, error)
+}
+
+// stagingReaper is implemented by stores that can drop the staging tables
+// of staged runs that ended without publishing or discarding them
+type stagingReaper interface {
+	DropOrphanedStaging(ctx context.Context) ([]int64, error)
+}
+
+// runHeartbeat saves a running run's progress to its row every interval, so
+// the run is not reaped while its process is alive
+type runHeartbeat struct {
+	mu                sync.Mutex
+	episodesProcessed int
+	metricsCollected  int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startHeartbeat starts heartbeats for runID when the store supports them.
+// Heartbeats go on until stopped, even when ctx is cancelled, so a run that
+// is draining stays alive.
+func startHeartbeat(ctx context.Context, store repository.Store, runID int64, interval time.Duration) *runHeartbeat {
+	h := &runHeartbeat{stop: make(chan struct{}), done: make(chan struct{})}
+
+	hs, ok := store.(heartbeatStore)
+	if !ok || interval <= 0 {
+		close(h.done)
+		return h
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer close(h.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-h.stop:
+				return
+			case <-ticker.C:
+			}
+
+			h.mu.Lock()
+			episodes, metrics := h.episodesProcessed, h.metricsCollected
+			h.mu.Unlock()
+
+			if err := hs.HeartbeatScraperRun(ctx, runID, episodes, metrics); err != nil {
+				slog.WarnContext(ctx, "Failed to send run heartbeat", logging.KeyError, err)
+			}
+		}
+	}()
+	return h
+}
+
+// progress notes the run's counters for the next heartbeat
+func (h *runHeartbeat) progress(run *scrapers.ScraperRun) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.episodesProcessed, h.metricsCollected = run.EpisodesProcessed, run.MetricsCollected
+}
+
+// end stops the heartbeats and waits for one in flight to finish
+func (h *runHeartbeat) end() {
+	select {
+	case <-h.done:
+	default:
+		close(h.stop)
+		<-h.done
+	}
+}
+
+// reapStaleRuns marks runs that stopped sending heartbeats as abandoned and
+// drops staging tables no running run owns
+func reapStaleRuns(ctx context.Context, store repository.Store, staleAfter time.Duration) {
+	reaper, ok := store.(staleRunStore)
+	if !ok || staleAfter <= 0 {
+		return
+	}
+
+	runs, err := reaper.AbandonStaleRuns(ctx, staleAfter)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to reap stale runs", logging.KeyError, err)
+		return
+	}
+
+	for _, run := range runs {
+		slog.WarnContext(ctx, "Marked stale run abandoned", logging.KeyRunID, run.ID, logging.KeyPlatform, run.Platform,
+			"started_at", run.RunStartedAt.Format(time.RFC3339), "episodes", run.EpisodesProcessed, "metrics", run.MetricsCollected)
+		promMetrics.ObserveRun(run)
+	}
+
+	if sr, ok := store.(stagingReaper); ok {
+		runIDs, err := sr.DropOrphanedStaging(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to drop orphaned staging tables", logging.KeyError, err)
+			return
+		}
+		for _, runID := range runIDs {
+			slog.WarnContext(ctx, "Dropped staging tables of a run that never published", logging.KeyRunID, runID)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// reaperStore is a memory store that reaps stale runs and orphaned staging
+type reaperStore struct {
+	*repository.MemoryStore
+	abandonErr error
+
+	mu         sync.Mutex
+	staleAfter []time.Duration
+	drops      int
+	heartbeats [][2]int
+}
+
+func (s *reaperStore) AbandonStaleRuns(ctx context.Context, staleAfter time.Duration) ([]*scrapers.ScraperRun, error) {
+	s.staleAfter = append(s.staleAfter, staleAfter)
+	if s.abandonErr != nil {
+		return nil, s.abandonErr
+	}
+	return []*scrapers.ScraperRun{{ID: 7, Platform: scrapers.PlatformSpotify, Status: scrapers.RunStatusAbandoned, RunStartedAt: time.Now()}}, nil
+}
+
+func (s *reaperStore) DropOrphanedStaging(ctx context.Context) ([]int64, error) {
+	s.drops++
+	return []int64{7}, nil
+}
+
+func (s *reaperStore) HeartbeatScraperRun(ctx context.Context, runID int64, episodesProcessed, metricsCollected int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.heartbeats = append(s.heartbeats, [2]int{episodesProcessed, metricsCollected})
+	return nil
+}
+
+func TestReapStaleRuns(t *testing.T) {
+	ctx := context.Background()
+
+	store := &reaperStore{MemoryStore: repository.NewMemoryStore()}
+	reapStaleRuns(ctx, store, 15*time.Minute)
+	if len(store.staleAfter) != 1 || store.staleAfter[0] != 15*time.Minute {
+		t.Errorf("AbandonStaleRuns() calls = %v, want one with 15m", store.staleAfter)
+	}
+	if store.drops != 1 {
+		t.Errorf("DropOrphanedStaging() calls = %d, want 1", store.drops)
+	}
+
+	// Reaping is off without a staleness threshold
+	store = &reaperStore{MemoryStore: repository.NewMemoryStore()}
+	reapStaleRuns(ctx, store, 0)
+	if len(store.staleAfter) != 0 || store.drops != 0 {
+		t.Errorf("reaped with StaleRunAfter 0: %d abandon and %d drop calls", len(store.staleAfter), store.drops)
+	}
+
+	// Staging tables are left alone while the runs owning them cannot be reaped
+	store = &reaperStore{MemoryStore: repository.NewMemoryStore(), abandonErr: errors.New("connection refused")}
+	reapStaleRuns(ctx, store, 15*time.Minute)
+	if store.drops != 0 {
+		t.Errorf("DropOrphanedStaging() calls after a failed reap = %d, want 0", store.drops)
+	}
+
+	// Stores without reaping are skipped
+	reapStaleRuns(ctx, repository.NewMemoryStore(), 15*time.Minute)
+}
+
+func TestHeartbeatSendsProgress(t *testing.T) {
+	store := &reaperStore{MemoryStore: repository.NewMemoryStore()}
+
+	h := startHeartbeat(context.Background(), store, 1, time.Millisecond)
+	h.progress(&scrapers.ScraperRun{EpisodesProcessed: 3, MetricsCollected: 12})
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		store.mu.Lock()
+		var last [2]int
+		if n := len(store.heartbeats); n > 0 {
+			last = store.heartbeats[n-1]
+		}
+		store.mu.Unlock()
+
+		if last == [2]int{3, 12} {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("last heartbeat = %v, want [3 12]", last)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	h.end()
+
+	store.mu.Lock()
+	sent := len(store.heartbeats)
+	store.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.heartbeats) != sent {
+		t.Errorf("heartbeats after end() = %d, want %d", len(store.heartbeats), sent)
+	}
+}
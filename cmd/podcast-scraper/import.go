@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// runImport implements `podcast-scraper import`. It loads episode metrics
+// written by export (or any CSV with the same columns) and upserts them
+// against episodes that already exist in the database.
+func runImport(ctx context.Context, config *Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	var f commandFlags
+	f.registerFilter(fs)
+	f.registerDateRange(fs)
+	f.registerDryRun(fs)
+	input := fs.String("file", "-", "CSV file to import, or - for stdin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := f.apply(config); err != nil {
+		return err
+	}
+
+	// Unlike collection, import only filters by date when asked to
+	var filter repository.MetricsFilter
+	filter.ShowName = f.show
+	filter.Platforms = config.Platforms
+	if f.from != "" || f.to != "" {
+		startDate, endDate, err := f.dateRange(config.LookbackDays)
+		if err != nil {
+			return err
+		}
+		filter.StartDate = startDate
+		filter.EndDate = endDate
+	}
+
+	var in io.Reader = os.Stdin
+	if *input != "-" {
+		file, err := os.Open(*input)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", *input, err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	records, err := readMetricsCSV(in)
+	if err != nil {
+		return err
+	}
+
+	db, err := connectDatabase(config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := checkSchema(ctx, config, db); err != nil {
+		return err
+	}
+
+	repo := repository.NewPodcastRepository(db)
+
+	var imported, skipped, failed int
+	batch := newMetricsBatch(repo, config.MetricsBatchSize, config.MetricsFlushInterval)
+	checks := newQualityChecks(config.Quality, repo, f.dryRun)
+	episodeIDs := make(map[string]int64)
+	for i, rec := range records {
+		if !matchesFilter(rec, filter) {
+			skipped++
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%s", rec.Platform, rec.PodcastPlatformID, rec.PlatformEpisodeID)
+		episodeID, ok := episodeIDs[key]
+		if !ok {
+			episodeID, err = repo.FindEpisodeID(ctx, rec.Platform, rec.PodcastPlatformID, rec.PlatformEpisodeID)
+			if errors.Is(err, repository.ErrNotFound) {
+				slog.WarnContext(ctx, "No episode for row, skipping", "row", i+2, logging.KeyPlatform, rec.Platform,
+					logging.KeyEpisodeID, rec.PlatformEpisodeID)
+				failed++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			episodeIDs[key] = episodeID
+		}
+		rec.Metrics.EpisodeID = episodeID
+
+		episode := &scrapers.Episode{
+			ID:                episodeID,
+			EpisodeTitle:      rec.EpisodeTitle,
+			PlatformEpisodeID: rec.PlatformEpisodeID,
+		}
+		metrics := checks.platform(rec.Platform).episodeMetrics(ctx, episode, []*scrapers.EpisodeMetrics{rec.Metrics})
+		if len(metrics) == 0 {
+			continue
+		}
+
+		if f.dryRun {
+			imported++
+			continue
+		}
+
+		row := i + 2
+		batch.add(ctx, metrics, func(ctx context.Context, written int, errs []error) {
+			imported += written
+			for _, err := range errs {
+				slog.ErrorContext(ctx, "Failed to import row", "row", row, logging.KeyError, err)
+				failed++
+			}
+		})
+	}
+	batch.flush(ctx)
+
+	verb := "Imported"
+	if f.dryRun {
+		verb = "Would import"
+	}
+	quarantined, rejected := checks.counts()
+	fmt.Printf("%s %d rows (%d filtered out, %d failed, %d quarantined, %d rejected)\n",
+		verb, imported, skipped, failed, quarantined, rejected)
+
+	if failed > 0 {
+		return errUnhealthy
+	}
+	return nil
+}
+
+// readMetricsCSV parses a CSV with a metricsColumns header. Columns may
+// appear in any order; unknown columns are ignored and missing metric
+// columns are left at zero.
+func readMetricsCSV(in io.Reader) ([]*repository.EpisodeMetricsRecord, error) {
+	r := csv.NewReader(in)
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make([]*metricsColumn, len(header))
+	for i, name := range header {
+		for j := range metricsColumns {
+			if metricsColumns[j].name == name {
+				columns[i] = &metricsColumns[j]
+				break
+			}
+		}
+	}
+
+	var records []*repository.EpisodeMetricsRecord
+	for line := 2; ; line++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		rec := &repository.EpisodeMetricsRecord{Metrics: &scrapers.EpisodeMetrics{}}
+		for i, value := range row {
+			if columns[i] == nil {
+				continue
+			}
+			if err := columns[i].set(rec, value); err != nil {
+				return nil, fmt.Errorf("line %d, column %s: %w", line, columns[i].name, err)
+			}
+		}
+
+		if rec.Platform == "" || rec.PlatformEpisodeID == "" || rec.Metrics.MetricDate.IsZero() {
+			return nil, fmt.Errorf("line %d: platform, platform_episode_id and metric_date are required", line)
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// matchesFilter applies a metrics filter to a parsed record
+func matchesFilter(rec *repository.EpisodeMetricsRecord, filter repository.MetricsFilter) bool {
+	if filter.ShowName != "" && rec.ShowName != filter.ShowName {
+		return false
+	}
+	if len(filter.Platforms) > 0 {
+		found := false
+		for _, p := range filter.Platforms {
+			if p == rec.Platform {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	date := rec.Metrics.MetricDate
+	if !filter.StartDate.IsZero() && date.Before(filter.StartDate) {
+		return false
+	}
+	if !filter.EndDate.IsZero() && date.After(filter.EndDate) {
+		return false
+	}
+	return true
+}
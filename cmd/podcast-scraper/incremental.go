@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// watermarkStore persists per-episode collection high-water marks
+type watermarkStore interface {
+	Watermarks(ctx context.Context, platform scrapers.Platform, podcastID int64) (map[int64]*repository.Watermark, error)
+	SaveWatermark(ctx context.Context, wm *repository.Watermark) error
+}
+
+// Adaptive polling tiers: episodes idle for longer are polled less often
+var pollTiers = []struct {
+	idleFor  time.Duration
+	interval time.Duration
+}{
+	{idleFor: 180 * 24 * time.Hour, interval: 30 * 24 * time.Hour},
+	{idleFor: 30 * 24 * time.Hour, interval: 7 * 24 * time.Hour},
+}
+
+// pollInterval returns how long to wait between polls of an episode. Activity
+// is measured from the last day with any plays, falling back to the publish
+// date; episodes with neither are always polled.
+func pollInterval(wm *repository.Watermark, publishDate, now time.Time) time.Duration {
+	ref := publishDate
+	if wm.LastActivityDate != nil && wm.LastActivityDate.After(ref) {
+		ref = *wm.LastActivityDate
+	}
+	if ref.IsZero() {
+		return 0
+	}
+
+	idle := now.Sub(ref)
+	for _, tier := range pollTiers {
+		if idle >= tier.idleFor {
+			return tier.interval
+		}
+	}
+	return 0
+}
+
+// incrementalPlan decides the fetch window for each episode of one platform
+// and computes the watermarks to save after a successful fetch
+type incrementalPlan struct {
+	platform    scrapers.Platform
+	podcastID   int64
+	opts        CollectOptions
+	now         time.Time
+	finalCutoff time.Time
+	marks       map[int64]*repository.Watermark // by episode row ID
+	show        *repository.Watermark
+	idle        int
+}
+
+// newIncrementalPlan creates a plan with no watermarks loaded
+func newIncrementalPlan(platform scrapers.Platform, podcastID int64, opts CollectOptions, now time.Time) *incrementalPlan {
+	return &incrementalPlan{
+		platform:    platform,
+		podcastID:   podcastID,
+		opts:        opts,
+		now:         now,
+		finalCutoff: truncateDay(now).AddDate(0, 0, -scrapers.FinalizationLagDays[platform]),
+	}
+}
+
+// setMarks loads the watermarks of a podcast, where the show's is stored
+// under episode ID 0
+func (p *incrementalPlan) setMarks(marks map[int64]*repository.Watermark) {
+	p.marks = make(map[int64]*repository.Watermark, len(marks))
+	for id, wm := range marks {
+		if id == 0 {
+			p.show = wm
+			continue
+		}
+		p.marks[id] = wm
+	}
+}
+
+// window returns the start date to fetch from for an episode and whether it
+// is due to be polled at all. An episodeID of 0 is an episode not stored
+// yet, which is always fetched from the start date.
+func (p *incrementalPlan) window(episodeID int64, publishDate time.Time) (time.Time, bool) {
+	if episodeID == 0 {
+		return p.opts.StartDate, true
+	}
+
+	wm := p.marks[episodeID]
+	if !p.opts.Incremental || wm == nil {
+		return p.opts.StartDate, true
+	}
+
+	if p.opts.AdaptivePolling {
+		if p.now.Sub(wm.LastPolledAt) < pollInterval(wm, publishDate, p.now) {
+			return time.Time{}, false
+		}
+	}
+
+	return p.restated(wm), true
+}
+
+// showWindow returns the start date to fetch show metrics from
+func (p *incrementalPlan) showWindow() time.Time {
+	if !p.opts.Incremental || p.show == nil {
+		return p.opts.StartDate
+	}
+	return p.restated(p.show)
+}
+
+// restated is the start of the window after wm that platforms may still restate
+func (p *incrementalPlan) restated(wm *repository.Watermark) time.Time {
+	return wm.FinalMetricDate.AddDate(0, 0, -p.opts.RestatementDays)
+}
+
+// finalDate is the latest metric date a fetch through EndDate has made final
+func (p *incrementalPlan) finalDate() time.Time {
+	end := truncateDay(p.opts.EndDate)
+	if end.After(p.finalCutoff) {
+		return p.finalCutoff
+	}
+	return end
+}
+
+// advanceEpisode returns the new watermark after every metric row of an
+// episode was stored
+func (p *incrementalPlan) advanceEpisode(episodeID int64, metrics []*scrapers.EpisodeMetrics, complete bool) *repository.Watermark {
+	if !complete {
+		return nil
+	}
+
+	var activity *time.Time
+	for _, m := range metrics {
+		if m.MetricDate.IsZero() {
+			continue
+		}
+		if m.Plays+m.Listeners+m.Views+m.Downloads+m.Streams == 0 {
+			continue
+		}
+		d := truncateDay(m.MetricDate)
+		if activity == nil || d.After(*activity) {
+			activity = &d
+		}
+	}
+
+	return p.watermark(episodeID, activity)
+}
+
+// advanceShow returns the new show-level watermark after every show metric
+// row was stored
+func (p *incrementalPlan) advanceShow(metrics []*scrapers.ShowMetrics, complete bool) *repository.Watermark {
+	if !complete {
+		return nil
+	}
+
+	var activity *time.Time
+	for _, m := range metrics {
+		if m.MetricDate.IsZero() {
+			continue
+		}
+		if m.TotalPlays+m.TotalListeners+m.TotalViews+m.TotalDownloads == 0 {
+			continue
+		}
+		d := truncateDay(m.MetricDate)
+		if activity == nil || d.After(*activity) {
+			activity = &d
+		}
+	}
+
+	return p.watermark(0, activity)
+}
+
+func (p *incrementalPlan) watermark(episodeID int64, activity *time.Time) *repository.Watermark {
+	return &repository.Watermark{
+		Platform:         p.platform,
+		PodcastID:        p.podcastID,
+		EpisodeID:        episodeID,
+		FinalMetricDate:  p.finalDate(),
+		LastActivityDate: activity,
+		LastPolledAt:     p.now,
+	}
+}
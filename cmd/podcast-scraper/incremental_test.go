@@ -0,0 +1,181 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+func TestPollInterval(t *testing.T) {
+	now := day(2026, 6, 1)
+	active := func(d time.Time) *time.Time { return &d }
+
+	tests := []struct {
+		name        string
+		activity    *time.Time
+		publishDate time.Time
+		want        time.Duration
+	}{
+		{name: "no activity or publish date", want: 0},
+		{name: "published recently", publishDate: now.AddDate(0, 0, -10), want: 0},
+		{name: "idle for a month", publishDate: now.AddDate(0, 0, -30), want: 7 * 24 * time.Hour},
+		{name: "idle for half a year", publishDate: now.AddDate(0, 0, -180), want: 30 * 24 * time.Hour},
+		{
+			name:        "recent plays on an old episode",
+			activity:    active(now.AddDate(0, 0, -3)),
+			publishDate: now.AddDate(-2, 0, 0),
+			want:        0,
+		},
+		{
+			name:        "activity older than publish date is ignored",
+			activity:    active(now.AddDate(-1, 0, 0)),
+			publishDate: now.AddDate(0, 0, -40),
+			want:        7 * 24 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wm := &repository.Watermark{LastActivityDate: tt.activity}
+			if got := pollInterval(wm, tt.publishDate, now); got != tt.want {
+				t.Errorf("pollInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncrementalPlanWindow(t *testing.T) {
+	now := day(2026, 6, 1)
+	start := day(2026, 1, 1)
+	published := day(2025, 1, 1)
+
+	tests := []struct {
+		name        string
+		incremental bool
+		adaptive    bool
+		episodeID   int64
+		mark        *repository.Watermark
+		wantStart   time.Time
+		wantDue     bool
+	}{
+		{
+			name:      "full collection ignores watermarks",
+			episodeID: 1,
+			mark:      &repository.Watermark{FinalMetricDate: day(2026, 5, 20)},
+			wantStart: start,
+			wantDue:   true,
+		},
+		{
+			name:        "no watermark starts at the start date",
+			incremental: true,
+			episodeID:   1,
+			wantStart:   start,
+			wantDue:     true,
+		},
+		{
+			name:        "new episode starts at the start date",
+			incremental: true,
+			episodeID:   0,
+			wantStart:   start,
+			wantDue:     true,
+		},
+		{
+			name:        "watermark less restatement days",
+			incremental: true,
+			episodeID:   1,
+			mark:        &repository.Watermark{FinalMetricDate: day(2026, 5, 20)},
+			wantStart:   day(2026, 5, 17),
+			wantDue:     true,
+		},
+		{
+			name:        "idle episode polled recently is skipped",
+			incremental: true,
+			adaptive:    true,
+			episodeID:   1,
+			mark:        &repository.Watermark{FinalMetricDate: day(2026, 5, 20), LastPolledAt: now.AddDate(0, 0, -2)},
+			wantDue:     false,
+		},
+		{
+			name:        "idle episode is polled once its interval passes",
+			incremental: true,
+			adaptive:    true,
+			episodeID:   1,
+			mark:        &repository.Watermark{FinalMetricDate: day(2026, 4, 1), LastPolledAt: now.AddDate(0, 0, -31)},
+			wantStart:   day(2026, 3, 29),
+			wantDue:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := CollectOptions{
+				StartDate:       start,
+				EndDate:         now,
+				Incremental:     tt.incremental,
+				AdaptivePolling: tt.adaptive,
+				RestatementDays: 3,
+			}
+			plan := newIncrementalPlan(scrapers.PlatformSpotify, 1, opts, now)
+			marks := map[int64]*repository.Watermark{
+				// A show watermark must not be used for new episodes
+				0: {FinalMetricDate: day(2026, 5, 30)},
+			}
+			if tt.mark != nil {
+				marks[1] = tt.mark
+			}
+			plan.setMarks(marks)
+
+			gotStart, gotDue := plan.window(tt.episodeID, published)
+			if gotDue != tt.wantDue {
+				t.Fatalf("window() due = %v, want %v", gotDue, tt.wantDue)
+			}
+			if gotDue && !gotStart.Equal(tt.wantStart) {
+				t.Errorf("window() start = %s, want %s", gotStart.Format("2006-01-02"), tt.wantStart.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestIncrementalPlanShowWindow(t *testing.T) {
+	now := day(2026, 6, 1)
+	opts := CollectOptions{StartDate: day(2026, 1, 1), EndDate: now, Incremental: true, RestatementDays: 3}
+	plan := newIncrementalPlan(scrapers.PlatformSpotify, 1, opts, now)
+
+	if got := plan.showWindow(); !got.Equal(opts.StartDate) {
+		t.Errorf("showWindow() without a watermark = %s, want %s", got.Format("2006-01-02"), opts.StartDate.Format("2006-01-02"))
+	}
+
+	plan.setMarks(map[int64]*repository.Watermark{0: {FinalMetricDate: day(2026, 5, 30)}})
+	if want := day(2026, 5, 27); !plan.showWindow().Equal(want) {
+		t.Errorf("showWindow() = %s, want %s", plan.showWindow().Format("2006-01-02"), want.Format("2006-01-02"))
+	}
+}
+
+func TestIncrementalPlanAdvance(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	opts := CollectOptions{StartDate: day(2026, 5, 1), EndDate: now}
+	plan := newIncrementalPlan(scrapers.PlatformSpotify, 1, opts, now)
+
+	metrics := []*scrapers.EpisodeMetrics{
+		{MetricDate: day(2026, 5, 20), Plays: 4},
+		{MetricDate: day(2026, 5, 25)},
+	}
+
+	if wm := plan.advanceEpisode(7, metrics, false); wm != nil {
+		t.Errorf("advanceEpisode() of an incomplete fetch = %+v, want nil", wm)
+	}
+
+	wm := plan.advanceEpisode(7, metrics, true)
+	if wm.EpisodeID != 7 {
+		t.Errorf("EpisodeID = %d, want 7", wm.EpisodeID)
+	}
+	// The last two days before now may still be restated by Spotify
+	if want := day(2026, 5, 30); !wm.FinalMetricDate.Equal(want) {
+		t.Errorf("FinalMetricDate = %s, want %s", wm.FinalMetricDate.Format("2006-01-02"), want.Format("2006-01-02"))
+	}
+	if wm.LastActivityDate == nil || !wm.LastActivityDate.Equal(day(2026, 5, 20)) {
+		t.Errorf("LastActivityDate = %v, want 2026-05-20", wm.LastActivityDate)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// runLedger records the per-item errors of one run
+type runLedger struct {
+	store    repository.Store
+	runID    int64
+	platform scrapers.Platform
+	count    int
+
+	// archiveFailures counts responses stored inline because the archive
+	// failed; their items were still collected
+	archiveFailures int
+}
+
+func newRunLedger(store repository.Store, runID int64, platform scrapers.Platform) *runLedger {
+	return &runLedger{store: store, runID: runID, platform: platform}
+}
+
+// stageMessages are the log messages of per-item errors by stage
+var stageMessages = map[string]string{
+	scrapers.StageUpsertEpisode:    "Failed to upsert episode",
+	scrapers.StageFetchMetrics:     "Failed to fetch episode metrics",
+	scrapers.StageFetchComments:    "Failed to fetch comments",
+	scrapers.StageStoreMetric:      "Failed to store episode metrics",
+	scrapers.StageStoreComment:     "Failed to store comments",
+	scrapers.StageFetchShowMetrics: "Failed to fetch show metrics",
+	scrapers.StageStoreShowMetric:  "Failed to store show metrics",
+	scrapers.StageArchiveResponse:  "Failed to archive response",
+}
+
+// record logs an error at stage and adds it to the ledger; episode is nil
+// for show-level errors
+func (l *runLedger) record(ctx context.Context, episode *scrapers.Episode, stage string, err error) {
+	l.count++
+	l.save(ctx, episode, stage, err)
+}
+
+// save logs an error at stage and records it in the store
+func (l *runLedger) save(ctx context.Context, episode *scrapers.Episode, stage string, err error) {
+	e := scrapers.NewScraperError(l.runID, l.platform, episode, stage, err)
+	promMetrics.ObserveItemError(e)
+
+	attrs := []any{logging.KeyStage, stage, "error_class", e.ErrorClass, logging.KeyError, err}
+	if episode != nil {
+		attrs = append(attrs, "episode", episode.EpisodeTitle)
+	}
+	if e.HTTPStatus != nil {
+		attrs = append(attrs, logging.KeyHTTPStatus, *e.HTTPStatus)
+	}
+	slog.WarnContext(ctx, stageMessages[stage], attrs...)
+
+	if err := l.store.RecordScraperError(context.WithoutCancel(ctx), e); err != nil {
+		slog.ErrorContext(ctx, "Failed to record scraper error", logging.KeyStage, stage, logging.KeyError, err)
+	}
+}
+
+// ReportArchiveFailure implements scrapers.ArchiveFailureReporter. The
+// response is still stored inline, so the failure marks the run partial
+// without counting as a failed item.
+func (l *runLedger) ReportArchiveFailure(ctx context.Context, resp *scrapers.Response, err error) {
+	var episode *scrapers.Episode
+	if resp.EpisodeID != 0 || resp.PlatformEpisodeID != "" {
+		episode = &scrapers.Episode{ID: resp.EpisodeID, PodcastID: resp.PodcastID, PlatformEpisodeID: resp.PlatformEpisodeID}
+	}
+	l.archiveFailures++
+	l.save(ctx, episode, scrapers.StageArchiveResponse, err)
+}
+
+// finish marks a run that would otherwise complete as partial when any
+// errors were recorded
+func (l *runLedger) finish(run *scrapers.ScraperRun) {
+	run.Status = scrapers.RunStatusCompleted
+	if l.count == 0 && l.archiveFailures == 0 {
+		return
+	}
+
+	run.Status = scrapers.RunStatusPartial
+	errMsg := fmt.Sprintf("%d item errors; see 'podcast-scraper runs show %d'", l.count, l.runID)
+	if l.archiveFailures > 0 {
+		errMsg = fmt.Sprintf("%d item errors and %d responses not archived; see 'podcast-scraper runs show %d'", l.count, l.archiveFailures, l.runID)
+	}
+	run.ErrorMessage = &errMsg
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// What a collection does when another process holds its run lock
+const (
+	lockSkip = "skip"
+	lockWait = "wait"
+	lockFail = "fail"
+)
+
+// lockPollInterval is how often a waiting collection retries the run lock
+const lockPollInterval = 5 * time.Second
+
+// runLocker is implemented by stores that can lock a show and platform
+// against concurrent collection across processes
+type runLocker interface {
+	TryLockRun(ctx context.Context, showName string, platform scrapers.Platform) (*repository.RunLock, error)
+}
+
+// lockRun takes the run lock for showName on platform. With lockWait it
+// retries until timeout; while the lock is still held after that, or
+// straight away in the other modes, the error wraps repository.ErrLockHeld.
+func lockRun(ctx context.Context, locker runLocker, showName string, platform scrapers.Platform, mode string, timeout time.Duration) (*repository.RunLock, error) {
+	lock, err := locker.TryLockRun(ctx, showName, platform)
+	if errors.Is(err, repository.ErrLockHeld) && mode == lockWait {
+		slog.InfoContext(ctx, "Waiting for run lock", "timeout", timeout.String())
+		lock, err = waitForRunLock(ctx, locker, showName, platform, timeout)
+	}
+	if errors.Is(err, repository.ErrLockHeld) {
+		return nil, fmt.Errorf("another process is collecting %s from %s: %w", showName, platform, err)
+	}
+	return lock, err
+}
+
+// waitForRunLock retries the run lock every lockPollInterval until timeout
+func waitForRunLock(ctx context.Context, locker runLocker, showName string, platform scrapers.Platform, timeout time.Duration) (*repository.RunLock, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return nil, repository.ErrLockHeld
+		case <-ticker.C:
+		}
+
+		lock, err := locker.TryLockRun(ctx, showName, platform)
+		if !errors.Is(err, repository.ErrLockHeld) {
+			return lock, err
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// heldLockStore is a memory store whose run lock is always held elsewhere
+type heldLockStore struct {
+	*repository.MemoryStore
+	attempts int
+}
+
+func (s *heldLockStore) TryLockRun(ctx context.Context, showName string, platform scrapers.Platform) (*repository.RunLock, error) {
+	s.attempts++
+	return nil, repository.ErrLockHeld
+}
+
+func TestCollectorRunLockHeld(t *testing.T) {
+	tests := []struct {
+		mode       string
+		wantStatus string
+	}{
+		{mode: lockSkip, wantStatus: scrapers.RunStatusSkipped},
+		{mode: lockFail, wantStatus: scrapers.RunStatusFailed},
+		{mode: lockWait, wantStatus: scrapers.RunStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			store := &heldLockStore{MemoryStore: repository.NewMemoryStore()}
+			collector := NewCollector(store, nil, []scrapers.Scraper{newFakeScraper()})
+
+			opts := testCollectOptions()
+			opts.Lock = tt.mode
+			opts.LockTimeout = 10 * time.Millisecond
+			if err := collector.Collect(context.Background(), opts); err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+
+			runs := store.Runs()
+			if len(runs) != 1 {
+				t.Fatalf("runs = %d, want 1", len(runs))
+			}
+			if runs[0].Status != tt.wantStatus {
+				t.Errorf("run status = %q, want %q", runs[0].Status, tt.wantStatus)
+			}
+			if runs[0].ErrorMessage == nil {
+				t.Error("run has no error message naming the held lock")
+			}
+			if got := len(store.Podcasts()); got != 0 {
+				t.Errorf("podcasts written without the lock = %d, want 0", got)
+			}
+		})
+	}
+}
+
+func TestLockRunWaitsUntilTimeout(t *testing.T) {
+	store := &heldLockStore{MemoryStore: repository.NewMemoryStore()}
+
+	_, err := lockRun(context.Background(), store, "Test Show", scrapers.PlatformSpotify, lockWait, 10*time.Millisecond)
+	if !errors.Is(err, repository.ErrLockHeld) {
+		t.Errorf("lockRun() error = %v, want ErrLockHeld", err)
+	}
+	if store.attempts != 1 {
+		t.Errorf("lock attempts = %d, want 1 before the first poll", store.attempts)
+	}
+}
@@ -4,71 +4,46 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/soypete/eleduck-analytics-connector/internal/alert"
+	"github.com/soypete/eleduck-analytics-connector/internal/anomaly"
+	"github.com/soypete/eleduck-analytics-connector/internal/archive"
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/quality"
 	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/schedule"
 	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
 	"github.com/soypete/eleduck-analytics-connector/internal/scrapers/amazon"
 	"github.com/soypete/eleduck-analytics-connector/internal/scrapers/apple"
 	"github.com/soypete/eleduck-analytics-connector/internal/scrapers/spotify"
 	"github.com/soypete/eleduck-analytics-connector/internal/scrapers/youtube"
+	"github.com/soypete/eleduck-analytics-connector/internal/telemetry"
 )
 
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
 
 	// Handle graceful shutdown
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		log.Println("Received shutdown signal, stopping...")
+		slog.Info("Received shutdown signal, stopping")
 		cancel()
 	}()
 
-	// Load configuration from environment
-	config := loadConfig()
-
-	// Connect to database
-	db, err := connectDatabase(config)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-	}
-	defer db.Close()
-
-	// Initialize repository
-	repo := repository.NewPodcastRepository(db)
-
-	// Initialize scrapers
-	scraperInstances, err := initializeScrapers(config)
-	if err != nil {
-		log.Fatalf("Failed to initialize scrapers: %v", err)
-	}
-
-	// Run metrics collection
-	collector := NewCollector(repo, scraperInstances)
-
-	// Determine run mode: one-time or scheduled
-	runMode := getEnv("RUN_MODE", "once")
-
-	if runMode == "scheduled" {
-		// Run on a schedule (e.g., daily at midnight)
-		scheduleInterval := getEnvDuration("SCHEDULE_INTERVAL", 24*time.Hour)
-		runScheduled(ctx, collector, scheduleInterval)
-	} else {
-		// Run once and exit
-		if err := collector.CollectAll(ctx); err != nil {
-			log.Fatalf("Collection failed: %v", err)
-		}
-		log.Println("Collection completed successfully")
-	}
+	code := run(ctx, os.Args[1:])
+	cancel()
+	os.Exit(code)
 }
 
 // Config holds application configuration
@@ -76,27 +51,202 @@ type Config struct {
 	DatabaseURL string
 	ShowName    string
 
+	// Store selects where scraped data is written: postgres, or sqlite for a
+	// local file at SQLitePath
+	Store      string
+	SQLitePath string
+
+	// Platforms restricts collection to these platforms; empty means all
+	Platforms []scrapers.Platform
+
+	// LookbackDays is the default collection window ending today
+	LookbackDays int
+
+	// Incremental collection fetches each episode from its high-water mark
+	// minus RestatementDays, and AdaptivePolling skips long-idle episodes
+	Incremental     bool
+	RestatementDays int
+	AdaptivePolling bool
+
+	// StagedWrites publishes each platform's data only when its run succeeds
+	StagedWrites bool
+
+	// ScheduleInterval is the time between collections in serve mode
+	ScheduleInterval time.Duration
+
+	// Schedule is the cron expression serve collects on, every
+	// ScheduleInterval when empty, and PlatformSchedules overrides it per
+	// platform. Expressions are evaluated in ScheduleTimezone, and each tick
+	// is delayed by up to ScheduleJitter. ScheduleCatchUp collects on startup
+	// for platforms whose last run was overtaken by a tick while serve was down.
+	Schedule          string
+	PlatformSchedules map[scrapers.Platform]string
+	ScheduleTimezone  *time.Location
+	ScheduleJitter    time.Duration
+	ScheduleCatchUp   bool
+
+	// ServeAddr is the address serve listens on for health checks and run
+	// triggers. DrainTimeout bounds how long a collection in flight may run
+	// after a shutdown signal, and /readyz fails when a platform has not
+	// succeeded within ReadyMaxRunAge (twice the gap between its scheduled
+	// runs when zero).
+	ServeAddr      string
+	DrainTimeout   time.Duration
+	ReadyMaxRunAge time.Duration
+
+	// MetricsBatchSize and MetricsFlushInterval bound how many episode metric
+	// rows backfill, import and reparse buffer before a batched write, and how
+	// old the oldest may be when more rows are added
+	MetricsBatchSize     int
+	MetricsFlushInterval time.Duration
+
+	// BackfillQuotaReserve is the API quota a backfill leaves for regular collections
+	BackfillQuotaReserve int64
+
+	// Archive is where API responses are archived; disabled when Archive.URL is empty
+	Archive archive.Config
+
 	// Apple Podcasts credentials
 	AppleEmail    string
 	ApplePassword string
 
 	// Spotify credentials (cookies)
-	SpotifySpCookie    string
-	SpotifySpKeyCookie string
+	SpotifySpCookie          string
+	SpotifySpKeyCookie       string
+	SpotifySpCookieExpiresAt *time.Time
 
 	// Amazon Music credentials
-	AmazonSessionCookie string
+	AmazonSessionCookie          string
+	AmazonSessionCookieExpiresAt *time.Time
 
 	// YouTube credentials
 	YouTubeAPIKey      string
 	YouTubeAccessToken string
+	YouTubeDailyQuota  int64
+
+	// PreflightChecks verifies credentials before collection starts
+	PreflightChecks bool
+
+	// SchemaCheck refuses to write to a Postgres database with pending migrations
+	SchemaCheck bool
+
+	// MetricsAddr serves Prometheus metrics at /metrics when set, and
+	// PushgatewayURL pushes them under PushgatewayJob after each collection
+	MetricsAddr    string
+	PushgatewayURL string
+	PushgatewayJob string
+
+	// Tracing selects where OpenTelemetry spans are exported
+	Tracing telemetry.TracingConfig
+
+	// RunLock is what a collection does when another process is already
+	// collecting the same show and platform: skip, wait up to
+	// RunLockTimeout, or fail
+	RunLock        string
+	RunLockTimeout time.Duration
+
+	// Running collections mark their run alive every HeartbeatInterval.
+	// Runs without a heartbeat for StaleRunAfter are marked abandoned when a
+	// collection starts, and by serve every ReapInterval.
+	HeartbeatInterval time.Duration
+	StaleRunAfter     time.Duration
+	ReapInterval      time.Duration
+
+	// Alerts selects where alerts about failed and suspicious runs go
+	Alerts alert.Config
+
+	// Quality decides what happens, per platform, to collected metric rows
+	// that break data quality rules
+	Quality *quality.Policy
+
+	// Anomalies tunes how each collection's metric dates are compared with
+	// their baselines; nil when anomaly detection is off
+	Anomalies *anomaly.Config
+
+	// Retention is applied by prune, and after each scheduled collection
+	// when PruneAfterCollect is set
+	Retention         repository.RetentionPolicy
+	PruneAfterCollect bool
 }
 
 // loadConfig loads configuration from environment variables
-func loadConfig() *Config {
+func loadConfig() (*Config, error) {
+	platforms, err := parsePlatforms(getEnv("PLATFORMS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PLATFORMS: %w", err)
+	}
+
+	store := getEnv("STORE", storePostgres)
+	if store != storePostgres && store != storeSQLite {
+		return nil, fmt.Errorf("invalid STORE %q: want %s or %s", store, storePostgres, storeSQLite)
+	}
+
+	runLock := getEnv("RUN_LOCK", lockSkip)
+	if runLock != lockSkip && runLock != lockWait && runLock != lockFail {
+		return nil, fmt.Errorf("invalid RUN_LOCK %q: want %s, %s or %s", runLock, lockSkip, lockWait, lockFail)
+	}
+
+	var alertTriggers []alert.Trigger
+	for _, name := range splitList(getEnv("ALERT_TRIGGERS", "")) {
+		trigger, err := alert.ParseTrigger(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ALERT_TRIGGERS: %w", err)
+		}
+		alertTriggers = append(alertTriggers, trigger)
+	}
+
+	qualityPolicy, err := quality.ParsePolicy(getEnv("QUALITY_RULES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid QUALITY_RULES: %w", err)
+	}
+
+	var anomalies *anomaly.Config
+	if getEnvBool("ANOMALY_DETECTION", true) {
+		method, err := anomaly.ParseMethod(getEnv("ANOMALY_METHOD", string(anomaly.MethodRolling)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ANOMALY_METHOD: %w", err)
+		}
+		defaults := anomaly.DefaultConfig()
+		anomalies = &anomaly.Config{
+			Method:     method,
+			WindowDays: getEnvInt("ANOMALY_WINDOW_DAYS", defaults.WindowDays),
+			Threshold:  getEnvFloat("ANOMALY_THRESHOLD", defaults.Threshold),
+			MinDelta:   getEnvFloat("ANOMALY_MIN_DELTA", defaults.MinDelta),
+		}
+	}
+
+	timezone, err := time.LoadLocation(getEnv("SCHEDULE_TIMEZONE", "UTC"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SCHEDULE_TIMEZONE: %w", err)
+	}
+
+	platformSchedules := map[scrapers.Platform]string{}
+	for _, platform := range scrapers.AllPlatforms {
+		if spec := getEnv("SCHEDULE_"+strings.ToUpper(string(platform)), ""); spec != "" {
+			platformSchedules[platform] = spec
+		}
+	}
+
 	return &Config{
 		DatabaseURL:         getEnv("DATABASE_URL", ""),
 		ShowName:            getEnv("SHOW_NAME", "domesticating ai"),
+		Store:               store,
+		SQLitePath:          getEnv("SQLITE_PATH", "podcast-metrics.db"),
+		Platforms:           platforms,
+		LookbackDays:        getEnvInt("LOOKBACK_DAYS", 30),
+		Incremental:         getEnvBool("INCREMENTAL", true),
+		RestatementDays:     getEnvInt("RESTATEMENT_DAYS", 3),
+		AdaptivePolling:     getEnvBool("ADAPTIVE_POLLING", true),
+		StagedWrites:        getEnvBool("STAGED_WRITES", false),
+		ScheduleInterval:    getEnvDuration("SCHEDULE_INTERVAL", 24*time.Hour),
+		Schedule:            getEnv("SCHEDULE", ""),
+		PlatformSchedules:   platformSchedules,
+		ScheduleTimezone:    timezone,
+		ScheduleJitter:      getEnvDuration("SCHEDULE_JITTER", 0),
+		ScheduleCatchUp:     getEnvBool("SCHEDULE_CATCH_UP", true),
+		ServeAddr:           getEnv("SERVE_ADDR", ":8080"),
+		DrainTimeout:        getEnvDuration("DRAIN_TIMEOUT", 5*time.Minute),
+		ReadyMaxRunAge:      getEnvDuration("READY_MAX_RUN_AGE", 0),
 		AppleEmail:          getEnv("APPLE_PODCASTS_EMAIL", ""),
 		ApplePassword:       getEnv("APPLE_PODCASTS_PASSWORD", ""),
 		SpotifySpCookie:     getEnv("SPOTIFY_SP_COOKIE", ""),
@@ -104,7 +254,117 @@ func loadConfig() *Config {
 		AmazonSessionCookie: getEnv("AMAZON_SESSION_COOKIE", ""),
 		YouTubeAPIKey:       getEnv("YOUTUBE_API_KEY", ""),
 		YouTubeAccessToken:  getEnv("YOUTUBE_ACCESS_TOKEN", ""),
+		YouTubeDailyQuota:   int64(getEnvInt("YOUTUBE_DAILY_QUOTA", 10000)),
+		PreflightChecks:     getEnvBool("PREFLIGHT_CHECKS", true),
+		SchemaCheck:         getEnvBool("SCHEMA_CHECK", false),
+		MetricsAddr:         getEnv("METRICS_ADDR", ""),
+		PushgatewayURL:      getEnv("PUSHGATEWAY_URL", ""),
+		PushgatewayJob:      getEnv("PUSHGATEWAY_JOB", "podcast-scraper"),
+		Tracing: telemetry.TracingConfig{
+			Exporter:    getEnv("OTEL_TRACES_EXPORTER", telemetry.ExporterNone),
+			ServiceName: "podcast-scraper",
+		},
+
+		SpotifySpCookieExpiresAt:     getEnvTime("SPOTIFY_SP_COOKIE_EXPIRES_AT"),
+		AmazonSessionCookieExpiresAt: getEnvTime("AMAZON_SESSION_COOKIE_EXPIRES_AT"),
+		BackfillQuotaReserve:         int64(getEnvInt("BACKFILL_QUOTA_RESERVE", 500)),
+		MetricsBatchSize:             getEnvInt("METRICS_BATCH_SIZE", 1000),
+		MetricsFlushInterval:         getEnvDuration("METRICS_FLUSH_INTERVAL", 10*time.Second),
+
+		Retention: repository.RetentionPolicy{
+			RawDataDays:  getEnvInt("RETENTION_RAW_DATA_DAYS", 90),
+			RollupDays:   getEnvInt("RETENTION_ROLLUP_DAYS", 0),
+			SnapshotDays: getEnvInt("RETENTION_SNAPSHOT_DAYS", 0),
+			RunDays:      getEnvInt("RETENTION_RUN_DAYS", 365),
+			ErrorDays:    getEnvInt("RETENTION_ERROR_DAYS", 90),
+		},
+		PruneAfterCollect: getEnvBool("PRUNE_AFTER_COLLECT", false),
+
+		RunLock:        runLock,
+		RunLockTimeout: getEnvDuration("RUN_LOCK_TIMEOUT", 10*time.Minute),
+
+		HeartbeatInterval: getEnvDuration("HEARTBEAT_INTERVAL", 30*time.Second),
+		StaleRunAfter:     getEnvDuration("STALE_RUN_AFTER", 10*time.Minute),
+		ReapInterval:      getEnvDuration("REAP_INTERVAL", 5*time.Minute),
+
+		Alerts: alert.Config{
+			WebhookURL:     getEnv("ALERT_WEBHOOK_URL", ""),
+			ChatWebhookURL: getEnv("ALERT_CHAT_WEBHOOK_URL", ""),
+			SMTPAddr:       getEnv("ALERT_SMTP_ADDR", ""),
+			SMTPFrom:       getEnv("ALERT_SMTP_FROM", "podcast-scraper@localhost"),
+			SMTPTo:         splitList(getEnv("ALERT_SMTP_TO", "")),
+			SMTPUsername:   getEnv("ALERT_SMTP_USERNAME", ""),
+			SMTPPassword:   getEnv("ALERT_SMTP_PASSWORD", ""),
+			Cooldown:       getEnvDuration("ALERT_COOLDOWN", 6*time.Hour),
+			Triggers:       alertTriggers,
+		},
+
+		Quality:   qualityPolicy,
+		Anomalies: anomalies,
+
+		Archive: archive.Config{
+			URL:             getEnv("ARCHIVE_URL", ""),
+			S3Endpoint:      getEnv("ARCHIVE_S3_ENDPOINT", ""),
+			S3Region:        getEnv("ARCHIVE_S3_REGION", "us-east-1"),
+			AccessKeyID:     getEnv("AWS_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getEnv("AWS_SECRET_ACCESS_KEY", ""),
+			SessionToken:    getEnv("AWS_SESSION_TOKEN", ""),
+		},
+	}, nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parsePlatforms parses a comma-separated platform list
+func parsePlatforms(value string) ([]scrapers.Platform, error) {
+	var platforms []scrapers.Platform
+	for _, name := range splitList(value) {
+		platform, err := scrapers.ParsePlatform(name)
+		if err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, platform)
+	}
+	return platforms, nil
+}
+
+// scheduleFor returns the schedule serve collects platform on
+func (c *Config) scheduleFor(platform scrapers.Platform) (*schedule.Schedule, error) {
+	spec, name := c.PlatformSchedules[platform], "SCHEDULE_"+strings.ToUpper(string(platform))
+	if spec == "" {
+		spec, name = c.Schedule, "SCHEDULE"
+	}
+	if spec == "" {
+		spec, name = "@every "+c.ScheduleInterval.String(), "SCHEDULE_INTERVAL"
+	}
+
+	s, err := schedule.Parse(spec, c.ScheduleTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return s, nil
+}
+
+// wantsPlatform reports whether the platform filter includes the platform
+func (c *Config) wantsPlatform(platform scrapers.Platform) bool {
+	if len(c.Platforms) == 0 {
+		return true
 	}
+	for _, p := range c.Platforms {
+		if p == platform {
+			return true
+		}
+	}
+	return false
 }
 
 // connectDatabase connects to the PostgreSQL database
@@ -142,12 +402,117 @@ func connectDatabase(config *Config) (*sql.DB, error) {
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
-	log.Println("Database connection established")
+	slog.Info("Database connection established")
 	return db, nil
 }
 
-// initializeScrapers creates all scraper instances
-func initializeScrapers(config *Config) ([]scrapers.Scraper, error) {
+// Store backends selectable with STORE
+const (
+	storePostgres = "postgres"
+	storeSQLite   = "sqlite"
+)
+
+// openStore opens the configured store and returns it with a function that
+// releases it. Only the Postgres repository tracks watermarks and backfill
+// checkpoints; callers check for those capabilities on the returned store.
+func openStore(ctx context.Context, config *Config) (repository.Store, func() error, error) {
+	if config.Store == storeSQLite {
+		store, err := repository.OpenSQLiteStore(ctx, config.SQLitePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		slog.InfoContext(ctx, "Writing to SQLite database", "path", config.SQLitePath)
+		return store, store.Close, nil
+	}
+
+	db, err := connectDatabase(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := checkSchema(ctx, config, db); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return repository.NewPodcastRepository(db), db.Close, nil
+}
+
+// newArchiver returns the archiver for responses fetched by collection runs,
+// or nil when ARCHIVE_URL is unset. Responses are indexed for reparse when
+// the store supports it.
+func newArchiver(config *Config, store repository.Store) (scrapers.ResponseArchiver, error) {
+	if config.Archive.URL == "" {
+		return nil, nil
+	}
+
+	blobs, err := archive.Open(config.Archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open response archive: %w", err)
+	}
+
+	index, ok := store.(archive.Index)
+	if !ok {
+		slog.Warn("Archived responses are not indexed by this store; reparse will not find them", "store", config.Store)
+	}
+
+	slog.Info("Archiving API responses", "url", config.Archive.URL)
+	return archive.NewRecorder(blobs, index), nil
+}
+
+// initializeScrapers creates all scraper instances and, when enabled, drops any
+// platform whose credentials fail the pre-flight check. Dropped platforms are
+// recorded as an auth_failed run so the failure shows up in raw.podcast_scraper_runs.
+func initializeScrapers(ctx context.Context, config *Config, store repository.Store, archiver scrapers.ResponseArchiver, notifier *alert.Notifier) ([]scrapers.Scraper, error) {
+	scraperList, err := newScrapers(config, archiver)
+	if err != nil {
+		return nil, err
+	}
+
+	if !config.PreflightChecks {
+		return scraperList, nil
+	}
+
+	var healthy []scrapers.Scraper
+	for _, scraper := range scraperList {
+		check := scraper.CheckCredentials(ctx)
+		if !check.AuthFailed() {
+			if !check.OK() {
+				slog.WarnContext(ctx, "Pre-flight check failed", logging.KeyPlatform, check.Platform,
+					"status", check.Status, "message", check.Message)
+			}
+			healthy = append(healthy, scraper)
+			continue
+		}
+
+		slog.WarnContext(ctx, "Skipping platform after failed credential check", logging.KeyPlatform, check.Platform,
+			"status", check.Status, "message", check.Message)
+
+		completedAt := time.Now()
+		errMsg := fmt.Sprintf("credentials %s: %s", check.Status, check.Message)
+		run := &scrapers.ScraperRun{
+			Platform:       check.Platform,
+			RunStartedAt:   completedAt,
+			RunCompletedAt: &completedAt,
+			Status:         scrapers.RunStatusAuthFailed,
+			ErrorMessage:   &errMsg,
+		}
+		runID, err := store.RecordScraperRun(ctx, run)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to record auth failure", logging.KeyPlatform, check.Platform, logging.KeyError, err)
+		}
+		run.ID = runID
+		notifyRun(ctx, notifier, runOutcome{run: run, show: config.ShowName})
+	}
+
+	if len(healthy) == 0 {
+		return nil, fmt.Errorf("no scrapers passed pre-flight checks - run doctor for details")
+	}
+
+	return healthy, nil
+}
+
+// newScrapers creates a scraper for every selected platform with configured
+// credentials. archiver may be nil to disable response archiving.
+func newScrapers(config *Config, archiver scrapers.ResponseArchiver) ([]scrapers.Scraper, error) {
 	var scraperList []scrapers.Scraper
 
 	// Apple Podcasts scraper
@@ -155,43 +520,51 @@ func initializeScrapers(config *Config) ([]scrapers.Scraper, error) {
 		appleScraper, err := apple.NewScraper(apple.Config{
 			Email:    config.AppleEmail,
 			Password: config.ApplePassword,
+			Archiver: archiver,
+			Observer: promMetrics,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Apple Podcasts scraper: %w", err)
 		}
 		scraperList = append(scraperList, appleScraper)
-		log.Println("Initialized Apple Podcasts scraper")
+		slog.Info("Initialized scraper", logging.KeyPlatform, scrapers.PlatformApplePodcasts)
 	} else {
-		log.Println("Skipping Apple Podcasts scraper (credentials not provided)")
+		slog.Info("Skipping scraper, credentials not provided", logging.KeyPlatform, scrapers.PlatformApplePodcasts)
 	}
 
 	// Spotify scraper
 	if config.SpotifySpCookie != "" {
 		spotifyScraper, err := spotify.NewScraper(spotify.Config{
-			SpCookie:    config.SpotifySpCookie,
-			SpKeyCookie: config.SpotifySpKeyCookie,
+			SpCookie:          config.SpotifySpCookie,
+			SpKeyCookie:       config.SpotifySpKeyCookie,
+			SpCookieExpiresAt: config.SpotifySpCookieExpiresAt,
+			Archiver:          archiver,
+			Observer:          promMetrics,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Spotify scraper: %w", err)
 		}
 		scraperList = append(scraperList, spotifyScraper)
-		log.Println("Initialized Spotify scraper")
+		slog.Info("Initialized scraper", logging.KeyPlatform, scrapers.PlatformSpotify)
 	} else {
-		log.Println("Skipping Spotify scraper (credentials not provided)")
+		slog.Info("Skipping scraper, credentials not provided", logging.KeyPlatform, scrapers.PlatformSpotify)
 	}
 
 	// Amazon Music scraper
 	if config.AmazonSessionCookie != "" {
 		amazonScraper, err := amazon.NewScraper(amazon.Config{
-			SessionCookie: config.AmazonSessionCookie,
+			SessionCookie:          config.AmazonSessionCookie,
+			SessionCookieExpiresAt: config.AmazonSessionCookieExpiresAt,
+			Archiver:               archiver,
+			Observer:               promMetrics,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Amazon Music scraper: %w", err)
 		}
 		scraperList = append(scraperList, amazonScraper)
-		log.Println("Initialized Amazon Music scraper")
+		slog.Info("Initialized scraper", logging.KeyPlatform, scrapers.PlatformAmazonMusic)
 	} else {
-		log.Println("Skipping Amazon Music scraper (credentials not provided)")
+		slog.Info("Skipping scraper, credentials not provided", logging.KeyPlatform, scrapers.PlatformAmazonMusic)
 	}
 
 	// YouTube scraper
@@ -199,196 +572,31 @@ func initializeScrapers(config *Config) ([]scrapers.Scraper, error) {
 		youtubeScraper, err := youtube.NewScraper(youtube.Config{
 			APIKey:      config.YouTubeAPIKey,
 			AccessToken: config.YouTubeAccessToken,
+			DailyQuota:  config.YouTubeDailyQuota,
+			Archiver:    archiver,
+			Observer:    promMetrics,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create YouTube scraper: %w", err)
 		}
 		scraperList = append(scraperList, youtubeScraper)
-		log.Println("Initialized YouTube scraper")
+		slog.Info("Initialized scraper", logging.KeyPlatform, scrapers.PlatformYouTube)
 	} else {
-		log.Println("Skipping YouTube scraper (credentials not provided)")
-	}
-
-	if len(scraperList) == 0 {
-		return nil, fmt.Errorf("no scrapers initialized - check credentials")
-	}
-
-	return scraperList, nil
-}
-
-// Collector orchestrates metrics collection across all platforms
-type Collector struct {
-	repo     *repository.PodcastRepository
-	scrapers []scrapers.Scraper
-}
-
-// NewCollector creates a new collector
-func NewCollector(repo *repository.PodcastRepository, scrapers []scrapers.Scraper) *Collector {
-	return &Collector{
-		repo:     repo,
-		scrapers: scrapers,
-	}
-}
-
-// CollectAll runs collection for all scrapers
-func (c *Collector) CollectAll(ctx context.Context) error {
-	showName := getEnv("SHOW_NAME", "domesticating ai")
-	lookbackDays := getEnvInt("LOOKBACK_DAYS", 30)
-
-	startDate := time.Now().AddDate(0, 0, -lookbackDays)
-	endDate := time.Now()
-
-	for _, scraper := range c.scrapers {
-		if err := c.collectForPlatform(ctx, scraper, showName, startDate, endDate); err != nil {
-			log.Printf("Error collecting from %s: %v", scraper.GetPlatform(), err)
-			// Continue with other platforms even if one fails
-			continue
-		}
-	}
-
-	return nil
-}
-
-// collectForPlatform collects metrics for a single platform
-func (c *Collector) collectForPlatform(ctx context.Context, scraper scrapers.Scraper, showName string, startDate, endDate time.Time) error {
-	platform := scraper.GetPlatform()
-	log.Printf("Starting collection for %s", platform)
-
-	// Record scraper run
-	run := &scrapers.ScraperRun{
-		Platform:     platform,
-		RunStartedAt: time.Now(),
-		Status:       "running",
-	}
-
-	runID, err := c.repo.RecordScraperRun(ctx, run)
-	if err != nil {
-		return fmt.Errorf("failed to record scraper run: %w", err)
-	}
-
-	defer func() {
-		completedAt := time.Now()
-		run.RunCompletedAt = &completedAt
-
-		if err := c.repo.UpdateScraperRun(ctx, runID, run); err != nil {
-			log.Printf("Failed to update scraper run: %v", err)
-		}
-	}()
-
-	// Fetch podcast info
-	podcast, err := scraper.FetchPodcastInfo(ctx, showName)
-	if err != nil {
-		run.Status = "failed"
-		errMsg := err.Error()
-		run.ErrorMessage = &errMsg
-		return fmt.Errorf("failed to fetch podcast info: %w", err)
-	}
-
-	// Upsert podcast to database
-	podcastID, err := c.repo.UpsertPodcast(ctx, podcast)
-	if err != nil {
-		run.Status = "failed"
-		errMsg := err.Error()
-		run.ErrorMessage = &errMsg
-		return fmt.Errorf("failed to upsert podcast: %w", err)
-	}
-	podcast.ID = podcastID
-
-	// Fetch episodes
-	episodes, err := scraper.FetchEpisodes(ctx, podcast)
-	if err != nil {
-		run.Status = "failed"
-		errMsg := err.Error()
-		run.ErrorMessage = &errMsg
-		return fmt.Errorf("failed to fetch episodes: %w", err)
-	}
-
-	log.Printf("Found %d episodes for %s on %s", len(episodes), showName, platform)
-
-	// Process each episode
-	for _, episode := range episodes {
-		episode.PodcastID = podcastID
-
-		// Upsert episode
-		episodeID, err := c.repo.UpsertEpisode(ctx, episode)
-		if err != nil {
-			log.Printf("Failed to upsert episode %s: %v", episode.EpisodeTitle, err)
-			continue
-		}
-		episode.ID = episodeID
-
-		// Fetch episode metrics
-		episodeMetrics, err := scraper.FetchEpisodeMetrics(ctx, episode, startDate, endDate)
-		if err != nil {
-			log.Printf("Failed to fetch metrics for episode %s: %v", episode.EpisodeTitle, err)
-			continue
-		}
-
-		// Store metrics
-		for _, metric := range episodeMetrics {
-			metric.EpisodeID = episodeID
-			if err := c.repo.UpsertEpisodeMetrics(ctx, metric); err != nil {
-				log.Printf("Failed to store metrics for episode %s: %v", episode.EpisodeTitle, err)
-				continue
-			}
-			run.MetricsCollected++
-		}
-
-		// Fetch comments (if platform supports it)
-		comments, err := scraper.FetchComments(ctx, episode)
-		if err != nil {
-			log.Printf("Failed to fetch comments for episode %s: %v", episode.EpisodeTitle, err)
-		} else {
-			for _, comment := range comments {
-				if err := c.repo.InsertComment(ctx, comment); err != nil {
-					log.Printf("Failed to store comment: %v", err)
-				}
-			}
-		}
-
-		run.EpisodesProcessed++
+		slog.Info("Skipping scraper, credentials not provided", logging.KeyPlatform, scrapers.PlatformYouTube)
 	}
 
-	// Fetch show-level metrics
-	showMetrics, err := scraper.FetchShowMetrics(ctx, podcast, startDate, endDate)
-	if err != nil {
-		log.Printf("Failed to fetch show metrics: %v", err)
-	} else {
-		for _, metric := range showMetrics {
-			metric.PodcastID = podcastID
-			if err := c.repo.UpsertShowMetrics(ctx, metric); err != nil {
-				log.Printf("Failed to store show metrics: %v", err)
-			}
+	var selected []scrapers.Scraper
+	for _, scraper := range scraperList {
+		if config.wantsPlatform(scraper.GetPlatform()) {
+			selected = append(selected, scrapers.Traced(scraper))
 		}
 	}
 
-	run.Status = "completed"
-	log.Printf("Completed collection for %s: %d episodes, %d metrics", platform, run.EpisodesProcessed, run.MetricsCollected)
-
-	return nil
-}
-
-// runScheduled runs the collector on a schedule
-func runScheduled(ctx context.Context, collector *Collector, interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	// Run immediately on startup
-	if err := collector.CollectAll(ctx); err != nil {
-		log.Printf("Collection failed: %v", err)
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no scrapers initialized - check credentials and platform filter")
 	}
 
-	for {
-		select {
-		case <-ctx.Done():
-			log.Println("Scheduled collection stopped")
-			return
-		case <-ticker.C:
-			if err := collector.CollectAll(ctx); err != nil {
-				log.Printf("Collection failed: %v", err)
-			}
-		}
-	}
+	return selected, nil
 }
 
 // getEnv gets an environment variable with a default value
@@ -410,6 +618,16 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvFloat gets an environment variable as float64 with a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
 // getEnvDuration gets an environment variable as duration with a default value
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
@@ -419,3 +637,23 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+// getEnvBool gets an environment variable as bool with a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// getEnvTime gets an RFC 3339 timestamp from an environment variable, or nil if unset
+func getEnvTime(key string) *time.Time {
+	if value := os.Getenv(key); value != "" {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
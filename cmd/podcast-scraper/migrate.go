@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	goosedb "github.com/pressly/goose/v3/database"
+	"github.com/soypete/eleduck-analytics-connector/database"
+)
+
+const migrateUsage = "usage: migrate up|down|status|redo|baseline|create [flags]"
+
+// runMigrate implements `podcast-scraper migrate`, applying the migrations
+// embedded in the binary to the database configured for the scraper
+func runMigrate(ctx context.Context, config *Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	action, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("migrate "+action, flag.ContinueOnError)
+	var to *int64
+	var dir *string
+	switch action {
+	case "up":
+		to = fs.Int64("to", 0, "apply migrations up to and including this version (default: latest)")
+	case "down":
+		to = fs.Int64("to", -1, "roll back every migration after this version (default: only the latest)")
+	case "baseline":
+		to = fs.Int64("to", legacySchemaVersion, "mark migrations up to and including this version as applied without running them")
+	case "status", "redo":
+	case "create":
+		dir = fs.String("dir", database.Dir, "directory to create the migration in")
+	default:
+		return fmt.Errorf("unknown migrate action %q; %s", action, migrateUsage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Creating a migration writes a file in the source tree; no database needed
+	if action == "create" {
+		if fs.NArg() != 1 {
+			return errors.New("usage: migrate create [flags] <name>")
+		}
+		return createMigration(*dir, fs.Arg(0))
+	}
+	if fs.NArg() != 0 {
+		return errors.New(migrateUsage)
+	}
+
+	db, err := connectDatabase(config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	provider, err := newMigrationProvider(db)
+	if err != nil {
+		return err
+	}
+
+	var results []*goose.MigrationResult
+	switch action {
+	case "up":
+		if *to > 0 {
+			results, err = provider.UpTo(ctx, *to)
+		} else {
+			results, err = provider.Up(ctx)
+		}
+	case "down":
+		if *to >= 0 {
+			results, err = provider.DownTo(ctx, *to)
+		} else {
+			var result *goose.MigrationResult
+			result, err = provider.Down(ctx)
+			if result != nil {
+				results = append(results, result)
+			}
+		}
+	case "redo":
+		results, err = redoMigration(ctx, provider)
+	case "baseline":
+		err = baselineMigrations(ctx, db, provider, *to)
+	case "status":
+		return printMigrationStatus(ctx, provider)
+	}
+
+	for _, result := range results {
+		fmt.Println(result)
+	}
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		fmt.Println("No migrations to apply")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("migrate %s failed: %w", action, err)
+	}
+
+	version, err := provider.GetDBVersion(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Database schema is at version %d\n", version)
+	return nil
+}
+
+// newMigrationProvider returns a goose provider for the embedded migrations
+func newMigrationProvider(db *sql.DB) (*goose.Provider, error) {
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, database.Migrations())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return provider, nil
+}
+
+// redoMigration rolls back the latest migration and applies it again
+func redoMigration(ctx context.Context, provider *goose.Provider) ([]*goose.MigrationResult, error) {
+	down, err := provider.Down(ctx)
+	if err != nil {
+		return nil, err
+	}
+	up, err := provider.UpByOne(ctx)
+	if err != nil {
+		return []*goose.MigrationResult{down}, err
+	}
+	return []*goose.MigrationResult{down, up}, nil
+}
+
+// legacySchemaVersion is the last migration applied by hand before the
+// scraper tracked migrations. Databases created then have its schema but no
+// goose version table.
+const legacySchemaVersion = 2
+
+// baselineMigrations records the pending migrations up to and including
+// version as applied without running them, for databases whose schema
+// already matches them
+func baselineMigrations(ctx context.Context, db *sql.DB, provider *goose.Provider, version int64) error {
+	// Status creates the version table when it is missing
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get migration status: %w", err)
+	}
+
+	store, err := goosedb.NewStore(goose.DialectPostgres, goose.DefaultTablename)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, s := range statuses {
+		if s.Source.Version > version || s.State != goose.StatePending {
+			continue
+		}
+		if err := store.Insert(ctx, tx, goosedb.InsertRequest{Version: s.Source.Version}); err != nil {
+			return fmt.Errorf("failed to mark migration %d as applied: %w", s.Source.Version, err)
+		}
+		fmt.Printf("Marked %s as applied\n", filepath.Base(s.Source.Path))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit baseline: %w", err)
+	}
+	return nil
+}
+
+// printMigrationStatus lists every embedded migration and whether it is applied
+func printMigrationStatus(ctx context.Context, provider *goose.Provider) error {
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get migration status: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED\tMIGRATION")
+	for _, s := range statuses {
+		applied := "-"
+		if s.State == goose.StateApplied {
+			applied = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Source.Version, s.State, applied, filepath.Base(s.Source.Path))
+	}
+	return w.Flush()
+}
+
+// checkSchema refuses to run against a database with pending migrations,
+// so the scraper never writes to a schema older than it expects. It is
+// skipped when SCHEMA_CHECK is disabled.
+func checkSchema(ctx context.Context, config *Config, db *sql.DB) error {
+	if !config.SchemaCheck {
+		return nil
+	}
+
+	provider, err := newMigrationProvider(db)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check schema version: %w", err)
+	}
+
+	var pending []string
+	for _, s := range statuses {
+		if s.State == goose.StatePending {
+			pending = append(pending, filepath.Base(s.Source.Path))
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("database schema has %d pending migrations (%s); run 'podcast-scraper migrate up' or set SCHEMA_CHECK=false",
+		len(pending), strings.Join(pending, ", "))
+}
+
+// migrationNameRe matches the characters replaced in new migration names
+var migrationNameRe = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// createMigration writes an empty SQL migration numbered after the last
+// one in dir, following the NNNN_name.sql convention
+func createMigration(dir, name string) error {
+	name = strings.ToLower(strings.Trim(migrationNameRe.ReplaceAllString(name, "_"), "_"))
+	if name == "" {
+		return errors.New("migration name must contain letters or digits")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var last int64
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		version, err := goose.NumericComponent(entry.Name())
+		if err != nil {
+			continue
+		}
+		if version > last {
+			last = version
+		}
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%04d_%s.sql", last+1, name))
+	content := "-- +goose Up\n\n-- +goose Down\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to create migration: %w", err)
+	}
+
+	fmt.Printf("Created %s\n", path)
+	return nil
+}
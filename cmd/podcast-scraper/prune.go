@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+)
+
+// pruneStore applies retention policies
+type pruneStore interface {
+	Prune(ctx context.Context, policy repository.RetentionPolicy, now time.Time, dryRun bool) (*repository.PruneResult, error)
+}
+
+// runPrune implements `podcast-scraper prune`, applying the configured
+// retention policy to the Postgres database
+func runPrune(ctx context.Context, config *Config, args []string) error {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	var f commandFlags
+	f.registerDryRun(fs)
+	policy := &config.Retention
+	fs.IntVar(&policy.RawDataDays, "raw-data-days", policy.RawDataDays, "clear archive references from raw_data on metrics older than this many days (0 keeps them)")
+	fs.IntVar(&policy.RollupDays, "rollup-days", policy.RollupDays, "roll daily episode metrics older than this many days into monthly aggregates (0 keeps them daily)")
+	fs.IntVar(&policy.SnapshotDays, "snapshot-days", policy.SnapshotDays, "delete metric snapshot history older than this many days (0 keeps it)")
+	fs.IntVar(&policy.RunDays, "run-days", policy.RunDays, "delete scraper runs older than this many days (0 keeps them)")
+	fs.IntVar(&policy.ErrorDays, "error-days", policy.ErrorDays, "delete scraper errors older than this many days (0 keeps them)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := connectDatabase(config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := checkSchema(ctx, config, db); err != nil {
+		return err
+	}
+
+	result, err := repository.NewPodcastRepository(db).Prune(ctx, *policy, time.Now(), f.dryRun)
+	if err != nil {
+		return err
+	}
+
+	verb := "Pruned"
+	if f.dryRun {
+		verb = "Would prune"
+	}
+	fmt.Printf("%s with retention: raw_data %s, daily metrics %s, snapshots %s, runs %s, errors %s\n", verb,
+		retentionValue(policy.RawDataDays), retentionValue(policy.RollupDays), retentionValue(policy.SnapshotDays),
+		retentionValue(policy.RunDays), retentionValue(policy.ErrorDays))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "raw_data cleared:\t%d\n", result.RawDataCleared)
+	fmt.Fprintf(w, "daily rows rolled up:\t%d\n", result.DailyRowsRolledUp)
+	fmt.Fprintf(w, "monthly rows written:\t%d\n", result.MonthlyRowsWritten)
+	fmt.Fprintf(w, "snapshots deleted:\t%d\n", result.SnapshotsDeleted)
+	fmt.Fprintf(w, "errors deleted:\t%d\n", result.ErrorsDeleted)
+	fmt.Fprintf(w, "runs deleted:\t%d\n", result.RunsDeleted)
+	return w.Flush()
+}
+
+// retentionValue renders a retention period in days for the summary line
+func retentionValue(days int) string {
+	if days <= 0 {
+		return "kept"
+	}
+	return fmt.Sprintf("%dd", days)
+}
+
+// pruneAfterCollect applies the retention policy after a scheduled
+// collection when the store supports it; failures are only logged
+func pruneAfterCollect(ctx context.Context, store pruneStore, policy repository.RetentionPolicy) {
+	result, err := store.Prune(ctx, policy, time.Now(), false)
+	if err != nil {
+		slog.ErrorContext(ctx, "Prune failed", logging.KeyError, err)
+		return
+	}
+	slog.InfoContext(ctx, "Pruned", "raw_data_cleared", result.RawDataCleared,
+		"daily_rows_rolled_up", result.DailyRowsRolledUp, "monthly_rows_written", result.MonthlyRowsWritten,
+		"snapshots_deleted", result.SnapshotsDeleted, "errors_deleted", result.ErrorsDeleted, "runs_deleted", result.RunsDeleted)
+}
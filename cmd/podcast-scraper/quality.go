@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/quality"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// quarantineStore keeps metric rows that broke data quality rules
+type quarantineStore interface {
+	QuarantineMetrics(ctx context.Context, rows []*scrapers.QuarantinedMetric) error
+}
+
+// runQuality checks one run's metric rows before they are written, holding
+// back the rows its policy quarantines or rejects
+type runQuality struct {
+	policy   *quality.Policy // nil checks nothing
+	store    quarantineStore // nil when the store cannot quarantine
+	runID    int64
+	platform scrapers.Platform
+	dryRun   bool // counts held rows without storing them
+
+	quarantined int
+	rejected    int
+}
+
+func newRunQuality(policy *quality.Policy, store repository.Store, runID int64, platform scrapers.Platform) *runQuality {
+	q := &runQuality{policy: policy, runID: runID, platform: platform}
+	if s, ok := store.(quarantineStore); ok {
+		q.store = s
+	}
+	return q
+}
+
+// episodeMetrics returns the rows collected for episode that may be written
+func (q *runQuality) episodeMetrics(ctx context.Context, episode *scrapers.Episode, rows []*scrapers.EpisodeMetrics) []*scrapers.EpisodeMetrics {
+	if q.policy == nil {
+		return rows
+	}
+
+	now := time.Now()
+	var kept []*scrapers.EpisodeMetrics
+	var held []*scrapers.QuarantinedMetric
+	for _, m := range rows {
+		verdict := q.policy.CheckEpisodeMetrics(q.platform, m, now)
+		if q.keep(ctx, verdict, m.MetricDate, "episode", episode.EpisodeTitle) {
+			kept = append(kept, m)
+			continue
+		}
+		if verdict.Action == quality.ActionQuarantine {
+			hq := q.quarantine(scrapers.MetricKindEpisode, episode.PodcastID, m.MetricDate, verdict, m, now)
+			hq.PlatformEpisodeID = episode.PlatformEpisodeID
+			if episode.ID > 0 {
+				id := episode.ID
+				hq.EpisodeID = &id
+			}
+			held = append(held, hq)
+		}
+	}
+
+	q.hold(ctx, held)
+	return kept
+}
+
+// showMetrics returns the show rows collected for podcastID that may be written
+func (q *runQuality) showMetrics(ctx context.Context, podcastID int64, rows []*scrapers.ShowMetrics) []*scrapers.ShowMetrics {
+	if q.policy == nil {
+		return rows
+	}
+
+	now := time.Now()
+	var kept []*scrapers.ShowMetrics
+	var held []*scrapers.QuarantinedMetric
+	for _, m := range rows {
+		verdict := q.policy.CheckShowMetrics(q.platform, m, now)
+		if q.keep(ctx, verdict, m.MetricDate) {
+			kept = append(kept, m)
+			continue
+		}
+		if verdict.Action == quality.ActionQuarantine {
+			held = append(held, q.quarantine(scrapers.MetricKindShow, podcastID, m.MetricDate, verdict, m, now))
+		}
+	}
+
+	q.hold(ctx, held)
+	return kept
+}
+
+// keep counts and logs the violations of a row and reports whether the row
+// may still be written. Rejected rows are counted here, quarantined ones once
+// they are stored.
+func (q *runQuality) keep(ctx context.Context, verdict quality.Verdict, metricDate time.Time, attrs ...any) bool {
+	if len(verdict.Violations) == 0 {
+		return true
+	}
+
+	for _, v := range verdict.Violations {
+		promMetrics.ObserveQualityViolation(q.platform, v.Rule, string(verdict.Action))
+	}
+
+	attrs = append(attrs, "action", verdict.Action, "reasons", verdict.Reasons())
+	if !metricDate.IsZero() {
+		attrs = append(attrs, "metric_date", metricDate.Format("2006-01-02"))
+	}
+	slog.WarnContext(ctx, "Metric row broke data quality rules", attrs...)
+
+	if verdict.Action == quality.ActionReject {
+		q.rejected++
+	}
+	return verdict.Keep()
+}
+
+func (q *runQuality) quarantine(kind string, podcastID int64, metricDate time.Time, verdict quality.Verdict, row interface{}, now time.Time) *scrapers.QuarantinedMetric {
+	hq := &scrapers.QuarantinedMetric{
+		RunID:         q.runID,
+		Platform:      q.platform,
+		Kind:          kind,
+		PodcastID:     podcastID,
+		Reasons:       verdict.Reasons(),
+		Row:           row,
+		QuarantinedAt: now,
+	}
+	if !metricDate.IsZero() {
+		hq.MetricDate = &metricDate
+	}
+	return hq
+}
+
+// hold stores quarantined rows. Rows that cannot be stored are dropped as if
+// rejected, so they still never reach the metric tables.
+func (q *runQuality) hold(ctx context.Context, rows []*scrapers.QuarantinedMetric) {
+	if len(rows) == 0 {
+		return
+	}
+
+	if q.dryRun {
+		q.quarantined += len(rows)
+		return
+	}
+	if q.store == nil {
+		slog.WarnContext(ctx, "Store cannot quarantine metric rows, dropping them", "rows", len(rows))
+		q.rejected += len(rows)
+		return
+	}
+	if err := q.store.QuarantineMetrics(context.WithoutCancel(ctx), rows); err != nil {
+		slog.ErrorContext(ctx, "Failed to quarantine metric rows, dropping them", "rows", len(rows), logging.KeyError, err)
+		q.rejected += len(rows)
+		return
+	}
+	q.quarantined += len(rows)
+}
+
+// held returns the number of rows kept out of the metric tables
+func (q *runQuality) held() int {
+	return q.quarantined + q.rejected
+}
+
+// flag notes held back rows on the run's message so they show up in run listings
+func (q *runQuality) flag(run *scrapers.ScraperRun) {
+	if q.held() == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("data quality rules quarantined %d and rejected %d metric rows", q.quarantined, q.rejected)
+	if run.ErrorMessage != nil {
+		msg = *run.ErrorMessage + "; " + msg
+	}
+	run.ErrorMessage = &msg
+}
+
+// qualityChecks applies the data quality rules to rows written outside a
+// collection run, such as by import and reparse, where one command writes
+// rows of several platforms. Quarantined rows are recorded without a run.
+type qualityChecks struct {
+	policy *quality.Policy
+	store  repository.Store
+	dryRun bool
+	runs   map[scrapers.Platform]*runQuality
+}
+
+func newQualityChecks(policy *quality.Policy, store repository.Store, dryRun bool) *qualityChecks {
+	return &qualityChecks{policy: policy, store: store, dryRun: dryRun, runs: make(map[scrapers.Platform]*runQuality)}
+}
+
+// platform returns the checks for rows of platform
+func (c *qualityChecks) platform(platform scrapers.Platform) *runQuality {
+	q, ok := c.runs[platform]
+	if !ok {
+		q = newRunQuality(c.policy, c.store, 0, platform)
+		q.dryRun = c.dryRun
+		c.runs[platform] = q
+	}
+	return q
+}
+
+// counts returns the rows quarantined and rejected across every platform
+func (c *qualityChecks) counts() (quarantined, rejected int) {
+	for _, q := range c.runs {
+		quarantined += q.quarantined
+		rejected += q.rejected
+	}
+	return quarantined, rejected
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// quotaStore persists the API quota runs consume, so every process checks
+// its reserve against the day's usage rather than only its own calls
+type quotaStore interface {
+	SaveQuotaUsage(ctx context.Context, runID int64, platform scrapers.Platform, day time.Time, units int64) error
+	QuotaUsedOn(ctx context.Context, platform scrapers.Platform, day time.Time) (int64, error)
+}
+
+var _ quotaStore = (*repository.PodcastRepository)(nil)
+
+// runQuota meters the API quota one run consumes per UTC day
+type runQuota struct {
+	reporter scrapers.QuotaReporter // nil for platforms without a quota
+	store    quotaStore             // nil when usage is not persisted
+	runID    int64
+	platform scrapers.Platform
+
+	day   time.Time // UTC day usage is currently recorded on
+	start int64     // reporter usage when day began for this run
+	last  int64     // reporter usage at the last save
+}
+
+func newRunQuota(scraper scrapers.Scraper, store repository.Store, runID int64) *runQuota {
+	q := &runQuota{runID: runID, platform: scraper.GetPlatform(), day: truncateDay(time.Now().UTC())}
+	if r, ok := scraper.(scrapers.QuotaReporter); ok {
+		q.reporter = r
+		q.start = r.QuotaUsed()
+		q.last = q.start
+	}
+	if s, ok := store.(quotaStore); ok {
+		q.store = s
+	}
+	return q
+}
+
+// save records the run's usage so far. Usage up to the previous save stays
+// on the day it was made, so a run crossing midnight is split between days.
+func (q *runQuota) save(ctx context.Context) {
+	if q.reporter == nil || q.store == nil {
+		return
+	}
+
+	used := q.reporter.QuotaUsed()
+	if day := truncateDay(time.Now().UTC()); !day.Equal(q.day) {
+		q.day, q.start = day, q.last
+	}
+	q.last = used
+
+	if err := q.store.SaveQuotaUsage(ctx, q.runID, q.platform, q.day, used-q.start); err != nil {
+		slog.ErrorContext(ctx, "Failed to save quota usage", logging.KeyError, err)
+	}
+}
+
+// remaining returns the quota left today across every run, or what this
+// process has left when usage is not persisted. ok is false for platforms
+// without a quota.
+func (q *runQuota) remaining(ctx context.Context) (units int64, ok bool) {
+	if q.reporter == nil {
+		return 0, false
+	}
+	local := q.reporter.DailyQuota() - q.reporter.QuotaUsed()
+	if q.store == nil {
+		return local, true
+	}
+
+	q.save(ctx)
+	used, err := q.store.QuotaUsedOn(ctx, q.platform, q.day)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read quota usage, counting this process only", logging.KeyError, err)
+		return local, true
+	}
+	return q.reporter.DailyQuota() - used, true
+}
+
+// quotaRemainingToday returns the quota a platform has left today from the
+// usage runs persisted; ok is false when usage is not tracked
+func quotaRemainingToday(ctx context.Context, store repository.Store, scraper scrapers.Scraper) (units int64, ok bool) {
+	reporter, isReporter := scraper.(scrapers.QuotaReporter)
+	s, isStore := store.(quotaStore)
+	if !isReporter || !isStore {
+		return 0, false
+	}
+
+	used, err := s.QuotaUsedOn(ctx, scraper.GetPlatform(), truncateDay(time.Now().UTC()))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read quota usage", logging.KeyPlatform, scraper.GetPlatform(), logging.KeyError, err)
+		return 0, false
+	}
+	return reporter.DailyQuota() - used, true
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/archive"
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers/amazon"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers/apple"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers/spotify"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers/youtube"
+)
+
+// runReparse implements `podcast-scraper reparse`. It rebuilds metrics with
+// the current parsers from archived responses and from the raw_data stored on
+// rows collected before archiving, so a parser fix can be applied to history
+// without calling the platforms again.
+func runReparse(ctx context.Context, config *Config, args []string) error {
+	fs := flag.NewFlagSet("reparse", flag.ContinueOnError)
+	var f commandFlags
+	f.registerPlatform(fs)
+	f.registerDryRun(fs)
+	runID := fs.Int64("run", 0, "reparse only the archived responses of this scraper run")
+	since := fs.String("since", "", "reparse metrics dated on or after this date (YYYY-MM-DD)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := f.apply(config); err != nil {
+		return err
+	}
+	if *runID <= 0 && *since == "" {
+		return fmt.Errorf("--run or --since is required")
+	}
+
+	var sinceDate time.Time
+	if *since != "" {
+		t, err := time.Parse("2006-01-02", *since)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		sinceDate = t
+	}
+
+	var blobs archive.Store
+	if config.Archive.URL != "" {
+		var err error
+		blobs, err = archive.Open(config.Archive)
+		if err != nil {
+			return fmt.Errorf("failed to open response archive: %w", err)
+		}
+	} else if *runID > 0 {
+		return fmt.Errorf("--run needs the response archive, but ARCHIVE_URL is not set")
+	}
+
+	parsers, err := newParsers()
+	if err != nil {
+		return err
+	}
+
+	db, err := connectDatabase(config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := checkSchema(ctx, config, db); err != nil {
+		return err
+	}
+
+	repo := repository.NewPodcastRepository(db)
+
+	r := &reparser{
+		repo:    repo,
+		parsers: parsers,
+		batch:   newMetricsBatch(repo, config.MetricsBatchSize, config.MetricsFlushInterval),
+		checks:  newQualityChecks(config.Quality, repo, f.dryRun),
+		since:   sinceDate,
+		dryRun:  f.dryRun,
+	}
+
+	// Archived responses first, then payloads stored on rows from before archiving
+	if blobs != nil {
+		entries, err := repo.ListArchiveEntries(ctx, repository.ArchiveFilter{
+			RunID:     *runID,
+			Platforms: config.Platforms,
+			Since:     sinceDate,
+		})
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := r.reparseArchived(ctx, blobs, entry); err != nil {
+				slog.WarnContext(ctx, "Failed to reparse archived response", logging.KeyPlatform, entry.Platform,
+					logging.KeyRunID, entry.RunID, "archive_key", entry.Key, logging.KeyError, err)
+				r.failed++
+			}
+		}
+	}
+
+	if *runID == 0 {
+		filter := repository.MetricsFilter{Platforms: config.Platforms, StartDate: sinceDate}
+
+		episodePayloads, err := repo.ListEpisodeMetricsPayloads(ctx, filter)
+		if err != nil {
+			return err
+		}
+		showPayloads, err := repo.ListShowMetricsPayloads(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		for _, payload := range append(episodePayloads, showPayloads...) {
+			if err := r.reparseStored(ctx, payload); err != nil {
+				slog.WarnContext(ctx, "Failed to reparse stored payload", logging.KeyPlatform, payload.Platform,
+					"episode_row_id", payload.EpisodeID, logging.KeyError, err)
+				r.failed++
+			}
+		}
+	}
+
+	r.batch.flush(ctx)
+
+	verb := "Reparsed"
+	if f.dryRun {
+		verb = "Would reparse"
+	}
+	quarantined, rejected := r.checks.counts()
+	fmt.Printf("%s %d archived responses and %d stored payloads into %d episode metrics and %d show metrics (%d skipped, %d unsupported, %d failed, %d quarantined, %d rejected)\n",
+		verb, r.archived, r.stored, r.episodeMetrics, r.showMetrics, r.skipped, r.unsupported, r.failed, quarantined, rejected)
+
+	if r.failed > 0 {
+		return errUnhealthy
+	}
+	return nil
+}
+
+// newParsers returns a response parser for every platform. Parsing needs no
+// credentials, so the scrapers are created without any.
+func newParsers() (map[scrapers.Platform]scrapers.ResponseParser, error) {
+	appleScraper, err := apple.NewScraper(apple.Config{})
+	if err != nil {
+		return nil, err
+	}
+	spotifyScraper, err := spotify.NewScraper(spotify.Config{})
+	if err != nil {
+		return nil, err
+	}
+	amazonScraper, err := amazon.NewScraper(amazon.Config{})
+	if err != nil {
+		return nil, err
+	}
+	youtubeScraper, err := youtube.NewScraper(youtube.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[scrapers.Platform]scrapers.ResponseParser{
+		scrapers.PlatformApplePodcasts: appleScraper,
+		scrapers.PlatformSpotify:       spotifyScraper,
+		scrapers.PlatformAmazonMusic:   amazonScraper,
+		scrapers.PlatformYouTube:       youtubeScraper,
+	}, nil
+}
+
+// reparser rewrites metrics from stored responses
+type reparser struct {
+	repo    *repository.PodcastRepository
+	parsers map[scrapers.Platform]scrapers.ResponseParser
+	batch   *metricsBatch
+	checks  *qualityChecks
+	since   time.Time
+	dryRun  bool
+
+	archived, stored, episodeMetrics, showMetrics, skipped, failed int
+
+	// unsupported counts responses of platforms whose parser cannot rebuild
+	// metrics; their rows are left as they are
+	unsupported int
+}
+
+// reparseArchived rewrites the metrics parsed from one archived response.
+// Responses other than metrics, such as episode lists, are skipped.
+func (r *reparser) reparseArchived(ctx context.Context, blobs archive.Store, entry *repository.ArchiveEntry) error {
+	if entry.Endpoint != scrapers.EndpointEpisodeMetrics && entry.Endpoint != scrapers.EndpointShowMetrics {
+		r.skipped++
+		return nil
+	}
+	if entry.PodcastID == nil || entry.StartDate == nil || entry.EndDate == nil {
+		return fmt.Errorf("archived %s response has no podcast or window", entry.Endpoint)
+	}
+
+	body, err := archive.Load(ctx, blobs, entry.Key)
+	if err != nil {
+		return err
+	}
+	r.archived++
+
+	src := &reparseSource{
+		platform:   entry.Platform,
+		podcastID:  *entry.PodcastID,
+		startDate:  *entry.StartDate,
+		endDate:    *entry.EndDate,
+		runID:      entry.RunID,
+		archiveKey: entry.Key,
+		body:       body,
+	}
+
+	if entry.Endpoint == scrapers.EndpointShowMetrics {
+		return r.showMetricsFrom(ctx, src)
+	}
+
+	episode := &scrapers.Episode{
+		PodcastID:         *entry.PodcastID,
+		PlatformEpisodeID: entry.PlatformEpisodeID,
+	}
+	if entry.EpisodeID != nil {
+		episode.ID = *entry.EpisodeID
+	} else {
+		// The episode was new to the run and stored after its metrics were fetched
+		episode.ID, err = r.repo.LookupEpisodeID(ctx, episode.PodcastID, episode.PlatformEpisodeID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("episode %q was never stored", episode.PlatformEpisodeID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.episodeMetricsFrom(ctx, src, episode)
+}
+
+// reparseStored rewrites the rows a raw_data payload was stored on. The
+// payload's window is unknown, so only dates it was stored on are rewritten.
+func (r *reparser) reparseStored(ctx context.Context, payload *repository.StoredPayload) error {
+	r.stored++
+
+	src := &reparseSource{
+		platform:  payload.Platform,
+		podcastID: payload.PodcastID,
+		startDate: payload.FirstDate,
+		endDate:   payload.LastDate,
+		runID:     payload.RunID,
+		body:      payload.RawData,
+	}
+
+	if payload.EpisodeID == 0 {
+		return r.showMetricsFrom(ctx, src)
+	}
+
+	return r.episodeMetricsFrom(ctx, src, &scrapers.Episode{
+		ID:                payload.EpisodeID,
+		PodcastID:         payload.PodcastID,
+		PlatformEpisodeID: payload.PlatformEpisodeID,
+	})
+}
+
+// reparseSource is a response body to parse and what is known about it
+type reparseSource struct {
+	platform           scrapers.Platform
+	podcastID          int64
+	startDate, endDate time.Time
+	runID              int64
+	archiveKey         string // Empty for payloads stored in raw_data
+	body               []byte
+}
+
+// keep reports whether a parsed row falls inside the source's window and
+// the --since bound
+func (r *reparser) keep(src *reparseSource, date time.Time) bool {
+	date = truncateDay(date)
+	if date.Before(truncateDay(src.startDate)) || date.After(truncateDay(src.endDate)) {
+		return false
+	}
+	return r.since.IsZero() || !date.Before(r.since)
+}
+
+func (r *reparser) episodeMetricsFrom(ctx context.Context, src *reparseSource, episode *scrapers.Episode) error {
+	parser, ok := r.parsers[src.platform]
+	if !ok {
+		return fmt.Errorf("no parser for platform %s", src.platform)
+	}
+
+	parsed, err := parser.ParseEpisodeMetrics(episode, src.startDate, src.endDate, src.body)
+	if errors.Is(err, scrapers.ErrParseUnsupported) {
+		r.unsupported++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var metrics []*scrapers.EpisodeMetrics
+	for _, metric := range parsed {
+		if !r.keep(src, metric.MetricDate) {
+			continue
+		}
+		metric.EpisodeID = episode.ID
+		metric.RunID = src.runID
+		if src.archiveKey != "" {
+			metric.RawData = scrapers.ArchiveRef(src.archiveKey)
+		}
+		metrics = append(metrics, metric)
+	}
+	metrics = r.checks.platform(src.platform).episodeMetrics(ctx, episode, metrics)
+
+	if r.dryRun {
+		r.episodeMetrics += len(metrics)
+		return nil
+	}
+
+	r.batch.add(ctx, metrics, func(ctx context.Context, written int, errs []error) {
+		r.episodeMetrics += written
+		for _, err := range errs {
+			slog.ErrorContext(ctx, "Failed to store reparsed metrics", "episode_row_id", episode.ID, logging.KeyError, err)
+			r.failed++
+		}
+	})
+	return nil
+}
+
+func (r *reparser) showMetricsFrom(ctx context.Context, src *reparseSource) error {
+	parser, ok := r.parsers[src.platform]
+	if !ok {
+		return fmt.Errorf("no parser for platform %s", src.platform)
+	}
+
+	podcast := &scrapers.Podcast{ID: src.podcastID, Platform: src.platform}
+	parsed, err := parser.ParseShowMetrics(podcast, src.startDate, src.endDate, src.body)
+	if errors.Is(err, scrapers.ErrParseUnsupported) {
+		r.unsupported++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var metrics []*scrapers.ShowMetrics
+	for _, metric := range parsed {
+		if !r.keep(src, metric.MetricDate) {
+			continue
+		}
+		metric.PodcastID = src.podcastID
+		if src.archiveKey != "" {
+			metric.RawData = scrapers.ArchiveRef(src.archiveKey)
+		}
+		metrics = append(metrics, metric)
+	}
+	metrics = r.checks.platform(src.platform).showMetrics(ctx, src.podcastID, metrics)
+
+	for _, metric := range metrics {
+		if !r.dryRun {
+			if err := r.repo.UpsertShowMetrics(ctx, metric); err != nil {
+				return err
+			}
+		}
+		r.showMetrics++
+	}
+	return nil
+}
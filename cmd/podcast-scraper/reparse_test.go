@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+func TestReparseSkipsUnsupportedParsers(t *testing.T) {
+	parsers, err := newParsers()
+	if err != nil {
+		t.Fatalf("newParsers() error = %v", err)
+	}
+	r := &reparser{parsers: parsers, checks: newQualityChecks(nil, nil, true), dryRun: true}
+	ctx := context.Background()
+	body := []byte(`{"starts": 10, "streams": 8, "listeners": 6}`)
+
+	// Rows of these platforms would be overwritten with zeros
+	for _, platform := range []scrapers.Platform{scrapers.PlatformSpotify, scrapers.PlatformApplePodcasts, scrapers.PlatformAmazonMusic} {
+		src := &reparseSource{platform: platform, podcastID: 1, startDate: day(2026, 1, 1), endDate: day(2026, 1, 1), body: body}
+		if err := r.episodeMetricsFrom(ctx, src, &scrapers.Episode{ID: 2, PodcastID: 1}); err != nil {
+			t.Errorf("%s episode metrics error = %v", platform, err)
+		}
+		if err := r.showMetricsFrom(ctx, src); err != nil {
+			t.Errorf("%s show metrics error = %v", platform, err)
+		}
+	}
+
+	if r.unsupported != 6 || r.episodeMetrics != 0 || r.showMetrics != 0 {
+		t.Errorf("unsupported %d, episode metrics %d, show metrics %d, want 6, 0, 0", r.unsupported, r.episodeMetrics, r.showMetrics)
+	}
+}
+
+func TestReparseYouTube(t *testing.T) {
+	parsers, err := newParsers()
+	if err != nil {
+		t.Fatalf("newParsers() error = %v", err)
+	}
+	r := &reparser{parsers: parsers, checks: newQualityChecks(nil, nil, true), dryRun: true}
+
+	// The second day falls outside the window the response was stored for
+	src := &reparseSource{
+		platform:  scrapers.PlatformYouTube,
+		podcastID: 1,
+		startDate: day(2026, 1, 1),
+		endDate:   day(2026, 1, 1),
+		body:      []byte(`{"rows": [["2026-01-01", 100, 5, 0, 2, 1, 300, 180, 3, 0], ["2026-01-02", 90, 4, 0, 1, 0, 250, 170, 1, 0]]}`),
+	}
+	if err := r.episodeMetricsFrom(context.Background(), src, &scrapers.Episode{ID: 2, PodcastID: 1}); err != nil {
+		t.Fatalf("episodeMetricsFrom() error = %v", err)
+	}
+	if r.episodeMetrics != 1 || r.unsupported != 0 {
+		t.Errorf("episode metrics %d, unsupported %d, want 1, 0", r.episodeMetrics, r.unsupported)
+	}
+}
@@ -0,0 +1,621 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/schedule"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// runReader is implemented by stores that can list recorded scraper runs
+type runReader interface {
+	ListScraperRuns(ctx context.Context, filter repository.RunFilter) ([]*scrapers.ScraperRun, error)
+	GetScraperRun(ctx context.Context, runID int64) (*scrapers.ScraperRun, error)
+	ListScraperErrors(ctx context.Context, runID int64) ([]*scrapers.ScraperError, error)
+}
+
+// pinger is implemented by stores backed by a database connection
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// maxQueuedRuns bounds the on-demand collections waiting for the worker
+const maxQueuedRuns = 16
+
+// server runs scheduled and on-demand collections one at a time and serves
+// health, readiness and run history over HTTP
+type server struct {
+	config    *Config
+	store     repository.Store
+	collector *Collector
+	pruner    pruneStore
+	schedules map[scrapers.Platform]*schedule.Schedule
+
+	queue    chan *runRequest
+	draining atomic.Bool
+
+	// pending counts the queued and running collections of each platform
+	mu      sync.Mutex
+	pending map[scrapers.Platform]int
+}
+
+// runRequest is the body of POST /runs. Every field is optional: an empty
+// platform collects all of them and an empty range the lookback window.
+type runRequest struct {
+	Platform string `json:"platform,omitempty"`
+	Show     string `json:"show,omitempty"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+
+	collector *Collector
+	opts      CollectOptions
+	scheduled bool
+}
+
+func newServer(config *Config, store repository.Store, collector *Collector) (*server, error) {
+	schedules := map[scrapers.Platform]*schedule.Schedule{}
+	for _, platform := range collector.platforms() {
+		sched, err := config.scheduleFor(platform)
+		if err != nil {
+			return nil, err
+		}
+		schedules[platform] = sched
+	}
+
+	pruner, _ := store.(pruneStore)
+	if config.PruneAfterCollect && pruner == nil {
+		slog.Warn("Pruning after collections is not supported by this store", "store", config.Store)
+	}
+
+	return &server{
+		config:    config,
+		store:     store,
+		collector: collector,
+		pruner:    pruner,
+		schedules: schedules,
+		queue:     make(chan *runRequest, maxQueuedRuns),
+		pending:   map[scrapers.Platform]int{},
+	}, nil
+}
+
+// run serves HTTP on addr and collects until ctx is done, then drains: new
+// requests are refused and the collection in flight may finish within
+// DrainTimeout before it is cancelled
+func (s *server) run(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	httpServer := &http.Server{Handler: s.routes(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.ErrorContext(ctx, "HTTP server stopped", logging.KeyError, err)
+		}
+	}()
+	slog.InfoContext(ctx, "Serving", "addr", addr)
+
+	// Collections outlive ctx so a signal does not abort writes in flight
+	runCtx, cancelRuns := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelRuns()
+
+	// Close out runs left behind by a killed process, here or elsewhere
+	reapStaleRuns(ctx, s.store, s.config.StaleRunAfter)
+	go s.reap(ctx)
+	go s.schedule(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.work(ctx, runCtx)
+	}()
+
+	<-ctx.Done()
+	s.draining.Store(true)
+	slog.InfoContext(runCtx, "Draining", "timeout", s.config.DrainTimeout.String())
+
+	shutdownCtx, cancel := context.WithTimeout(runCtx, 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		slog.ErrorContext(runCtx, "Failed to stop HTTP server", logging.KeyError, err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(s.config.DrainTimeout):
+		slog.WarnContext(runCtx, "Drain timed out, cancelling the collection in flight")
+		cancelRuns()
+		<-done
+	}
+
+	if n := len(s.queue); n > 0 {
+		slog.WarnContext(runCtx, "Dropped queued collections", "runs", n)
+	}
+	slog.InfoContext(runCtx, "Stopped")
+	return nil
+}
+
+// work runs queued collections one at a time until ctx is done.
+// Collections run with runCtx.
+func (s *server) work(ctx, runCtx context.Context) {
+	for {
+		// Stop before picking up more work once draining
+		if ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-s.queue:
+			if !req.scheduled {
+				slog.InfoContext(runCtx, "Starting requested collection", logging.KeyPlatform, req.Platform,
+					logging.KeyShow, req.opts.ShowName, "from", req.opts.StartDate.Format("2006-01-02"), "to", req.opts.EndDate.Format("2006-01-02"))
+			}
+			s.collect(runCtx, req.collector, req.opts)
+			s.release(req)
+		}
+	}
+}
+
+// schedule queues a collection for each platform whenever its schedule is
+// due until ctx is done. A tick is skipped while the platform's previous
+// collection is still queued or running.
+func (s *server) schedule(ctx context.Context) {
+	now := time.Now()
+	due := make(map[scrapers.Platform]time.Time, len(s.schedules))
+	for platform, sched := range s.schedules {
+		due[platform] = s.firstDue(ctx, platform, sched, now)
+		slog.InfoContext(ctx, "Scheduled collection", logging.KeyPlatform, platform,
+			"schedule", sched.String(), "next", due[platform].Format(time.RFC3339))
+	}
+
+	for len(due) > 0 {
+		var next time.Time
+		for _, t := range due {
+			if next.IsZero() || t.Before(next) {
+				next = t
+			}
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		for platform, t := range due {
+			if t.After(now) {
+				continue
+			}
+			s.enqueueScheduled(ctx, platform, now)
+			due[platform] = s.schedules[platform].Next(now).Add(schedule.Jitter(s.config.ScheduleJitter))
+		}
+	}
+}
+
+// reap marks stale runs abandoned every ReapInterval until ctx is done
+func (s *server) reap(ctx context.Context) {
+	if s.config.ReapInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.config.ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reapStaleRuns(ctx, s.store, s.config.StaleRunAfter)
+		}
+	}
+}
+
+// firstDue returns when platform is first collected after startup: right
+// away when catching up on a tick missed while serve was down, otherwise at
+// its next tick
+func (s *server) firstDue(ctx context.Context, platform scrapers.Platform, sched *schedule.Schedule, now time.Time) time.Time {
+	next := sched.Next(now).Add(schedule.Jitter(s.config.ScheduleJitter))
+	if !s.config.ScheduleCatchUp {
+		return next
+	}
+
+	// Without run history every platform is collected on startup
+	reader, ok := s.store.(runReader)
+	if !ok {
+		return now
+	}
+
+	runs, err := reader.ListScraperRuns(ctx, repository.RunFilter{Platforms: []scrapers.Platform{platform}, Limit: 1})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read the last run, collecting now", logging.KeyPlatform, platform, logging.KeyError, err)
+		return now
+	}
+	if len(runs) == 0 || sched.Missed(runs[0].RunStartedAt, now) {
+		return now
+	}
+	return next
+}
+
+// enqueueScheduled queues a scheduled collection of platform
+func (s *server) enqueueScheduled(ctx context.Context, platform scrapers.Platform, now time.Time) {
+	collector, _ := s.collector.only(platform)
+	req := &runRequest{
+		Platform:  string(platform),
+		collector: collector,
+		opts:      defaultCollectOptions(s.config, now),
+		scheduled: true,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending[platform] > 0 {
+		slog.WarnContext(ctx, "Skipping scheduled collection, the previous one has not finished", logging.KeyPlatform, platform)
+		return
+	}
+	if !s.enqueueLocked(req) {
+		slog.WarnContext(ctx, "Skipping scheduled collection, the queue is full", logging.KeyPlatform, platform)
+	}
+}
+
+// enqueue queues req, reporting false when the queue is full
+func (s *server) enqueue(req *runRequest) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enqueueLocked(req)
+}
+
+func (s *server) enqueueLocked(req *runRequest) bool {
+	select {
+	case s.queue <- req:
+	default:
+		return false
+	}
+	for _, platform := range req.collector.platforms() {
+		s.pending[platform]++
+	}
+	return true
+}
+
+// release marks req's collection finished
+func (s *server) release(req *runRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, platform := range req.collector.platforms() {
+		s.pending[platform]--
+	}
+}
+
+// collect runs one collection, then pushes metrics and prunes when enabled
+func (s *server) collect(ctx context.Context, collector *Collector, opts CollectOptions) {
+	if err := collector.Collect(ctx, opts); err != nil {
+		slog.ErrorContext(ctx, "Collection failed", logging.KeyError, err)
+	}
+	pushMetrics(ctx, s.config)
+	if s.config.PruneAfterCollect && s.pruner != nil {
+		pruneAfterCollect(ctx, s.pruner, s.config.Retention)
+	}
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	mux.HandleFunc("POST /runs", s.handleTriggerRun)
+	mux.HandleFunc("GET /runs", s.handleListRuns)
+	mux.HandleFunc("GET /runs/{id}", s.handleGetRun)
+	mux.Handle("GET /metrics", promMetrics.Handler())
+	return mux
+}
+
+// handleHealthz reports the process is up
+func (s *server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz reports whether the server is not draining, its store is
+// reachable and every platform it collects succeeded recently
+func (s *server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	checks := map[string]string{}
+	ready := true
+	fail := func(name, reason string) {
+		checks[name] = reason
+		ready = false
+	}
+
+	if s.draining.Load() {
+		fail("server", "draining")
+	}
+
+	if p, ok := s.store.(pinger); ok {
+		if err := p.Ping(ctx); err != nil {
+			fail("database", logging.Redact(err.Error()))
+		} else {
+			checks["database"] = "ok"
+		}
+	}
+
+	// Freshness is only known for stores that keep run history
+	if reader, ok := s.store.(runReader); ok {
+		now := time.Now()
+		maxAges := map[scrapers.Platform]time.Duration{}
+		var oldest time.Duration
+		for _, platform := range s.collector.platforms() {
+			maxAges[platform] = s.maxRunAge(platform, now)
+			if maxAges[platform] > oldest {
+				oldest = maxAges[platform]
+			}
+		}
+
+		runs, err := reader.ListScraperRuns(ctx, repository.RunFilter{
+			Platforms: s.collector.platforms(),
+			Since:     now.Add(-oldest),
+			Limit:     1000,
+		})
+		if err != nil {
+			fail("runs", logging.Redact(err.Error()))
+		}
+
+		succeeded := map[scrapers.Platform]time.Time{}
+		for _, run := range runs {
+			if run.Status != scrapers.RunStatusCompleted && run.Status != scrapers.RunStatusPartial {
+				continue
+			}
+			if _, ok := succeeded[run.Platform]; !ok {
+				succeeded[run.Platform] = run.RunStartedAt
+			}
+		}
+
+		for _, platform := range s.collector.platforms() {
+			if err != nil {
+				break
+			}
+			maxAge := maxAges[platform]
+			if startedAt, ok := succeeded[platform]; ok && now.Sub(startedAt) <= maxAge {
+				checks[string(platform)] = "last succeeded " + startedAt.UTC().Format(time.RFC3339)
+				continue
+			}
+			fail(string(platform), "no successful run in the last "+maxAge.String())
+		}
+	}
+
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, map[string]interface{}{"ready": ready, "checks": checks})
+}
+
+// handleTriggerRun queues a collection for a platform, show and date range
+func (s *server) handleTriggerRun(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		writeError(w, http.StatusServiceUnavailable, errors.New("server is draining"))
+		return
+	}
+
+	req := &runRequest{}
+	if r.ContentLength != 0 {
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+	}
+
+	if err := s.resolve(req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !s.enqueue(req) {
+		writeError(w, http.StatusTooManyRequests, fmt.Errorf("%d collections are already queued", maxQueuedRuns))
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"queued":   len(s.queue),
+		"platform": req.Platform,
+		"show":     req.opts.ShowName,
+		"from":     req.opts.StartDate.Format("2006-01-02"),
+		"to":       req.opts.EndDate.Format("2006-01-02"),
+	})
+}
+
+// resolve validates a run request and fills in its collector and options,
+// following the rules of the collect command
+func (s *server) resolve(req *runRequest) error {
+	req.collector = s.collector
+	if req.Platform != "" {
+		platform, err := scrapers.ParsePlatform(req.Platform)
+		if err != nil {
+			return err
+		}
+		collector, ok := s.collector.only(platform)
+		if !ok {
+			return fmt.Errorf("platform %s is not collected by this server", platform)
+		}
+		req.collector = collector
+	}
+
+	req.opts = defaultCollectOptions(s.config, time.Now())
+	if req.Show != "" {
+		req.opts.ShowName = req.Show
+	}
+
+	// An explicit range is fetched exactly as asked
+	if req.From != "" || req.To != "" {
+		f := commandFlags{from: req.From, to: req.To}
+		startDate, endDate, err := f.dateRange(s.config.LookbackDays)
+		if err != nil {
+			return err
+		}
+		req.opts.StartDate, req.opts.EndDate = startDate, endDate
+		req.opts.Incremental = false
+	}
+
+	return nil
+}
+
+// runJSON is a scraper run as returned by the runs endpoints
+type runJSON struct {
+	ID                int64      `json:"id"`
+	Platform          string     `json:"platform"`
+	Status            string     `json:"status"`
+	StartedAt         time.Time  `json:"started_at"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+	EpisodesProcessed int        `json:"episodes_processed"`
+	MetricsCollected  int        `json:"metrics_collected"`
+	SchemaDrift       int        `json:"schema_drift"`
+	ErrorMessage      *string    `json:"error_message,omitempty"`
+}
+
+func newRunJSON(run *scrapers.ScraperRun) runJSON {
+	return runJSON{
+		ID:                run.ID,
+		Platform:          string(run.Platform),
+		Status:            string(run.Status),
+		StartedAt:         run.RunStartedAt,
+		CompletedAt:       run.RunCompletedAt,
+		EpisodesProcessed: run.EpisodesProcessed,
+		MetricsCollected:  run.MetricsCollected,
+		SchemaDrift:       run.SchemaDrift,
+		ErrorMessage:      run.ErrorMessage,
+	}
+}
+
+// errorJSON is a per-item error of a run
+type errorJSON struct {
+	Stage        string    `json:"stage"`
+	Class        string    `json:"class"`
+	EpisodeID    *int64    `json:"episode_id,omitempty"`
+	EpisodeTitle string    `json:"episode_title,omitempty"`
+	HTTPStatus   *int      `json:"http_status,omitempty"`
+	Message      string    `json:"message"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
+
+// handleListRuns lists recent runs, optionally for ?platform= and up to ?limit=
+func (s *server) handleListRuns(w http.ResponseWriter, r *http.Request) {
+	reader, ok := s.store.(runReader)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, fmt.Errorf("run history is not available with STORE=%s", s.config.Store))
+		return
+	}
+
+	filter := repository.RunFilter{Limit: 50}
+	if v := r.URL.Query().Get("platform"); v != "" {
+		platforms, err := parsePlatforms(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		filter.Platforms = platforms
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", v))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	runs, err := reader.ListScraperRuns(r.Context(), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	out := make([]runJSON, len(runs))
+	for i, run := range runs {
+		out[i] = newRunJSON(run)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"runs": out})
+}
+
+// handleGetRun returns a run with its per-item errors
+func (s *server) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	reader, ok := s.store.(runReader)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, fmt.Errorf("run history is not available with STORE=%s", s.config.Store))
+		return
+	}
+
+	runID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid run id %q", r.PathValue("id")))
+		return
+	}
+
+	run, err := reader.GetScraperRun(r.Context(), runID)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("run %d not found", runID))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ledger, err := reader.ListScraperErrors(r.Context(), runID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	errs := make([]errorJSON, len(ledger))
+	for i, e := range ledger {
+		errs[i] = errorJSON{
+			Stage:        e.Stage,
+			Class:        string(e.ErrorClass),
+			EpisodeID:    e.EpisodeID,
+			EpisodeTitle: e.EpisodeTitle,
+			HTTPStatus:   e.HTTPStatus,
+			Message:      e.Message,
+			OccurredAt:   e.OccurredAt,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"run": newRunJSON(run), "errors": errs})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": logging.Redact(err.Error())})
+}
+
+// maxRunAge returns how recently platform must have succeeded for serve to
+// be ready: READY_MAX_RUN_AGE, or twice the gap between its scheduled runs
+func (s *server) maxRunAge(platform scrapers.Platform, now time.Time) time.Duration {
+	if s.config.ReadyMaxRunAge > 0 {
+		return s.config.ReadyMaxRunAge
+	}
+	return 2 * s.schedules[platform].Period(now)
+}
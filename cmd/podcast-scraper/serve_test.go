@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// historyStore is a memory store that can list the last run of a platform
+type historyStore struct {
+	*repository.MemoryStore
+	runs []*scrapers.ScraperRun
+}
+
+func (s *historyStore) ListScraperRuns(ctx context.Context, filter repository.RunFilter) ([]*scrapers.ScraperRun, error) {
+	return s.runs, nil
+}
+
+func (s *historyStore) GetScraperRun(ctx context.Context, runID int64) (*scrapers.ScraperRun, error) {
+	return nil, nil
+}
+
+func (s *historyStore) ListScraperErrors(ctx context.Context, runID int64) ([]*scrapers.ScraperError, error) {
+	return nil, nil
+}
+
+func TestScheduleForPrecedence(t *testing.T) {
+	config := &Config{
+		ScheduleInterval:  6 * time.Hour,
+		Schedule:          "0 6 * * *",
+		PlatformSchedules: map[scrapers.Platform]string{scrapers.PlatformYouTube: "@hourly"},
+	}
+	from := time.Date(2026, 3, 1, 0, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		config   *Config
+		platform scrapers.Platform
+		want     time.Time
+	}{
+		{name: "platform schedule", config: config, platform: scrapers.PlatformYouTube, want: time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC)},
+		{name: "shared schedule", config: config, platform: scrapers.PlatformApplePodcasts, want: time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)},
+		{name: "interval", config: &Config{ScheduleInterval: 6 * time.Hour}, platform: scrapers.PlatformSpotify, want: from.Add(6 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched, err := tt.config.scheduleFor(tt.platform)
+			if err != nil {
+				t.Fatalf("scheduleFor() error = %v", err)
+			}
+			if got := sched.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	_, err := (&Config{PlatformSchedules: map[scrapers.Platform]string{scrapers.PlatformSpotify: "hourly"}}).scheduleFor(scrapers.PlatformSpotify)
+	if err == nil {
+		t.Error("scheduleFor() with an invalid expression error = nil")
+	}
+}
+
+func TestFirstDueCatchesUpOnMissedTicks(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	config := &Config{Schedule: "0 6 * * *", ScheduleCatchUp: true}
+	sched, err := config.scheduleFor(scrapers.PlatformSpotify)
+	if err != nil {
+		t.Fatalf("scheduleFor() error = %v", err)
+	}
+	next := time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		catchUp bool
+		runs    []*scrapers.ScraperRun
+		want    time.Time
+	}{
+		{name: "no run history", catchUp: true, want: now},
+		{name: "missed a tick", catchUp: true, runs: []*scrapers.ScraperRun{{RunStartedAt: now.Add(-30 * time.Hour)}}, want: now},
+		{name: "ran since the last tick", catchUp: true, runs: []*scrapers.ScraperRun{{RunStartedAt: now.Add(-3 * time.Hour)}}, want: next},
+		{name: "catch-up off", runs: []*scrapers.ScraperRun{{RunStartedAt: now.Add(-30 * time.Hour)}}, want: next},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &server{
+				config: &Config{ScheduleCatchUp: tt.catchUp},
+				store:  &historyStore{MemoryStore: repository.NewMemoryStore(), runs: tt.runs},
+			}
+			if got := s.firstDue(context.Background(), scrapers.PlatformSpotify, sched, now); !got.Equal(tt.want) {
+				t.Errorf("firstDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnqueueScheduledSkipsUnfinishedCollections(t *testing.T) {
+	store := repository.NewMemoryStore()
+	s := &server{
+		config:    &Config{},
+		store:     store,
+		collector: NewCollector(store, nil, []scrapers.Scraper{newFakeScraper()}),
+		queue:     make(chan *runRequest, maxQueuedRuns),
+		pending:   map[scrapers.Platform]int{},
+	}
+	ctx := context.Background()
+
+	s.enqueueScheduled(ctx, scrapers.PlatformSpotify, time.Now())
+	s.enqueueScheduled(ctx, scrapers.PlatformSpotify, time.Now())
+	if got := len(s.queue); got != 1 {
+		t.Fatalf("queued collections = %d, want 1 while the first is pending", got)
+	}
+
+	s.release(<-s.queue)
+	s.enqueueScheduled(ctx, scrapers.PlatformSpotify, time.Now())
+	if got := len(s.queue); got != 1 {
+		t.Errorf("queued collections after the first finished = %d, want 1", got)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// stagingMemoryStore holds staged metrics aside until the run is published
+type stagingMemoryStore struct {
+	*repository.MemoryStore
+	staged               []*scrapers.EpisodeMetrics
+	published, discarded []int64
+}
+
+// stagedRun writes podcasts and episodes through and queues metrics
+type stagedRun struct {
+	repository.Store
+	owner *stagingMemoryStore
+}
+
+func (s *stagedRun) UpsertEpisodeMetricsBatch(ctx context.Context, metrics []*scrapers.EpisodeMetrics) []error {
+	s.owner.staged = append(s.owner.staged, metrics...)
+	return make([]error, len(metrics))
+}
+
+func (s *stagedRun) InTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	return fn(s)
+}
+
+func (s *stagingMemoryStore) StageRun(ctx context.Context, runID int64) (repository.Store, error) {
+	return &stagedRun{Store: s.MemoryStore, owner: s}, nil
+}
+
+func (s *stagingMemoryStore) PublishRun(ctx context.Context, runID int64) error {
+	for _, err := range s.MemoryStore.UpsertEpisodeMetricsBatch(ctx, s.staged) {
+		if err != nil {
+			return err
+		}
+	}
+	s.staged = nil
+	s.published = append(s.published, runID)
+	return nil
+}
+
+func (s *stagingMemoryStore) DiscardRun(ctx context.Context, runID int64) error {
+	s.staged = nil
+	s.discarded = append(s.discarded, runID)
+	return nil
+}
+
+func TestCollectorPublishesStagedRun(t *testing.T) {
+	store := &stagingMemoryStore{MemoryStore: repository.NewMemoryStore()}
+	collector := NewCollector(store, nil, []scrapers.Scraper{newFakeScraper()})
+
+	opts := testCollectOptions()
+	opts.Staged = true
+	if err := collector.Collect(context.Background(), opts); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	if len(store.published) != 1 || len(store.discarded) != 0 {
+		t.Fatalf("published %v, discarded %v, want one run published", store.published, store.discarded)
+	}
+	if got := len(store.EpisodeMetrics()); got != 3 {
+		t.Errorf("episode metrics = %d, want 3", got)
+	}
+	if run := store.Runs()[0]; run.Status != scrapers.RunStatusCompleted {
+		t.Errorf("run status = %s, want completed", run.Status)
+	}
+}
+
+func TestCollectorDiscardsStagedRunWithErrors(t *testing.T) {
+	store := &stagingMemoryStore{MemoryStore: repository.NewMemoryStore()}
+	scraper := newFakeScraper()
+	scraper.fetchErrs = map[string]error{"ep-2": errors.New("rate limited")}
+	collector := NewCollector(store, nil, []scrapers.Scraper{scraper})
+
+	opts := testCollectOptions()
+	opts.Staged = true
+	if err := collector.Collect(context.Background(), opts); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	// ep-1 was staged but a staged run is all or nothing
+	if len(store.published) != 0 || len(store.discarded) != 1 {
+		t.Fatalf("published %v, discarded %v, want one run discarded", store.published, store.discarded)
+	}
+	if got := len(store.EpisodeMetrics()); got != 0 {
+		t.Errorf("episode metrics = %d, want 0", got)
+	}
+	if run := store.Runs()[0]; run.Status != scrapers.RunStatusFailed {
+		t.Errorf("run status = %s, want failed", run.Status)
+	}
+}
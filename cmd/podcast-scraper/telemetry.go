@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+	"github.com/soypete/eleduck-analytics-connector/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// promMetrics collects the Prometheus metrics of this process
+var promMetrics = telemetry.NewMetrics()
+
+// serveMetrics starts the metrics listener when METRICS_ADDR is set
+func serveMetrics(ctx context.Context, config *Config) error {
+	if config.MetricsAddr == "" {
+		return nil
+	}
+	if err := promMetrics.Serve(ctx, config.MetricsAddr); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "Serving Prometheus metrics", "addr", config.MetricsAddr, "path", "/metrics")
+	return nil
+}
+
+// pushMetrics pushes the metrics to the Pushgateway when PUSHGATEWAY_URL is
+// set. A failed push is logged rather than failing the collection.
+func pushMetrics(ctx context.Context, config *Config) {
+	if config.PushgatewayURL == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	if err := promMetrics.Push(ctx, config.PushgatewayURL, config.PushgatewayJob); err != nil {
+		slog.ErrorContext(ctx, "Failed to push metrics", logging.KeyError, err)
+	}
+}
+
+// observeRun records a finished run and, for quota-limited platforms, the
+// quota it left consumed
+func observeRun(scraper scrapers.Scraper, run *scrapers.ScraperRun) {
+	promMetrics.ObserveRun(run)
+	if r, ok := scraper.(scrapers.QuotaReporter); ok {
+		promMetrics.SetQuotaUsed(run.Platform, r.QuotaUsed())
+	}
+}
+
+// startRunSpan starts the root span of one platform's run, so each run is
+// its own trace. The run ID is added once the run is recorded.
+func startRunSpan(ctx context.Context, name string, platform scrapers.Platform, show string) (context.Context, trace.Span) {
+	return scrapers.Tracer.Start(ctx, name, trace.WithNewRoot(), trace.WithAttributes(
+		scrapers.AttrPlatform.String(string(platform)),
+		scrapers.AttrShow.String(show),
+	))
+}
+
+// endRunSpan records the outcome of run on its span and ends it
+func endRunSpan(span trace.Span, run *scrapers.ScraperRun) {
+	span.SetAttributes(
+		attribute.String("podcast.run_status", string(run.Status)),
+		attribute.Int("podcast.episodes_processed", run.EpisodesProcessed),
+		attribute.Int("podcast.metrics_collected", run.MetricsCollected),
+	)
+	if run.Status == scrapers.RunStatusFailed && run.ErrorMessage != nil {
+		span.SetStatus(codes.Error, logging.Redact(*run.ErrorMessage))
+	}
+	span.End()
+}
@@ -0,0 +1,25 @@
+// Package database holds the goose SQL migrations for the analytics
+// database, embedded so binaries can apply them without the source tree.
+package database
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var embedMigrations embed.FS
+
+// Dir is the migrations directory relative to the repository root, where
+// new migrations are created
+const Dir = "database/migrations"
+
+// Migrations returns the embedded migration files
+func Migrations() fs.FS {
+	sub, err := fs.Sub(embedMigrations, "migrations")
+	if err != nil {
+		// Only fails for an invalid path, which is fixed at compile time
+		panic(err)
+	}
+	return sub
+}
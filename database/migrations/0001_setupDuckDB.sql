@@ -1,4 +1,4 @@
---+ goose Up
-CREATE EXTENSION pg_duckdb;
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_duckdb;
 
---+ goose Down
+-- +goose Down
@@ -16,8 +16,8 @@ CREATE TABLE IF NOT EXISTS raw.podcasts (
     UNIQUE(platform, platform_id)
 );
 
-CREATE INDEX idx_podcasts_platform ON raw.podcasts(platform);
-CREATE INDEX idx_podcasts_show_name ON raw.podcasts(show_name);
+CREATE INDEX IF NOT EXISTS idx_podcasts_platform ON raw.podcasts(platform);
+CREATE INDEX IF NOT EXISTS idx_podcasts_show_name ON raw.podcasts(show_name);
 
 -- Episodes table
 CREATE TABLE IF NOT EXISTS raw.podcast_episodes (
@@ -35,9 +35,9 @@ CREATE TABLE IF NOT EXISTS raw.podcast_episodes (
     UNIQUE(podcast_id, platform_episode_id)
 );
 
-CREATE INDEX idx_episodes_podcast_id ON raw.podcast_episodes(podcast_id);
-CREATE INDEX idx_episodes_publish_date ON raw.podcast_episodes(publish_date);
-CREATE INDEX idx_episodes_platform_episode_id ON raw.podcast_episodes(platform_episode_id);
+CREATE INDEX IF NOT EXISTS idx_episodes_podcast_id ON raw.podcast_episodes(podcast_id);
+CREATE INDEX IF NOT EXISTS idx_episodes_publish_date ON raw.podcast_episodes(publish_date);
+CREATE INDEX IF NOT EXISTS idx_episodes_platform_episode_id ON raw.podcast_episodes(platform_episode_id);
 
 -- Daily episode metrics (aggregated)
 CREATE TABLE IF NOT EXISTS raw.podcast_episode_metrics (
@@ -88,9 +88,9 @@ CREATE TABLE IF NOT EXISTS raw.podcast_episode_metrics (
     UNIQUE(episode_id, metric_date)
 );
 
-CREATE INDEX idx_episode_metrics_episode_id ON raw.podcast_episode_metrics(episode_id);
-CREATE INDEX idx_episode_metrics_date ON raw.podcast_episode_metrics(metric_date);
-CREATE INDEX idx_episode_metrics_episode_date ON raw.podcast_episode_metrics(episode_id, metric_date);
+CREATE INDEX IF NOT EXISTS idx_episode_metrics_episode_id ON raw.podcast_episode_metrics(episode_id);
+CREATE INDEX IF NOT EXISTS idx_episode_metrics_date ON raw.podcast_episode_metrics(metric_date);
+CREATE INDEX IF NOT EXISTS idx_episode_metrics_episode_date ON raw.podcast_episode_metrics(episode_id, metric_date);
 
 -- Show-level daily metrics (aggregated across all episodes)
 CREATE TABLE IF NOT EXISTS raw.podcast_show_metrics (
@@ -132,9 +132,9 @@ CREATE TABLE IF NOT EXISTS raw.podcast_show_metrics (
     UNIQUE(podcast_id, metric_date)
 );
 
-CREATE INDEX idx_show_metrics_podcast_id ON raw.podcast_show_metrics(podcast_id);
-CREATE INDEX idx_show_metrics_date ON raw.podcast_show_metrics(metric_date);
-CREATE INDEX idx_show_metrics_podcast_date ON raw.podcast_show_metrics(podcast_id, metric_date);
+CREATE INDEX IF NOT EXISTS idx_show_metrics_podcast_id ON raw.podcast_show_metrics(podcast_id);
+CREATE INDEX IF NOT EXISTS idx_show_metrics_date ON raw.podcast_show_metrics(metric_date);
+CREATE INDEX IF NOT EXISTS idx_show_metrics_podcast_date ON raw.podcast_show_metrics(podcast_id, metric_date);
 
 -- Comments table (for platforms that provide detailed comment data like YouTube)
 CREATE TABLE IF NOT EXISTS raw.podcast_comments (
@@ -153,9 +153,9 @@ CREATE TABLE IF NOT EXISTS raw.podcast_comments (
     UNIQUE(episode_id, platform_comment_id)
 );
 
-CREATE INDEX idx_comments_episode_id ON raw.podcast_comments(episode_id);
-CREATE INDEX idx_comments_published_at ON raw.podcast_comments(published_at);
-CREATE INDEX idx_comments_parent_id ON raw.podcast_comments(parent_comment_id);
+CREATE INDEX IF NOT EXISTS idx_comments_episode_id ON raw.podcast_comments(episode_id);
+CREATE INDEX IF NOT EXISTS idx_comments_published_at ON raw.podcast_comments(published_at);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON raw.podcast_comments(parent_comment_id);
 
 -- Scraper runs tracking (for monitoring and debugging)
 CREATE TABLE IF NOT EXISTS raw.podcast_scraper_runs (
@@ -170,12 +170,12 @@ CREATE TABLE IF NOT EXISTS raw.podcast_scraper_runs (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
-CREATE INDEX idx_scraper_runs_platform ON raw.podcast_scraper_runs(platform);
-CREATE INDEX idx_scraper_runs_started_at ON raw.podcast_scraper_runs(run_started_at);
-CREATE INDEX idx_scraper_runs_status ON raw.podcast_scraper_runs(status);
+CREATE INDEX IF NOT EXISTS idx_scraper_runs_platform ON raw.podcast_scraper_runs(platform);
+CREATE INDEX IF NOT EXISTS idx_scraper_runs_started_at ON raw.podcast_scraper_runs(run_started_at);
+CREATE INDEX IF NOT EXISTS idx_scraper_runs_status ON raw.podcast_scraper_runs(status);
 
 -- Staging views for data quality and transformation
-CREATE VIEW staging.podcast_metrics_latest AS
+CREATE OR REPLACE VIEW staging.podcast_metrics_latest AS
 SELECT
     p.show_name,
     p.platform,
@@ -197,7 +197,7 @@ JOIN raw.podcasts p ON pe.podcast_id = p.id
 WHERE pem.metric_date >= CURRENT_DATE - INTERVAL '30 days';
 
 -- Analytics view for cross-platform comparison
-CREATE VIEW analytics.podcast_performance_summary AS
+CREATE OR REPLACE VIEW analytics.podcast_performance_summary AS
 SELECT
     p.show_name,
     p.platform,
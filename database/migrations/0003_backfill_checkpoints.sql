@@ -0,0 +1,23 @@
+-- +goose Up
+-- Track backfill progress so an interrupted backfill resumes where it stopped
+
+CREATE TABLE IF NOT EXISTS raw.podcast_backfill_checkpoints (
+    id BIGSERIAL PRIMARY KEY,
+    platform VARCHAR(50) NOT NULL,
+    podcast_id BIGINT NOT NULL REFERENCES raw.podcasts(id) ON DELETE CASCADE,
+    episode_id BIGINT NOT NULL DEFAULT 0, -- 0 for show-level metrics
+    window_start DATE NOT NULL,
+    window_end DATE NOT NULL,
+    status VARCHAR(20) NOT NULL, -- 'completed', 'failed'
+    metrics_collected INTEGER DEFAULT 0,
+    error_message TEXT,
+    run_id BIGINT REFERENCES raw.podcast_scraper_runs(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(platform, podcast_id, episode_id, window_start, window_end)
+);
+
+CREATE INDEX idx_backfill_checkpoints_platform_podcast ON raw.podcast_backfill_checkpoints(platform, podcast_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS raw.podcast_backfill_checkpoints;
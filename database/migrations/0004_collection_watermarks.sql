@@ -0,0 +1,18 @@
+-- +goose Up
+-- Per-episode high-water marks for incremental collection
+
+CREATE TABLE IF NOT EXISTS raw.podcast_collection_watermarks (
+    id BIGSERIAL PRIMARY KEY,
+    platform VARCHAR(50) NOT NULL,
+    podcast_id BIGINT NOT NULL REFERENCES raw.podcasts(id) ON DELETE CASCADE,
+    episode_id BIGINT NOT NULL DEFAULT 0, -- 0 for show-level metrics
+    final_metric_date DATE NOT NULL, -- Latest metric_date the platform will no longer revise
+    last_activity_date DATE, -- Latest metric_date with any plays, views or listens
+    last_polled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(platform, podcast_id, episode_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS raw.podcast_collection_watermarks;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Per-item error ledger for scraper runs
+
+CREATE TABLE IF NOT EXISTS raw.podcast_scraper_errors (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT NOT NULL REFERENCES raw.podcast_scraper_runs(id) ON DELETE CASCADE,
+    platform VARCHAR(50) NOT NULL,
+    episode_id BIGINT, -- NULL for show-level errors and episodes never stored
+    episode_title VARCHAR(500),
+    stage VARCHAR(50) NOT NULL, -- 'upsert_episode', 'fetch_metrics', 'fetch_comments', 'store_metric', ...
+    error_class VARCHAR(50) NOT NULL, -- 'auth', 'rate_limited', 'server_error', 'storage', ...
+    http_status INTEGER,
+    message TEXT NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_scraper_errors_run_id ON raw.podcast_scraper_errors(run_id);
+CREATE INDEX idx_scraper_errors_class ON raw.podcast_scraper_errors(error_class);
+
+-- +goose Down
+DROP TABLE IF EXISTS raw.podcast_scraper_errors;
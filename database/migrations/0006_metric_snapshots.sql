@@ -0,0 +1,173 @@
+-- +goose Up
+-- Append-only history of episode metric values (SCD type 2). A trigger on
+-- raw.podcast_episode_metrics closes the open snapshot and opens a new one
+-- whenever a platform reports different numbers for the same day.
+
+ALTER TABLE raw.podcast_episode_metrics
+    ADD COLUMN IF NOT EXISTS run_id BIGINT; -- Scraper run that last wrote the row
+
+CREATE TABLE IF NOT EXISTS raw.podcast_episode_metric_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    episode_id BIGINT NOT NULL REFERENCES raw.podcast_episodes(id) ON DELETE CASCADE,
+    metric_date DATE NOT NULL,
+
+    plays BIGINT,
+    listeners BIGINT,
+    engaged_listeners BIGINT,
+    views BIGINT,
+    likes BIGINT,
+    dislikes BIGINT,
+    comments_count BIGINT,
+    shares BIGINT,
+    watch_time_minutes BIGINT,
+    average_view_duration_seconds INTEGER,
+    subscribers_gained INTEGER,
+    subscribers_lost INTEGER,
+    downloads BIGINT,
+    streams BIGINT,
+    completion_rate DECIMAL(5,2),
+    average_listen_time_seconds INTEGER,
+    followers_total BIGINT,
+    followers_gained INTEGER,
+    followers_lost INTEGER,
+
+    valid_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    valid_to TIMESTAMP WITH TIME ZONE, -- NULL for the current value
+    run_id BIGINT REFERENCES raw.podcast_scraper_runs(id) ON DELETE SET NULL -- Run that observed the value
+);
+
+CREATE UNIQUE INDEX idx_metric_snapshots_current
+    ON raw.podcast_episode_metric_snapshots(episode_id, metric_date)
+    WHERE valid_to IS NULL;
+CREATE INDEX idx_metric_snapshots_episode_date
+    ON raw.podcast_episode_metric_snapshots(episode_id, metric_date, valid_from);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION raw.snapshot_episode_metrics() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (
+        OLD.plays, OLD.listeners, OLD.engaged_listeners, OLD.views, OLD.likes,
+        OLD.dislikes, OLD.comments_count, OLD.shares, OLD.watch_time_minutes,
+        OLD.average_view_duration_seconds, OLD.subscribers_gained, OLD.subscribers_lost,
+        OLD.downloads, OLD.streams, OLD.completion_rate, OLD.average_listen_time_seconds,
+        OLD.followers_total, OLD.followers_gained, OLD.followers_lost
+    ) IS NOT DISTINCT FROM (
+        NEW.plays, NEW.listeners, NEW.engaged_listeners, NEW.views, NEW.likes,
+        NEW.dislikes, NEW.comments_count, NEW.shares, NEW.watch_time_minutes,
+        NEW.average_view_duration_seconds, NEW.subscribers_gained, NEW.subscribers_lost,
+        NEW.downloads, NEW.streams, NEW.completion_rate, NEW.average_listen_time_seconds,
+        NEW.followers_total, NEW.followers_gained, NEW.followers_lost
+    ) THEN
+        RETURN NEW;
+    END IF;
+
+    UPDATE raw.podcast_episode_metric_snapshots
+    SET valid_to = NOW()
+    WHERE episode_id = NEW.episode_id
+      AND metric_date = NEW.metric_date
+      AND valid_to IS NULL;
+
+    INSERT INTO raw.podcast_episode_metric_snapshots (
+        episode_id, metric_date, plays, listeners, engaged_listeners, views, likes,
+        dislikes, comments_count, shares, watch_time_minutes,
+        average_view_duration_seconds, subscribers_gained, subscribers_lost,
+        downloads, streams, completion_rate, average_listen_time_seconds,
+        followers_total, followers_gained, followers_lost, valid_from, run_id
+    )
+    VALUES (
+        NEW.episode_id, NEW.metric_date, NEW.plays, NEW.listeners, NEW.engaged_listeners, NEW.views, NEW.likes,
+        NEW.dislikes, NEW.comments_count, NEW.shares, NEW.watch_time_minutes,
+        NEW.average_view_duration_seconds, NEW.subscribers_gained, NEW.subscribers_lost,
+        NEW.downloads, NEW.streams, NEW.completion_rate, NEW.average_listen_time_seconds,
+        NEW.followers_total, NEW.followers_gained, NEW.followers_lost, NOW(), NEW.run_id
+    );
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_episode_metrics_snapshot
+    AFTER INSERT OR UPDATE ON raw.podcast_episode_metrics
+    FOR EACH ROW EXECUTE FUNCTION raw.snapshot_episode_metrics();
+
+-- Seed the history with the values already loaded
+INSERT INTO raw.podcast_episode_metric_snapshots (
+    episode_id, metric_date, plays, listeners, engaged_listeners, views, likes,
+    dislikes, comments_count, shares, watch_time_minutes,
+    average_view_duration_seconds, subscribers_gained, subscribers_lost,
+    downloads, streams, completion_rate, average_listen_time_seconds,
+    followers_total, followers_gained, followers_lost, valid_from
+)
+SELECT
+    episode_id, metric_date, plays, listeners, engaged_listeners, views, likes,
+    dislikes, comments_count, shares, watch_time_minutes,
+    average_view_duration_seconds, subscribers_gained, subscribers_lost,
+    downloads, streams, completion_rate, average_listen_time_seconds,
+    followers_total, followers_gained, followers_lost, COALESCE(updated_at, created_at, NOW())
+FROM raw.podcast_episode_metrics;
+
+-- Every revision of a day's numbers, with the change against the value it replaced
+CREATE VIEW analytics.podcast_metric_restatements AS
+SELECT
+    p.platform,
+    p.show_name,
+    pe.episode_title,
+    cur.episode_id,
+    cur.metric_date,
+    cur.valid_from AS restated_at,
+    cur.run_id,
+    (cur.valid_from::date - cur.metric_date) AS days_after_metric_date,
+    prev.plays AS previous_plays,
+    cur.plays,
+    COALESCE(cur.plays, 0) - COALESCE(prev.plays, 0) AS plays_delta,
+    prev.views AS previous_views,
+    cur.views,
+    COALESCE(cur.views, 0) - COALESCE(prev.views, 0) AS views_delta,
+    prev.downloads AS previous_downloads,
+    cur.downloads,
+    COALESCE(cur.downloads, 0) - COALESCE(prev.downloads, 0) AS downloads_delta,
+    prev.listeners AS previous_listeners,
+    cur.listeners,
+    COALESCE(cur.listeners, 0) - COALESCE(prev.listeners, 0) AS listeners_delta
+FROM raw.podcast_episode_metric_snapshots cur
+JOIN raw.podcast_episode_metric_snapshots prev
+    ON prev.episode_id = cur.episode_id
+   AND prev.metric_date = cur.metric_date
+   AND prev.valid_to = cur.valid_from
+JOIN raw.podcast_episodes pe ON cur.episode_id = pe.id
+JOIN raw.podcasts p ON pe.podcast_id = p.id;
+
+-- How much and how late each platform revises its numbers
+CREATE VIEW analytics.podcast_restatement_summary AS
+WITH days AS (
+    SELECT
+        p.platform,
+        COUNT(DISTINCT (s.episode_id, s.metric_date)) AS metric_days
+    FROM raw.podcast_episode_metric_snapshots s
+    JOIN raw.podcast_episodes pe ON s.episode_id = pe.id
+    JOIN raw.podcasts p ON pe.podcast_id = p.id
+    GROUP BY p.platform
+)
+SELECT
+    r.platform,
+    d.metric_days,
+    COUNT(DISTINCT (r.episode_id, r.metric_date)) AS restated_days,
+    ROUND(100.0 * COUNT(DISTINCT (r.episode_id, r.metric_date)) / NULLIF(d.metric_days, 0), 2) AS restated_pct,
+    COUNT(*) AS restatements,
+    ROUND(AVG(r.days_after_metric_date), 1) AS avg_days_after,
+    MAX(r.days_after_metric_date) AS max_days_after,
+    SUM(ABS(r.plays_delta) + ABS(r.views_delta) + ABS(r.downloads_delta)) AS total_abs_delta,
+    ROUND(100.0 * SUM(ABS(r.plays_delta) + ABS(r.views_delta) + ABS(r.downloads_delta))
+        / NULLIF(SUM(COALESCE(r.previous_plays, 0) + COALESCE(r.previous_views, 0) + COALESCE(r.previous_downloads, 0)), 0), 2) AS avg_abs_change_pct
+FROM analytics.podcast_metric_restatements r
+JOIN days d ON d.platform = r.platform
+GROUP BY r.platform, d.metric_days;
+
+-- +goose Down
+DROP VIEW IF EXISTS analytics.podcast_restatement_summary;
+DROP VIEW IF EXISTS analytics.podcast_metric_restatements;
+DROP TRIGGER IF EXISTS trg_episode_metrics_snapshot ON raw.podcast_episode_metrics;
+DROP FUNCTION IF EXISTS raw.snapshot_episode_metrics();
+DROP TABLE IF EXISTS raw.podcast_episode_metric_snapshots;
+ALTER TABLE raw.podcast_episode_metrics DROP COLUMN IF EXISTS run_id;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Index of platform API responses archived to the blob store. Metric rows
+-- keep only the archive key in raw_data; reparse finds responses here.
+
+CREATE TABLE IF NOT EXISTS raw.podcast_response_archive (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT NOT NULL REFERENCES raw.podcast_scraper_runs(id) ON DELETE CASCADE,
+    platform VARCHAR(50) NOT NULL,
+    endpoint VARCHAR(50) NOT NULL, -- 'podcast_info', 'episodes', 'episode_metrics', 'show_metrics', 'comments'
+    podcast_id BIGINT REFERENCES raw.podcasts(id) ON DELETE CASCADE,
+    episode_id BIGINT REFERENCES raw.podcast_episodes(id) ON DELETE CASCADE, -- NULL when the episode was new to the run
+    platform_episode_id VARCHAR(255),
+    start_date DATE, -- Requested metrics window, NULL for endpoints without one
+    end_date DATE,
+    archive_key TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(run_id, archive_key)
+);
+
+CREATE INDEX idx_response_archive_platform_endpoint ON raw.podcast_response_archive(platform, endpoint, archived_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS raw.podcast_response_archive;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Differences between platform responses and the shapes the scrapers
+-- declare for them, recorded once per run for each field and kind
+
+CREATE TABLE IF NOT EXISTS raw.podcast_schema_drift (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT NOT NULL REFERENCES raw.podcast_scraper_runs(id) ON DELETE CASCADE,
+    platform VARCHAR(50) NOT NULL,
+    endpoint VARCHAR(50) NOT NULL, -- 'podcast_info', 'episodes', 'episode_metrics', 'show_metrics', 'comments'
+    field_path TEXT NOT NULL, -- e.g. 'items[].snippet.title'
+    drift_kind VARCHAR(20) NOT NULL, -- 'new', 'missing', 'retyped'
+    expected_type VARCHAR(20), -- NULL for new fields
+    observed_type VARCHAR(20), -- NULL for missing fields
+    sample TEXT, -- JSON of the field, or of its parent when missing
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(run_id, platform, endpoint, field_path, drift_kind)
+);
+
+CREATE INDEX idx_schema_drift_platform_detected ON raw.podcast_schema_drift(platform, detected_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS raw.podcast_schema_drift;
@@ -0,0 +1,43 @@
+-- +goose Up
+-- Monthly aggregates of daily episode metrics rolled up by `podcast-scraper
+-- prune`. Additive metrics are summed, rates are averaged over the days
+-- rolled up, and followers_total is the last day's value.
+
+CREATE TABLE IF NOT EXISTS raw.podcast_episode_metrics_monthly (
+    id BIGSERIAL PRIMARY KEY,
+    episode_id BIGINT NOT NULL REFERENCES raw.podcast_episodes(id) ON DELETE CASCADE,
+    month DATE NOT NULL, -- First day of the month
+    days INTEGER NOT NULL, -- Daily rows rolled up
+
+    plays BIGINT DEFAULT 0,
+    listeners BIGINT DEFAULT 0, -- Sum of daily listeners, not unique listeners
+    engaged_listeners BIGINT DEFAULT 0,
+    views BIGINT DEFAULT 0,
+    likes BIGINT DEFAULT 0,
+    dislikes BIGINT DEFAULT 0,
+    comments_count BIGINT DEFAULT 0,
+    shares BIGINT DEFAULT 0,
+    watch_time_minutes BIGINT DEFAULT 0,
+    average_view_duration_seconds INTEGER,
+    subscribers_gained INTEGER DEFAULT 0,
+    subscribers_lost INTEGER DEFAULT 0,
+    downloads BIGINT DEFAULT 0,
+    streams BIGINT DEFAULT 0,
+    completion_rate DECIMAL(5,2),
+    average_listen_time_seconds INTEGER,
+    followers_total BIGINT,
+    followers_gained INTEGER DEFAULT 0,
+    followers_lost INTEGER DEFAULT 0,
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(episode_id, month)
+);
+
+CREATE INDEX idx_episode_metrics_monthly_month ON raw.podcast_episode_metrics_monthly(month);
+
+CREATE INDEX IF NOT EXISTS idx_scraper_errors_occurred_at ON raw.podcast_scraper_errors(occurred_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS raw.idx_scraper_errors_occurred_at;
+DROP TABLE IF EXISTS raw.podcast_episode_metrics_monthly;
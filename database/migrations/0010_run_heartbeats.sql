@@ -0,0 +1,11 @@
+-- +goose Up
+-- Running collections update heartbeat_at with their progress so runs whose
+-- process died can be told apart from slow ones and marked 'abandoned'
+
+ALTER TABLE raw.podcast_scraper_runs ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_scraper_runs_running ON raw.podcast_scraper_runs(run_started_at) WHERE status = 'running';
+
+-- +goose Down
+DROP INDEX IF EXISTS raw.idx_scraper_runs_running;
+ALTER TABLE raw.podcast_scraper_runs DROP COLUMN IF EXISTS heartbeat_at;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- When each alert was last sent, so repeats are suppressed for a cooldown
+-- across CronJob runs. Rows are deleted once the alerted problem clears.
+
+CREATE TABLE IF NOT EXISTS raw.podcast_alert_state (
+    alert_key TEXT PRIMARY KEY, -- '<trigger>/<platform>/<show>'
+    last_sent_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS raw.podcast_alert_state;
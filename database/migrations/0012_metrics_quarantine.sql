@@ -0,0 +1,23 @@
+-- +goose Up
+-- Metric rows held back from the metric tables because they broke data
+-- quality rules, kept as collected with the reasons they were held back
+
+CREATE TABLE IF NOT EXISTS raw.podcast_metrics_quarantine (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT REFERENCES raw.podcast_scraper_runs(id) ON DELETE SET NULL,
+    platform VARCHAR(50) NOT NULL,
+    metric_kind VARCHAR(20) NOT NULL, -- 'episode', 'show'
+    podcast_id BIGINT,
+    episode_id BIGINT, -- NULL for show metrics and episodes never stored
+    platform_episode_id VARCHAR(255),
+    metric_date DATE, -- NULL when the row had no date
+    reasons TEXT[] NOT NULL, -- e.g. 'missing_date: metric date is missing'
+    row_data JSONB NOT NULL,
+    quarantined_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_metrics_quarantine_platform_at ON raw.podcast_metrics_quarantine(platform, quarantined_at);
+CREATE INDEX idx_metrics_quarantine_run_id ON raw.podcast_metrics_quarantine(run_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS raw.podcast_metrics_quarantine;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- Daily episode and show metrics that stood out from their rolling baseline,
+-- whether from a platform returning bad data or a real event. Detection
+-- replaces the findings for the dates it analyzes, so a restated day that no
+-- longer stands out drops out of the table.
+
+CREATE TABLE IF NOT EXISTS raw.podcast_metric_anomalies (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT REFERENCES raw.podcast_scraper_runs(id) ON DELETE SET NULL,
+    platform VARCHAR(50) NOT NULL,
+    podcast_id BIGINT NOT NULL REFERENCES raw.podcasts(id) ON DELETE CASCADE,
+    episode_id BIGINT REFERENCES raw.podcast_episodes(id) ON DELETE CASCADE, -- NULL for show metrics
+    metric VARCHAR(50) NOT NULL, -- 'plays', 'views', 'total_plays', 'total_views', 'followers_gained', 'subscribers_gained'
+    metric_date DATE NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    baseline DOUBLE PRECISION NOT NULL, -- median of the baseline window
+    mad DOUBLE PRECISION NOT NULL, -- median absolute deviation around it
+    score DOUBLE PRECISION NOT NULL, -- signed distance from the baseline in scaled MADs
+    direction VARCHAR(10) NOT NULL, -- 'spike', 'drop'
+    method VARCHAR(20) NOT NULL, -- 'rolling', 'weekday'
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_metric_anomalies_podcast_date ON raw.podcast_metric_anomalies(podcast_id, metric_date);
+CREATE INDEX idx_metric_anomalies_platform_date ON raw.podcast_metric_anomalies(platform, metric_date);
+
+-- +goose Down
+DROP TABLE IF EXISTS raw.podcast_metric_anomalies;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- API quota units each run consumed per UTC day, for platforms with a daily
+-- quota such as YouTube. Summed by day, it is the usage every process checks
+-- its reserve against, since a process only sees the calls it made itself.
+
+CREATE TABLE IF NOT EXISTS raw.podcast_api_quota_usage (
+    run_id BIGINT NOT NULL REFERENCES raw.podcast_scraper_runs(id) ON DELETE CASCADE,
+    usage_date DATE NOT NULL,
+    platform VARCHAR(50) NOT NULL,
+    units BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (run_id, usage_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_quota_usage_platform_date ON raw.podcast_api_quota_usage(platform, usage_date);
+
+-- +goose Down
+DROP TABLE IF EXISTS raw.podcast_api_quota_usage;
@@ -0,0 +1,41 @@
+-- +goose Up
+-- Retention keeps what reparse and the monthly rollup depend on: the archive
+-- index outlives pruned runs, and daily episode metrics for a month that was
+-- already rolled up are dropped on write instead of being rolled up a second
+-- time. The monthly row stays authoritative for that month.
+
+ALTER TABLE raw.podcast_response_archive
+    ALTER COLUMN run_id DROP NOT NULL,
+    DROP CONSTRAINT IF EXISTS podcast_response_archive_run_id_fkey,
+    ADD CONSTRAINT podcast_response_archive_run_id_fkey
+        FOREIGN KEY (run_id) REFERENCES raw.podcast_scraper_runs(id) ON DELETE SET NULL;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION raw.skip_rolled_up_episode_metrics() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM raw.podcast_episode_metrics_monthly
+        WHERE episode_id = NEW.episode_id
+          AND month = date_trunc('month', NEW.metric_date)::date
+    ) THEN
+        RETURN NULL;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_episode_metrics_skip_rolled_up
+    BEFORE INSERT ON raw.podcast_episode_metrics
+    FOR EACH ROW EXECUTE FUNCTION raw.skip_rolled_up_episode_metrics();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS trg_episode_metrics_skip_rolled_up ON raw.podcast_episode_metrics;
+DROP FUNCTION IF EXISTS raw.skip_rolled_up_episode_metrics();
+DELETE FROM raw.podcast_response_archive WHERE run_id IS NULL;
+ALTER TABLE raw.podcast_response_archive
+    DROP CONSTRAINT IF EXISTS podcast_response_archive_run_id_fkey,
+    ADD CONSTRAINT podcast_response_archive_run_id_fkey
+        FOREIGN KEY (run_id) REFERENCES raw.podcast_scraper_runs(id) ON DELETE CASCADE,
+    ALTER COLUMN run_id SET NOT NULL;
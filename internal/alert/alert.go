@@ -0,0 +1,276 @@
+// Package alert notifies webhooks, chat channels and email about runs that
+// failed, lost their credentials or collected suspicious data. Repeats of an
+// alert are suppressed for a cooldown, remembered across processes when the
+// State is backed by the database.
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// Trigger is the condition an alert reports
+type Trigger string
+
+const (
+	TriggerRunFailed   Trigger = "run_failed"
+	TriggerRunPartial  Trigger = "run_partial"
+	TriggerAuthExpired Trigger = "auth_expired"
+	TriggerZeroMetrics Trigger = "zero_metrics"
+	TriggerAnomaly     Trigger = "anomaly"
+)
+
+// AllTriggers lists every trigger
+var AllTriggers = []Trigger{
+	TriggerRunFailed,
+	TriggerRunPartial,
+	TriggerAuthExpired,
+	TriggerZeroMetrics,
+	TriggerAnomaly,
+}
+
+// ParseTrigger validates a trigger name
+func ParseTrigger(name string) (Trigger, error) {
+	for _, t := range AllTriggers {
+		if string(t) == name {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown alert trigger %q", name)
+}
+
+// Alert describes one problem with a show's collection on a platform
+type Alert struct {
+	Trigger  Trigger           `json:"trigger"`
+	Platform scrapers.Platform `json:"platform"`
+	Show     string            `json:"show"`
+	RunID    int64             `json:"run_id,omitempty"`
+	Status   string            `json:"status,omitempty"`
+	Summary  string            `json:"summary"`
+	Detail   string            `json:"detail,omitempty"`
+	Time     time.Time         `json:"time"`
+}
+
+// Key identifies repeats of the same alert
+func (a *Alert) Key() string {
+	return fmt.Sprintf("%s/%s/%s", a.Trigger, a.Platform, a.Show)
+}
+
+// Title is a one-line description for subjects and chat messages
+func (a *Alert) Title() string {
+	return fmt.Sprintf("[podcast-scraper] %s %s: %s", a.Show, a.Platform, a.Summary)
+}
+
+// Text is the full plain-text description of the alert
+func (a *Alert) Text() string {
+	var b strings.Builder
+	b.WriteString(a.Title())
+	if a.Detail != "" {
+		b.WriteString("\n" + a.Detail)
+	}
+	if a.RunID != 0 {
+		fmt.Fprintf(&b, "\nSee 'podcast-scraper runs show %d'", a.RunID)
+	}
+	return b.String()
+}
+
+// Sink delivers alerts to one destination
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, a *Alert) error
+}
+
+// State remembers when each alert was last sent
+type State interface {
+	// LastAlerted returns when the alert with key was last sent, or the
+	// zero time if it has not been sent since it was last cleared
+	LastAlerted(ctx context.Context, key string) (time.Time, error)
+	MarkAlerted(ctx context.Context, key string, at time.Time) error
+	ClearAlerted(ctx context.Context, keys []string) error
+}
+
+// MemoryState keeps alert state for the life of the process
+type MemoryState struct {
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+// NewMemoryState creates an empty in-memory alert state
+func NewMemoryState() *MemoryState {
+	return &MemoryState{sent: make(map[string]time.Time)}
+}
+
+func (s *MemoryState) LastAlerted(ctx context.Context, key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sent[key], nil
+}
+
+func (s *MemoryState) MarkAlerted(ctx context.Context, key string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent[key] = at
+	return nil
+}
+
+func (s *MemoryState) ClearAlerted(ctx context.Context, keys []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.sent, key)
+	}
+	return nil
+}
+
+// Config selects the sinks alerts go to and when they are sent
+type Config struct {
+	// WebhookURL receives each alert as a JSON POST
+	WebhookURL string
+
+	// ChatWebhookURL is a Slack or Discord incoming webhook
+	ChatWebhookURL string
+
+	// SMTP settings for email alerts, sent when SMTPAddr and SMTPTo are set
+	SMTPAddr     string
+	SMTPFrom     string
+	SMTPTo       []string
+	SMTPUsername string
+	SMTPPassword string
+
+	// Cooldown suppresses repeats of an alert until it has passed, unless
+	// the problem clears in between
+	Cooldown time.Duration
+
+	// Triggers limits which conditions alert; all of them when empty
+	Triggers []Trigger
+}
+
+// sendTimeout bounds how long one sink may take to deliver an alert
+const sendTimeout = 15 * time.Second
+
+// Notifier sends alerts to every configured sink. A nil Notifier sends nothing.
+type Notifier struct {
+	sinks    []Sink
+	state    State
+	cooldown time.Duration
+	triggers map[Trigger]bool
+}
+
+// New returns a notifier for cfg that remembers sent alerts in state, or nil
+// when no sink is configured
+func New(cfg Config, state State) *Notifier {
+	client := &http.Client{Timeout: sendTimeout}
+
+	var sinks []Sink
+	if cfg.WebhookURL != "" {
+		sinks = append(sinks, &Webhook{URL: cfg.WebhookURL, Client: client})
+	}
+	if cfg.ChatWebhookURL != "" {
+		sinks = append(sinks, &Chat{URL: cfg.ChatWebhookURL, Client: client})
+	}
+	if cfg.SMTPAddr != "" && len(cfg.SMTPTo) > 0 {
+		sinks = append(sinks, &SMTP{
+			Addr:     cfg.SMTPAddr,
+			From:     cfg.SMTPFrom,
+			To:       cfg.SMTPTo,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+		})
+	}
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	return NewWithSinks(sinks, state, cfg.Cooldown, cfg.Triggers)
+}
+
+// NewWithSinks returns a notifier sending to sinks. Only the given triggers
+// alert, or all of them when none are given.
+func NewWithSinks(sinks []Sink, state State, cooldown time.Duration, triggers []Trigger) *Notifier {
+	if state == nil {
+		state = NewMemoryState()
+	}
+	if len(triggers) == 0 {
+		triggers = AllTriggers
+	}
+
+	enabled := make(map[Trigger]bool, len(triggers))
+	for _, t := range triggers {
+		enabled[t] = true
+	}
+
+	return &Notifier{sinks: sinks, state: state, cooldown: cooldown, triggers: enabled}
+}
+
+// Notify sends a to every sink unless its trigger is disabled or the same
+// alert was sent within the cooldown. Credentials in its text are redacted.
+// Errors from individual sinks are joined.
+func (n *Notifier) Notify(ctx context.Context, a *Alert) error {
+	if n == nil || !n.triggers[a.Trigger] {
+		return nil
+	}
+
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+	a.Summary = logging.Redact(a.Summary)
+	a.Detail = logging.Redact(a.Detail)
+
+	last, err := n.state.LastAlerted(ctx, a.Key())
+	if err != nil {
+		return fmt.Errorf("failed to read alert state: %w", err)
+	}
+	if !last.IsZero() && a.Time.Sub(last) < n.cooldown {
+		slog.DebugContext(ctx, "Suppressed repeated alert", "alert", a.Key(), "last_sent", last.Format(time.RFC3339))
+		return nil
+	}
+
+	var errs []error
+	sent := false
+	for _, sink := range n.sinks {
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err := sink.Send(sendCtx, a)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+			continue
+		}
+		sent = true
+	}
+
+	// A repeat is only suppressed once some sink delivered the alert
+	if sent {
+		slog.InfoContext(ctx, "Sent alert", "alert", a.Key(), "summary", a.Summary)
+		if err := n.state.MarkAlerted(ctx, a.Key(), a.Time); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save alert state: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Resolve clears the given triggers for a show on a platform, so the next
+// occurrence alerts straight away instead of waiting out the cooldown
+func (n *Notifier) Resolve(ctx context.Context, platform scrapers.Platform, show string, triggers ...Trigger) error {
+	if n == nil || len(triggers) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(triggers))
+	for i, t := range triggers {
+		keys[i] = (&Alert{Trigger: t, Platform: platform, Show: show}).Key()
+	}
+	if err := n.state.ClearAlerted(ctx, keys); err != nil {
+		return fmt.Errorf("failed to clear alert state: %w", err)
+	}
+	return nil
+}
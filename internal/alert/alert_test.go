@@ -0,0 +1,138 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// recordingSink keeps the alerts it is sent, failing with err when set
+type recordingSink struct {
+	sent []*Alert
+	err  error
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Send(ctx context.Context, a *Alert) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, a)
+	return nil
+}
+
+func TestNotifierCooldownAndResolve(t *testing.T) {
+	ctx := context.Background()
+	sink := &recordingSink{}
+	n := NewWithSinks([]Sink{sink}, nil, time.Hour, nil)
+
+	first := testAlert()
+	if err := n.Notify(ctx, first); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	// A repeat within the cooldown is suppressed
+	repeat := testAlert()
+	repeat.Time = first.Time.Add(30 * time.Minute)
+	if err := n.Notify(ctx, repeat); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(sink.sent) != 1 {
+		t.Fatalf("alerts sent within the cooldown = %d, want 1", len(sink.sent))
+	}
+
+	// Another show is a different alert
+	other := testAlert()
+	other.Show = "Other Show"
+	if err := n.Notify(ctx, other); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(sink.sent) != 2 {
+		t.Fatalf("alerts sent for another show = %d, want 2", len(sink.sent))
+	}
+
+	// Once resolved, the next occurrence alerts straight away
+	if err := n.Resolve(ctx, scrapers.PlatformSpotify, "Test Show", TriggerRunFailed); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	again := testAlert()
+	again.Time = first.Time.Add(40 * time.Minute)
+	if err := n.Notify(ctx, again); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(sink.sent) != 3 {
+		t.Errorf("alerts sent after resolve = %d, want 3", len(sink.sent))
+	}
+
+	// And after the cooldown
+	late := testAlert()
+	late.Time = again.Time.Add(2 * time.Hour)
+	if err := n.Notify(ctx, late); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(sink.sent) != 4 {
+		t.Errorf("alerts sent after the cooldown = %d, want 4", len(sink.sent))
+	}
+}
+
+func TestNotifierSkipsDisabledTriggers(t *testing.T) {
+	sink := &recordingSink{}
+	n := NewWithSinks([]Sink{sink}, nil, time.Hour, []Trigger{TriggerAuthExpired})
+
+	if err := n.Notify(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(sink.sent) != 0 {
+		t.Errorf("alerts sent for a disabled trigger = %d, want 0", len(sink.sent))
+	}
+}
+
+func TestNotifierRetriesUndelivered(t *testing.T) {
+	ctx := context.Background()
+	failing := &recordingSink{err: errors.New("connection refused")}
+	state := NewMemoryState()
+	n := NewWithSinks([]Sink{failing}, state, time.Hour, nil)
+
+	err := n.Notify(ctx, testAlert())
+	if err == nil || !strings.Contains(err.Error(), "recording: connection refused") {
+		t.Errorf("Notify() error = %v, want the sink's error", err)
+	}
+
+	// Nothing was delivered, so the next occurrence is not suppressed
+	if last, _ := state.LastAlerted(ctx, testAlert().Key()); !last.IsZero() {
+		t.Errorf("LastAlerted() = %v after a failed delivery, want zero", last)
+	}
+}
+
+func TestNotifierRedacts(t *testing.T) {
+	sink := &recordingSink{}
+	n := NewWithSinks([]Sink{sink}, nil, time.Hour, nil)
+
+	a := testAlert()
+	a.Detail = "401 for https://example.com/?access_token=abc"
+	if err := n.Notify(context.Background(), a); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if strings.Contains(sink.sent[0].Detail, "abc") {
+		t.Errorf("Detail = %q, want the token redacted", sink.sent[0].Detail)
+	}
+}
+
+func TestNilNotifier(t *testing.T) {
+	if n := New(Config{}, nil); n != nil {
+		t.Fatalf("New() without sinks = %v, want nil", n)
+	}
+
+	var n *Notifier
+	if err := n.Notify(context.Background(), testAlert()); err != nil {
+		t.Errorf("nil Notify() error = %v", err)
+	}
+	if err := n.Resolve(context.Background(), scrapers.PlatformSpotify, "Test Show", TriggerRunFailed); err != nil {
+		t.Errorf("nil Resolve() error = %v", err)
+	}
+}
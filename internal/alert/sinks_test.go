@@ -0,0 +1,208 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+func testAlert() *Alert {
+	return &Alert{
+		Trigger:  TriggerRunFailed,
+		Platform: scrapers.PlatformSpotify,
+		Show:     "Test Show",
+		RunID:    42,
+		Status:   scrapers.RunStatusFailed,
+		Summary:  "run failed",
+		Detail:   "failed to fetch episodes",
+		Time:     time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC),
+	}
+}
+
+// captureServer records the JSON body of each POST and answers with status
+func captureServer(t *testing.T, status int) (*httptest.Server, *[]map[string]interface{}) {
+	t.Helper()
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request = %s with Content-Type %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode body: %v", err)
+		}
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+		if status >= 300 {
+			w.Write([]byte("no such hook"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &bodies
+}
+
+func TestWebhookPostsAlert(t *testing.T) {
+	server, bodies := captureServer(t, http.StatusOK)
+
+	sink := &Webhook{URL: server.URL, Client: server.Client()}
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if len(*bodies) != 1 {
+		t.Fatalf("posts = %d, want 1", len(*bodies))
+	}
+	body := (*bodies)[0]
+	want := map[string]interface{}{
+		"trigger":  "run_failed",
+		"platform": "spotify",
+		"show":     "Test Show",
+		"run_id":   float64(42),
+		"status":   "failed",
+		"summary":  "run failed",
+		"detail":   "failed to fetch episodes",
+		"time":     "2026-03-01T06:00:00Z",
+	}
+	for key, v := range want {
+		if body[key] != v {
+			t.Errorf("%s = %v, want %v", key, body[key], v)
+		}
+	}
+}
+
+func TestChatPostsTextAndContent(t *testing.T) {
+	server, bodies := captureServer(t, http.StatusNoContent)
+
+	sink := &Chat{URL: server.URL, Client: server.Client()}
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if len(*bodies) != 1 {
+		t.Fatalf("posts = %d, want 1", len(*bodies))
+	}
+	want := "[podcast-scraper] Test Show spotify: run failed\nfailed to fetch episodes\nSee 'podcast-scraper runs show 42'"
+	body := (*bodies)[0]
+	if body["text"] != want {
+		t.Errorf("text = %q, want %q", body["text"], want)
+	}
+	if body["content"] != want {
+		t.Errorf("content = %q, want %q", body["content"], want)
+	}
+}
+
+func TestWebhookReportsErrorStatus(t *testing.T) {
+	server, _ := captureServer(t, http.StatusNotFound)
+
+	err := (&Webhook{URL: server.URL, Client: server.Client()}).Send(context.Background(), testAlert())
+	if err == nil || !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "no such hook") {
+		t.Errorf("Send() error = %v, want the status and body", err)
+	}
+}
+
+// smtpSession is what a stand-in SMTP server received
+type smtpSession struct {
+	from string
+	to   []string
+	data string
+}
+
+// serveSMTP accepts one connection on a local listener and speaks just
+// enough SMTP to take a message, which it sends on the returned channel
+func serveSMTP(t *testing.T) (string, <-chan *smtpSession) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan *smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		session := &smtpSession{}
+		tp.PrintfLine("220 localhost ESMTP stand-in")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			verb := strings.ToUpper(strings.Fields(line + " ")[0])
+			switch verb {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "MAIL":
+				session.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<> ")
+				tp.PrintfLine("250 OK")
+			case "RCPT":
+				session.to = append(session.to, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<> "))
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				lines, err := tp.ReadDotLines()
+				if err != nil {
+					return
+				}
+				session.data = strings.Join(lines, "\n")
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 Bye")
+				sessions <- session
+				return
+			default:
+				tp.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), sessions
+}
+
+func TestSMTPSendsMessage(t *testing.T) {
+	addr, sessions := serveSMTP(t)
+
+	sink := &SMTP{Addr: addr, From: "scraper@example.com", To: []string{"ops@example.com", "oncall@example.com"}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sink.Send(ctx, testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	var session *smtpSession
+	select {
+	case session = <-sessions:
+	case <-ctx.Done():
+		t.Fatal("the SMTP server received no message")
+	}
+
+	if session.from != "scraper@example.com" {
+		t.Errorf("MAIL FROM = %q, want scraper@example.com", session.from)
+	}
+	if strings.Join(session.to, ",") != "ops@example.com,oncall@example.com" {
+		t.Errorf("RCPT TO = %v, want both recipients", session.to)
+	}
+	for _, want := range []string{
+		"To: ops@example.com, oncall@example.com",
+		"Subject: [podcast-scraper] Test Show spotify: run failed",
+		"failed to fetch episodes",
+		"See 'podcast-scraper runs show 42'",
+	} {
+		if !strings.Contains(session.data, want) {
+			t.Errorf("message does not contain %q:\n%s", want, session.data)
+		}
+	}
+}
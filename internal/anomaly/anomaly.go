@@ -0,0 +1,186 @@
+// Package anomaly flags daily metric values that stand out from their recent
+// history. Each day is compared with the median of a rolling baseline and the
+// median absolute deviation (MAD) around it, which a single outlier in the
+// baseline barely moves.
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Method selects the days a value is compared with
+type Method string
+
+const (
+	// MethodRolling compares a day with every day of the window before it
+	MethodRolling Method = "rolling"
+	// MethodWeekday compares a day with the same weekday in the window
+	// before it, for metrics with a weekly rhythm
+	MethodWeekday Method = "weekday"
+)
+
+// ParseMethod validates a method name
+func ParseMethod(name string) (Method, error) {
+	switch m := Method(name); m {
+	case MethodRolling, MethodWeekday:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown anomaly method %q (want %s or %s)", name, MethodRolling, MethodWeekday)
+}
+
+// Direction is which way an anomalous value departs from its baseline
+type Direction string
+
+const (
+	DirectionSpike Direction = "spike"
+	DirectionDrop  Direction = "drop"
+)
+
+// madScale turns a MAD into an estimate of the standard deviation of
+// normally distributed values, so Threshold reads like a z-score
+const madScale = 1.4826
+
+// Config tunes detection
+type Config struct {
+	Method Method
+
+	// WindowDays is how many days before a value its baseline covers
+	WindowDays int
+
+	// Threshold is how many scaled MADs a value must be from the baseline
+	// median to be anomalous
+	Threshold float64
+
+	// MinDelta is the smallest absolute difference from the median that is
+	// anomalous, so low-traffic series do not alert on a handful of plays
+	MinDelta float64
+}
+
+// DefaultConfig compares each day with the 28 days before it
+func DefaultConfig() Config {
+	return Config{Method: MethodRolling, WindowDays: 28, Threshold: 3.5, MinDelta: 10}
+}
+
+// minPoints is how many baseline days a value needs before it is judged:
+// half the window, and at least three
+func (c Config) minPoints() int {
+	n := c.WindowDays
+	if c.Method == MethodWeekday {
+		n /= 7
+	}
+	return max(3, n/2)
+}
+
+// Point is one day's value of a series
+type Point struct {
+	Date  time.Time // midnight UTC
+	Value float64
+}
+
+// Finding is a day whose value stands out from its baseline
+type Finding struct {
+	Date      time.Time
+	Value     float64
+	Median    float64
+	MAD       float64
+	Score     float64 // signed distance from the median in scaled MADs
+	Direction Direction
+	Points    int // baseline days the median was taken over
+}
+
+// Detect returns the findings among the points dated from to to (inclusive),
+// each judged against the points in the window before it. Days missing from
+// points are left out of baselines rather than counted as zero.
+func (c Config) Detect(points []Point, from, to time.Time) []Finding {
+	byDate := make(map[time.Time]float64, len(points))
+	for _, p := range points {
+		byDate[day(p.Date)] = p.Value
+	}
+
+	var findings []Finding
+	for _, p := range points {
+		date := day(p.Date)
+		if date.Before(day(from)) || date.After(day(to)) {
+			continue
+		}
+
+		baseline := c.baseline(byDate, date)
+		if len(baseline) < c.minPoints() {
+			continue
+		}
+		if f, ok := c.judge(date, p.Value, baseline); ok {
+			findings = append(findings, f)
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Date.Before(findings[j].Date) })
+	return findings
+}
+
+// baseline returns the values in the window before date
+func (c Config) baseline(byDate map[time.Time]float64, date time.Time) []float64 {
+	step := 1
+	if c.Method == MethodWeekday {
+		step = 7
+	}
+
+	var values []float64
+	for d := step; d <= c.WindowDays; d += step {
+		if v, ok := byDate[date.AddDate(0, 0, -d)]; ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func (c Config) judge(date time.Time, value float64, baseline []float64) (Finding, bool) {
+	median := medianOf(baseline)
+	deviations := make([]float64, len(baseline))
+	for i, v := range baseline {
+		deviations[i] = math.Abs(v - median)
+	}
+	mad := medianOf(deviations)
+
+	// The scale never falls below a tenth of the baseline's level, so a flat
+	// baseline still has a spread to measure against and small wobbles in a
+	// steady series do not count
+	scale := math.Max(madScale*mad, math.Max(1, 0.1*math.Abs(median)))
+
+	delta := value - median
+	score := delta / scale
+	if math.Abs(score) < c.Threshold || math.Abs(delta) < c.MinDelta {
+		return Finding{}, false
+	}
+
+	f := Finding{
+		Date:      date,
+		Value:     value,
+		Median:    median,
+		MAD:       mad,
+		Score:     score,
+		Direction: DirectionSpike,
+		Points:    len(baseline),
+	}
+	if delta < 0 {
+		f.Direction = DirectionDrop
+	}
+	return f, true
+}
+
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
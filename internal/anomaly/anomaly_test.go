@@ -0,0 +1,140 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// series returns one point a day from start with the given values
+func series(values ...float64) []Point {
+	points := make([]Point, len(values))
+	for i, v := range values {
+		points[i] = Point{Date: start.AddDate(0, 0, i), Value: v}
+	}
+	return points
+}
+
+// steady returns n days of values wobbling around level
+func steady(n int, level float64) []float64 {
+	wobble := []float64{0, 2, -3, 1, -1, 3, -2}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = level + wobble[i%len(wobble)]
+	}
+	return values
+}
+
+func TestDetectSpikeAndDrop(t *testing.T) {
+	values := append(steady(28, 100), 300, 20)
+	points := series(values...)
+
+	findings := DefaultConfig().Detect(points, start, start.AddDate(0, 0, 29))
+	if len(findings) != 2 {
+		t.Fatalf("Detect() = %d findings, want 2: %+v", len(findings), findings)
+	}
+
+	spike, drop := findings[0], findings[1]
+	if !spike.Date.Equal(start.AddDate(0, 0, 28)) || spike.Direction != DirectionSpike || spike.Score <= 0 {
+		t.Errorf("first finding = %+v, want a spike on day 28", spike)
+	}
+	if !drop.Date.Equal(start.AddDate(0, 0, 29)) || drop.Direction != DirectionDrop || drop.Score >= 0 {
+		t.Errorf("second finding = %+v, want a drop on day 29", drop)
+	}
+	// The spike is in the drop's baseline but barely moves its median
+	if drop.Median < 99 || drop.Median > 101 {
+		t.Errorf("drop median = %v, want about 100", drop.Median)
+	}
+}
+
+func TestDetectIgnoresSteadySeries(t *testing.T) {
+	points := series(steady(40, 100)...)
+
+	if findings := DefaultConfig().Detect(points, start, start.AddDate(0, 0, 39)); len(findings) != 0 {
+		t.Errorf("Detect() = %+v, want no findings", findings)
+	}
+}
+
+func TestDetectMinDelta(t *testing.T) {
+	// Tripling a handful of plays is far outside the baseline, but too small to matter
+	values := make([]float64, 28)
+	for i := range values {
+		values[i] = 3
+	}
+	points := series(append(values, 9)...)
+
+	if findings := DefaultConfig().Detect(points, start, start.AddDate(0, 0, 28)); len(findings) != 0 {
+		t.Errorf("Detect() = %+v, want no findings below MinDelta", findings)
+	}
+}
+
+func TestDetectNeedsBaseline(t *testing.T) {
+	// Only five days precede the spike, fewer than half the window
+	points := series(100, 101, 99, 100, 102, 500)
+
+	if findings := DefaultConfig().Detect(points, start, start.AddDate(0, 0, 5)); len(findings) != 0 {
+		t.Errorf("Detect() = %+v, want no findings without enough baseline", findings)
+	}
+}
+
+func TestDetectOnlyJudgesRange(t *testing.T) {
+	values := append(steady(28, 100), 300, 100)
+	points := series(values...)
+
+	last := start.AddDate(0, 0, 29)
+	if findings := DefaultConfig().Detect(points, last, last); len(findings) != 0 {
+		t.Errorf("Detect() = %+v, want the spike before from left out", findings)
+	}
+}
+
+func TestDetectWeekday(t *testing.T) {
+	// Weekends run at a tenth of weekdays, which a rolling baseline would flag
+	var values []float64
+	for i := 0; i < 35; i++ {
+		if d := start.AddDate(0, 0, i).Weekday(); d == time.Saturday || d == time.Sunday {
+			values = append(values, 100)
+		} else {
+			values = append(values, 1000)
+		}
+	}
+	points := series(values...)
+	from, to := start.AddDate(0, 0, 28), start.AddDate(0, 0, 34)
+
+	weekday := DefaultConfig()
+	weekday.Method = MethodWeekday
+	if findings := weekday.Detect(points, from, to); len(findings) != 0 {
+		t.Errorf("weekday Detect() = %+v, want no findings", findings)
+	}
+
+	if findings := DefaultConfig().Detect(points, from, to); len(findings) == 0 {
+		t.Error("rolling Detect() found nothing, want the weekend flagged")
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	for _, name := range []string{"rolling", "weekday"} {
+		if m, err := ParseMethod(name); err != nil || string(m) != name {
+			t.Errorf("ParseMethod(%q) = %q, %v", name, m, err)
+		}
+	}
+	if _, err := ParseMethod("zscore"); err == nil {
+		t.Error("ParseMethod(\"zscore\") succeeded, want an error")
+	}
+}
+
+func TestMedianOf(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{[]float64{3, 1, 2}, 2},
+		{[]float64{4, 1, 3, 2}, 2.5},
+		{[]float64{7}, 7},
+	}
+	for _, tt := range tests {
+		if got := medianOf(tt.values); got != tt.want {
+			t.Errorf("medianOf(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
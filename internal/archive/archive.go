@@ -0,0 +1,170 @@
+// Package archive keeps gzip-compressed copies of platform API responses in a
+// content-addressed blob store, so metrics can be rebuilt from them later
+// without calling the platforms again.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/repository"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// ErrNotFound is returned when no object is stored under a key
+var ErrNotFound = errors.New("not found in archive")
+
+// Store is a blob store for archived responses. Keys are content addressed,
+// so putting an existing key again is a no-op.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Config selects and configures the blob store
+type Config struct {
+	// URL is a local directory (a path or file:///path) or s3://bucket/prefix
+	URL string
+
+	// S3 settings, used for s3:// URLs. Endpoint may point at any
+	// S3-compatible service; buckets are addressed path-style.
+	S3Endpoint      string
+	S3Region        string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Open opens the blob store described by cfg
+func Open(cfg Config) (Store, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("no archive URL configured")
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "", "file":
+		dir := u.Path
+		if u.Scheme == "" {
+			dir = cfg.URL
+		}
+		return NewLocal(dir)
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          u.Host,
+			Prefix:          strings.Trim(u.Path, "/"),
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+			SessionToken:    cfg.SessionToken,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported archive URL scheme %q", u.Scheme)
+	}
+}
+
+// Key returns the key a response body is archived under:
+// <run_id>/<platform>/<endpoint>/<sha256 of body>.json.gz
+func Key(runID int64, platform scrapers.Platform, endpoint string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf("%d/%s/%s/%s.json.gz", runID, platform, endpoint, hex.EncodeToString(sum[:]))
+}
+
+// Load reads and decompresses the response body archived under key
+func Load(ctx context.Context, store Store, key string) ([]byte, error) {
+	data, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %w", key, err)
+	}
+	defer zr.Close()
+
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %w", key, err)
+	}
+	return body, nil
+}
+
+// Index records which responses were archived for each run
+type Index interface {
+	RecordArchiveEntry(ctx context.Context, entry *repository.ArchiveEntry) error
+}
+
+// Recorder archives responses for the scrapers. Each body is compressed into
+// the blob store and, for responses made during a run, indexed so reparse can
+// find it again.
+type Recorder struct {
+	blobs Store
+	index Index
+}
+
+// NewRecorder creates a recorder. index may be nil, in which case responses
+// are archived but cannot be found by reparse.
+func NewRecorder(blobs Store, index Index) *Recorder {
+	return &Recorder{blobs: blobs, index: index}
+}
+
+// Archive implements scrapers.ResponseArchiver
+func (r *Recorder) Archive(ctx context.Context, resp *scrapers.Response) (string, error) {
+	runID := scrapers.RunIDFromContext(ctx)
+	key := Key(runID, resp.Platform, resp.Endpoint, resp.Body)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(resp.Body); err != nil {
+		return "", fmt.Errorf("failed to compress response: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress response: %w", err)
+	}
+
+	if err := r.blobs.Put(ctx, key, buf.Bytes()); err != nil {
+		return "", err
+	}
+
+	if r.index == nil || runID == 0 {
+		return key, nil
+	}
+
+	entry := &repository.ArchiveEntry{
+		RunID:             runID,
+		Platform:          resp.Platform,
+		Endpoint:          resp.Endpoint,
+		PlatformEpisodeID: resp.PlatformEpisodeID,
+		Key:               key,
+		SizeBytes:         int64(len(resp.Body)),
+	}
+	if resp.PodcastID > 0 {
+		entry.PodcastID = &resp.PodcastID
+	}
+	if resp.EpisodeID > 0 {
+		entry.EpisodeID = &resp.EpisodeID
+	}
+	if !resp.StartDate.IsZero() {
+		entry.StartDate = &resp.StartDate
+		entry.EndDate = &resp.EndDate
+	}
+
+	if err := r.index.RecordArchiveEntry(ctx, entry); err != nil {
+		return "", err
+	}
+	return key, nil
+}
@@ -0,0 +1,194 @@
+// Package logging configures structured logging through log/slog. Records
+// carry the attributes set on their context with With and the IDs of its
+// trace span, and credentials in logged messages, values and errors are
+// redacted before they are written.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys shared by every package that logs
+const (
+	KeyRunID      = "run_id"
+	KeyPlatform   = "platform"
+	KeyShow       = "show"
+	KeyEpisodeID  = "episode_id"
+	KeyStage      = "stage"
+	KeyHTTPStatus = "http_status"
+	KeyError      = "error"
+	KeyTraceID    = "trace_id"
+	KeySpanID     = "span_id"
+)
+
+// Redacted replaces credentials in logged values
+const Redacted = "[REDACTED]"
+
+// New returns a logger writing format ("json" or "text") to w at level
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: l}
+
+	var h slog.Handler
+	switch format {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q (want json or text)", format)
+	}
+
+	return slog.New(&handler{next: h}), nil
+}
+
+type attrsKey struct{}
+
+// With returns a context whose log records carry args, given as alternating
+// keys and values or slog.Attrs like slog.Logger.With. An attribute replaces
+// one with the same key set by an enclosing context.
+func With(ctx context.Context, args ...any) context.Context {
+	r := slog.NewRecord(time.Time{}, 0, "", 0)
+	r.Add(args...)
+
+	added := make(map[string]bool, r.NumAttrs())
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		added[a.Key] = true
+		attrs = append(attrs, a)
+		return true
+	})
+
+	var merged []slog.Attr
+	for _, a := range attrsFromContext(ctx) {
+		if !added[a.Key] {
+			merged = append(merged, a)
+		}
+	}
+	return context.WithValue(ctx, attrsKey{}, append(merged, attrs...))
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// handler adds context attributes and span IDs to records and redacts them
+type handler struct {
+	next slog.Handler
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, Redact(r.Message), r.PC)
+
+	// Attributes of the record take precedence over the context's
+	own := make(map[string]bool, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		own[a.Key] = true
+		return true
+	})
+	for _, a := range attrsFromContext(ctx) {
+		if !own[a.Key] {
+			out.AddAttrs(redactAttr(a))
+		}
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		out.AddAttrs(slog.String(KeyTraceID, sc.TraceID().String()), slog.String(KeySpanID, sc.SpanID().String()))
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &handler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name)}
+}
+
+// sensitiveKeys are attribute keys whose values are never logged
+var sensitiveKeys = []string{"password", "secret", "token", "cookie", "api_key", "apikey", "authorization"}
+
+// redactAttr redacts a's value, or all of it when its key names a credential
+func redactAttr(a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return slog.String(a.Key, Redacted)
+		}
+	}
+
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]any, len(group))
+		for i, g := range group {
+			redacted[i] = redactAttr(g)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case error:
+			return slog.String(a.Key, Redact(x.Error()))
+		case fmt.Stringer:
+			return slog.String(a.Key, Redact(x.String()))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+// redactions match credentials in free-form text such as error bodies,
+// request URLs and headers
+var redactions = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// Authorization, Cookie and Set-Cookie headers
+	{regexp.MustCompile(`(?i)\b(authorization|cookie|set-cookie)(:\s*)[^\r\n]+`), "${1}${2}" + Redacted},
+	{regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9\-._~+/]+=*`), "${1}" + Redacted},
+	// Platform session cookies
+	{regexp.MustCompile(`(?i)\b(sp_dc|sp_key|session-token|session-id|ubid-main|at-main|x-main|myacinfo|dqsid)=[^;\s&"]+`), "${1}=" + Redacted},
+	// JSON fields
+	{regexp.MustCompile(`(?i)("(?:access_token|refresh_token|id_token|client_secret|password|api_key|apikey|key|token|session_token|cookie)"\s*:\s*")[^"]*"`), "${1}" + Redacted + `"`},
+	// Query strings and form bodies
+	{regexp.MustCompile(`(?i)\b(access_token|refresh_token|id_token|client_secret|password|api_key|apikey|key|token)=[^&\s"]+`), "${1}=" + Redacted},
+}
+
+// Redact replaces credentials, session cookies and tokens in s
+func Redact(s string) string {
+	for _, r := range redactions {
+		s = r.re.ReplaceAllString(s, r.repl)
+	}
+	return s
+}
@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain text", in: "request failed: 503", want: "request failed: 503"},
+		{name: "authorization header", in: "Authorization: Bearer abc.def", want: "Authorization: " + Redacted},
+		{name: "bearer token", in: "sent bearer abc.def-123", want: "sent bearer " + Redacted},
+		{name: "cookie header", in: "Cookie: sp_dc=abc; sp_key=def", want: "Cookie: " + Redacted},
+		{name: "session cookies", in: "sp_dc=abc; other=1; session-token=xyz", want: "sp_dc=" + Redacted + "; other=1; session-token=" + Redacted},
+		{name: "json fields", in: `{"access_token":"abc","expires_in":3600}`, want: `{"access_token":"` + Redacted + `","expires_in":3600}`},
+		{name: "query string", in: "GET /v3/videos?part=id&key=AIza123&id=1", want: "GET /v3/videos?part=id&key=" + Redacted + "&id=1"},
+		{name: "form body", in: "grant_type=refresh_token&refresh_token=1//abc&client_secret=s3", want: "grant_type=refresh_token&refresh_token=" + Redacted + "&client_secret=" + Redacted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.in); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// logRecord logs one record through a JSON logger and returns it decoded
+func logRecord(t *testing.T, ctx context.Context, log func(ctx context.Context, logger *slog.Logger)) map[string]any {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "info")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	log(ctx, logger)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("failed to decode %q: %v", buf.String(), err)
+	}
+	return record
+}
+
+func TestHandlerRedactsRecords(t *testing.T) {
+	record := logRecord(t, context.Background(), func(ctx context.Context, logger *slog.Logger) {
+		logger.With("api_key", "AIza123").InfoContext(ctx, "Fetching ?key=AIza123",
+			"password", "hunter2",
+			KeyError, errors.New("401: Authorization: Bearer abc"),
+			slog.Group("request", "url", "https://example.com/?access_token=abc"))
+	})
+
+	for key, want := range map[string]string{
+		"msg":      "Fetching ?key=" + Redacted,
+		"api_key":  Redacted,
+		"password": Redacted,
+		KeyError:   "401: Authorization: " + Redacted,
+	} {
+		if got := record[key]; got != want {
+			t.Errorf("%s = %v, want %q", key, got, want)
+		}
+	}
+
+	request, _ := record["request"].(map[string]any)
+	if got := request["url"]; got != "https://example.com/?access_token="+Redacted {
+		t.Errorf("request.url = %v, want the token redacted", got)
+	}
+}
+
+func TestHandlerAddsContextAttributes(t *testing.T) {
+	ctx := With(context.Background(), KeyPlatform, "spotify", KeyRunID, 1)
+	ctx = With(ctx, KeyRunID, 2, "cookie", "sp_dc=abc")
+
+	record := logRecord(t, ctx, func(ctx context.Context, logger *slog.Logger) {
+		logger.InfoContext(ctx, "Collected", KeyShow, "Show")
+	})
+
+	if got := record[KeyPlatform]; got != "spotify" {
+		t.Errorf("%s = %v, want spotify", KeyPlatform, got)
+	}
+	// The innermost context wins
+	if got := record[KeyRunID]; got != float64(2) {
+		t.Errorf("%s = %v, want 2", KeyRunID, got)
+	}
+	if got := record["cookie"]; got != Redacted {
+		t.Errorf("cookie = %v, want %q", got, Redacted)
+	}
+	if got := record[KeyShow]; got != "Show" {
+		t.Errorf("%s = %v, want Show", KeyShow, got)
+	}
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "xml", "info"); err == nil || !strings.Contains(err.Error(), "format") {
+		t.Errorf("New() with format xml error = %v, want an invalid format error", err)
+	}
+	if _, err := New(&bytes.Buffer{}, "json", "loud"); err == nil || !strings.Contains(err.Error(), "level") {
+		t.Errorf("New() with level loud error = %v, want an invalid level error", err)
+	}
+}
@@ -0,0 +1,333 @@
+// Package quality checks scraped metric rows against data quality rules
+// before they are written. Each rule's action, set per platform, decides
+// whether a row that breaks it is quarantined, rejected, only logged, or
+// not checked at all.
+package quality
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// Action is what happens to a row that breaks a rule
+type Action string
+
+const (
+	// ActionQuarantine holds the row aside with its reasons instead of writing it
+	ActionQuarantine Action = "quarantine"
+	// ActionReject drops the row
+	ActionReject Action = "reject"
+	// ActionWarn writes the row and logs the violation
+	ActionWarn Action = "warn"
+	// ActionOff skips the rule
+	ActionOff Action = "off"
+)
+
+// severity orders actions so the strongest one a row earns wins
+var severity = map[Action]int{ActionOff: 0, ActionWarn: 1, ActionQuarantine: 2, ActionReject: 3}
+
+func parseAction(name string) (Action, error) {
+	a := Action(name)
+	if _, ok := severity[a]; !ok {
+		return "", fmt.Errorf("unknown action %q (want quarantine, reject, warn or off)", name)
+	}
+	return a, nil
+}
+
+// Rule checks one property of episode and show metric rows. A check returns
+// why the row breaks the rule, or "" when it passes.
+type Rule struct {
+	Name        string
+	Description string
+
+	episode func(m *scrapers.EpisodeMetrics, now time.Time) string
+	show    func(m *scrapers.ShowMetrics, now time.Time) string
+}
+
+// Rules lists every rule
+var Rules = []Rule{
+	{
+		Name:        "missing_date",
+		Description: "metric date is unset, usually because it failed to parse",
+		episode: func(m *scrapers.EpisodeMetrics, now time.Time) string {
+			return checkDate(m.MetricDate, now, false)
+		},
+		show: func(m *scrapers.ShowMetrics, now time.Time) string {
+			return checkDate(m.MetricDate, now, false)
+		},
+	},
+	{
+		Name:        "future_date",
+		Description: "metric date is more than a day ahead",
+		episode: func(m *scrapers.EpisodeMetrics, now time.Time) string {
+			return checkDate(m.MetricDate, now, true)
+		},
+		show: func(m *scrapers.ShowMetrics, now time.Time) string {
+			return checkDate(m.MetricDate, now, true)
+		},
+	},
+	{
+		Name:        "negative_value",
+		Description: "a count or gained/lost delta is negative",
+		episode: func(m *scrapers.EpisodeMetrics, now time.Time) string {
+			return negatives(map[string]int64{
+				"plays":                         m.Plays,
+				"listeners":                     m.Listeners,
+				"engaged_listeners":             m.EngagedListeners,
+				"views":                         m.Views,
+				"likes":                         m.Likes,
+				"dislikes":                      m.Dislikes,
+				"comments_count":                m.CommentsCount,
+				"shares":                        m.Shares,
+				"watch_time_minutes":            m.WatchTimeMinutes,
+				"average_view_duration_seconds": int64(m.AverageViewDuration),
+				"subscribers_gained":            int64(m.SubscribersGained),
+				"subscribers_lost":              int64(m.SubscribersLost),
+				"downloads":                     m.Downloads,
+				"streams":                       m.Streams,
+				"average_listen_time_seconds":   int64(derefInt(m.AverageListenTime)),
+				"followers_total":               deref(m.FollowersTotal),
+				"followers_gained":              int64(m.FollowersGained),
+				"followers_lost":                int64(m.FollowersLost),
+			})
+		},
+		show: func(m *scrapers.ShowMetrics, now time.Time) string {
+			return negatives(map[string]int64{
+				"total_plays":             m.TotalPlays,
+				"total_listeners":         m.TotalListeners,
+				"total_engaged_listeners": m.TotalEngagedListeners,
+				"total_views":             m.TotalViews,
+				"total_downloads":         m.TotalDownloads,
+				"total_comments":          m.TotalComments,
+				"total_likes":             m.TotalLikes,
+				"total_shares":            m.TotalShares,
+				"followers_total":         deref(m.FollowersTotal),
+				"followers_gained":        int64(m.FollowersGained),
+				"followers_lost":          int64(m.FollowersLost),
+				"subscribers_total":       deref(m.SubscribersTotal),
+				"subscribers_gained":      int64(m.SubscribersGained),
+				"subscribers_lost":        int64(m.SubscribersLost),
+			})
+		},
+	},
+	{
+		Name:        "completion_rate_range",
+		Description: "completion rate is outside 0-100",
+		episode: func(m *scrapers.EpisodeMetrics, now time.Time) string {
+			return checkPercent("completion_rate", m.CompletionRate)
+		},
+		show: func(m *scrapers.ShowMetrics, now time.Time) string {
+			return checkPercent("average_completion_rate", m.AverageCompletionRate)
+		},
+	},
+	{
+		Name:        "plays_without_listeners",
+		Description: "plays are reported without any listeners",
+		episode: func(m *scrapers.EpisodeMetrics, now time.Time) string {
+			if m.Plays > 0 && m.Listeners == 0 {
+				return fmt.Sprintf("%d plays but no listeners", m.Plays)
+			}
+			return ""
+		},
+		show: func(m *scrapers.ShowMetrics, now time.Time) string {
+			if m.TotalPlays > 0 && m.TotalListeners == 0 {
+				return fmt.Sprintf("%d plays but no listeners", m.TotalPlays)
+			}
+			return ""
+		},
+	},
+}
+
+func checkDate(date, now time.Time, future bool) string {
+	switch {
+	case !future && date.IsZero():
+		return "metric date is missing"
+	case future && !date.IsZero() && date.After(now.AddDate(0, 0, 1)):
+		return fmt.Sprintf("metric date %s is in the future", date.Format("2006-01-02"))
+	}
+	return ""
+}
+
+func checkPercent(field string, v *float64) string {
+	if v != nil && (*v < 0 || *v > 100) {
+		return fmt.Sprintf("%s %.2f is outside 0-100", field, *v)
+	}
+	return ""
+}
+
+func negatives(values map[string]int64) string {
+	var fields []string
+	for field, v := range values {
+		if v < 0 {
+			fields = append(fields, fmt.Sprintf("%s=%d", field, v))
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	sort.Strings(fields)
+	return "negative " + strings.Join(fields, ", ")
+}
+
+func deref(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func derefInt(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// Violation is one rule a row breaks
+type Violation struct {
+	Rule   string
+	Action Action
+	Reason string
+}
+
+// String describes the violation for logs and the quarantine table
+func (v Violation) String() string {
+	return v.Rule + ": " + v.Reason
+}
+
+// Verdict is what happens to one row: the strongest action among the rules
+// it breaks, or ActionOff when it breaks none that are checked
+type Verdict struct {
+	Action     Action
+	Violations []Violation
+}
+
+// Keep reports whether the row is still written
+func (v Verdict) Keep() bool {
+	return v.Action == ActionOff || v.Action == ActionWarn
+}
+
+// Reasons lists the violations as strings
+func (v Verdict) Reasons() []string {
+	reasons := make([]string, len(v.Violations))
+	for i, violation := range v.Violations {
+		reasons[i] = violation.String()
+	}
+	return reasons
+}
+
+// Policy holds the action of each rule, by default and per platform
+type Policy struct {
+	defaults  map[string]Action
+	platforms map[scrapers.Platform]map[string]Action
+}
+
+// DefaultPolicy quarantines rows breaking any rule on every platform
+func DefaultPolicy() *Policy {
+	p := &Policy{defaults: make(map[string]Action), platforms: make(map[scrapers.Platform]map[string]Action)}
+	for _, r := range Rules {
+		p.defaults[r.Name] = ActionQuarantine
+	}
+	return p
+}
+
+// ParsePolicy overrides the default policy with a comma-separated list of
+// rule=action entries. An entry prefixed with platform: applies to that
+// platform only, e.g. "completion_rate_range=reject,youtube:plays_without_listeners=off".
+// The rule "all" sets every rule at once.
+func ParsePolicy(spec string) (*Policy, error) {
+	p := DefaultPolicy()
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, actionName, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid quality rule %q: want [platform:]rule=action", entry)
+		}
+		action, err := parseAction(strings.TrimSpace(actionName))
+		if err != nil {
+			return nil, fmt.Errorf("invalid quality rule %q: %w", entry, err)
+		}
+
+		actions := p.defaults
+		if platformName, rule, ok := strings.Cut(name, ":"); ok {
+			platform, err := scrapers.ParsePlatform(strings.TrimSpace(platformName))
+			if err != nil {
+				return nil, fmt.Errorf("invalid quality rule %q: %w", entry, err)
+			}
+			if p.platforms[platform] == nil {
+				p.platforms[platform] = make(map[string]Action)
+			}
+			actions, name = p.platforms[platform], rule
+		}
+
+		name = strings.TrimSpace(name)
+		if name == "all" {
+			for _, r := range Rules {
+				actions[r.Name] = action
+			}
+			continue
+		}
+		if !knownRule(name) {
+			return nil, fmt.Errorf("invalid quality rule %q: unknown rule %q", entry, name)
+		}
+		actions[name] = action
+	}
+
+	return p, nil
+}
+
+func knownRule(name string) bool {
+	for _, r := range Rules {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Action returns what happens on platform to rows that break rule
+func (p *Policy) Action(platform scrapers.Platform, rule string) Action {
+	if a, ok := p.platforms[platform][rule]; ok {
+		return a
+	}
+	return p.defaults[rule]
+}
+
+// CheckEpisodeMetrics checks an episode metrics row collected from platform
+func (p *Policy) CheckEpisodeMetrics(platform scrapers.Platform, m *scrapers.EpisodeMetrics, now time.Time) Verdict {
+	return p.check(platform, func(r Rule) string { return r.episode(m, now) })
+}
+
+// CheckShowMetrics checks a show metrics row collected from platform
+func (p *Policy) CheckShowMetrics(platform scrapers.Platform, m *scrapers.ShowMetrics, now time.Time) Verdict {
+	return p.check(platform, func(r Rule) string { return r.show(m, now) })
+}
+
+func (p *Policy) check(platform scrapers.Platform, check func(Rule) string) Verdict {
+	verdict := Verdict{Action: ActionOff}
+	for _, r := range Rules {
+		action := p.Action(platform, r.Name)
+		if action == ActionOff {
+			continue
+		}
+		reason := check(r)
+		if reason == "" {
+			continue
+		}
+
+		verdict.Violations = append(verdict.Violations, Violation{Rule: r.Name, Action: action, Reason: reason})
+		if severity[action] > severity[verdict.Action] {
+			verdict.Action = action
+		}
+	}
+	return verdict
+}
@@ -0,0 +1,142 @@
+package quality
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+var now = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func percent(v float64) *float64 { return &v }
+
+func TestCheckEpisodeMetrics(t *testing.T) {
+	valid := func() *scrapers.EpisodeMetrics {
+		return &scrapers.EpisodeMetrics{MetricDate: now.AddDate(0, 0, -1), Plays: 10, Listeners: 8}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(m *scrapers.EpisodeMetrics)
+		rules  []string
+	}{
+		{name: "valid row", modify: func(m *scrapers.EpisodeMetrics) {}},
+		{name: "missing date", modify: func(m *scrapers.EpisodeMetrics) { m.MetricDate = time.Time{} }, rules: []string{"missing_date"}},
+		{name: "tomorrow is allowed", modify: func(m *scrapers.EpisodeMetrics) { m.MetricDate = now.AddDate(0, 0, 1) }},
+		{name: "future date", modify: func(m *scrapers.EpisodeMetrics) { m.MetricDate = now.AddDate(0, 0, 3) }, rules: []string{"future_date"}},
+		{name: "negative plays", modify: func(m *scrapers.EpisodeMetrics) { m.Plays = -1 }, rules: []string{"negative_value"}},
+		{name: "completion rate over 100", modify: func(m *scrapers.EpisodeMetrics) { m.CompletionRate = percent(120) }, rules: []string{"completion_rate_range"}},
+		{name: "plays without listeners", modify: func(m *scrapers.EpisodeMetrics) { m.Listeners = 0 }, rules: []string{"plays_without_listeners"}},
+		{
+			name:   "several rules",
+			modify: func(m *scrapers.EpisodeMetrics) { m.MetricDate = time.Time{}; m.Listeners = 0; m.Views = -2 },
+			rules:  []string{"missing_date", "negative_value", "plays_without_listeners"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := valid()
+			tt.modify(m)
+
+			verdict := DefaultPolicy().CheckEpisodeMetrics(scrapers.PlatformSpotify, m, now)
+
+			var rules []string
+			for _, v := range verdict.Violations {
+				rules = append(rules, v.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.rules) {
+				t.Errorf("violated rules = %v, want %v", rules, tt.rules)
+			}
+			if wantKeep := len(tt.rules) == 0; verdict.Keep() != wantKeep {
+				t.Errorf("Keep() = %v, want %v", verdict.Keep(), wantKeep)
+			}
+		})
+	}
+}
+
+func TestCheckShowMetrics(t *testing.T) {
+	m := &scrapers.ShowMetrics{MetricDate: now, TotalPlays: 5, AverageCompletionRate: percent(-1)}
+
+	verdict := DefaultPolicy().CheckShowMetrics(scrapers.PlatformAmazonMusic, m, now)
+
+	want := []string{
+		"completion_rate_range: average_completion_rate -1.00 is outside 0-100",
+		"plays_without_listeners: 5 plays but no listeners",
+	}
+	if got := verdict.Reasons(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Reasons() = %q, want %q", got, want)
+	}
+}
+
+func TestNegativesListsFieldsInOrder(t *testing.T) {
+	got := negatives(map[string]int64{"views": -3, "likes": -1, "plays": 4})
+	if want := "negative likes=-1, views=-3"; got != want {
+		t.Errorf("negatives() = %q, want %q", got, want)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy("completion_rate_range=reject, youtube:plays_without_listeners=off, amazon_music:all=warn")
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		platform scrapers.Platform
+		rule     string
+		want     Action
+	}{
+		{scrapers.PlatformSpotify, "completion_rate_range", ActionReject},
+		{scrapers.PlatformSpotify, "plays_without_listeners", ActionQuarantine},
+		{scrapers.PlatformYouTube, "plays_without_listeners", ActionOff},
+		{scrapers.PlatformYouTube, "completion_rate_range", ActionReject},
+		{scrapers.PlatformAmazonMusic, "completion_rate_range", ActionWarn},
+		{scrapers.PlatformAmazonMusic, "missing_date", ActionWarn},
+	}
+	for _, tt := range tests {
+		if got := p.Action(tt.platform, tt.rule); got != tt.want {
+			t.Errorf("Action(%s, %s) = %s, want %s", tt.platform, tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	for _, spec := range []string{
+		"completion_rate_range",
+		"completion_rate_range=drop",
+		"no_such_rule=warn",
+		"myspace:all=warn",
+	} {
+		if _, err := ParsePolicy(spec); err == nil {
+			t.Errorf("ParsePolicy(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestVerdictTakesStrongestAction(t *testing.T) {
+	p, err := ParsePolicy("negative_value=warn,plays_without_listeners=reject")
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	m := &scrapers.EpisodeMetrics{MetricDate: now, Plays: 3, Views: -1}
+
+	verdict := p.CheckEpisodeMetrics(scrapers.PlatformSpotify, m, now)
+	if verdict.Action != ActionReject {
+		t.Errorf("Action = %s, want %s", verdict.Action, ActionReject)
+	}
+	if verdict.Keep() {
+		t.Error("Keep() = true for a rejected row")
+	}
+
+	p, err = ParsePolicy("all=warn")
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	verdict = p.CheckEpisodeMetrics(scrapers.PlatformSpotify, m, now)
+	if verdict.Action != ActionWarn || !verdict.Keep() {
+		t.Errorf("verdict = %+v, want a kept row with warnings", verdict)
+	}
+}
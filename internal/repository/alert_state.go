@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// LastAlerted returns when the alert with key was last sent, or the zero
+// time if it has not been sent since it was last cleared
+func (r *PodcastRepository) LastAlerted(ctx context.Context, key string) (time.Time, error) {
+	var sentAt time.Time
+	err := r.db.QueryRowContext(ctx, `SELECT last_sent_at FROM raw.podcast_alert_state WHERE alert_key = $1`, key).Scan(&sentAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read alert state: %w", err)
+	}
+	return sentAt, nil
+}
+
+// MarkAlerted records that the alert with key was sent at
+func (r *PodcastRepository) MarkAlerted(ctx context.Context, key string, at time.Time) error {
+	query := `
+		INSERT INTO raw.podcast_alert_state (alert_key, last_sent_at)
+		VALUES ($1, $2)
+		ON CONFLICT (alert_key) DO UPDATE SET last_sent_at = EXCLUDED.last_sent_at
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, key, at); err != nil {
+		return fmt.Errorf("failed to save alert state: %w", err)
+	}
+	return nil
+}
+
+// ClearAlerted forgets the alerts with keys, so they are sent again the
+// next time they fire
+func (r *PodcastRepository) ClearAlerted(ctx context.Context, keys []string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM raw.podcast_alert_state WHERE alert_key = ANY($1)`, pq.Array(keys)); err != nil {
+		return fmt.Errorf("failed to clear alert state: %w", err)
+	}
+	return nil
+}
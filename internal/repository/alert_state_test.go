@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/alert"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// countingSink counts the alerts it is sent
+type countingSink struct {
+	sent int
+}
+
+func (s *countingSink) Name() string { return "counting" }
+
+func (s *countingSink) Send(ctx context.Context, a *alert.Alert) error {
+	s.sent++
+	return nil
+}
+
+func TestAlertState(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+
+	sink := &countingSink{}
+	n := alert.NewWithSinks([]alert.Sink{sink}, repo, time.Hour, nil)
+	newAlert := func(at time.Time) *alert.Alert {
+		return &alert.Alert{Trigger: alert.TriggerRunFailed, Platform: scrapers.PlatformSpotify, Show: "Test Show", Summary: "run failed", Time: at}
+	}
+	const stateCount = `SELECT COUNT(*) FROM raw.podcast_alert_state`
+
+	sentAt := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	if err := n.Notify(ctx, newAlert(sentAt)); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if got := countRows(t, repo, stateCount); got != 1 {
+		t.Fatalf("alert state rows after sending = %d, want 1", got)
+	}
+
+	// The cooldown holds across notifiers sharing the database
+	other := alert.NewWithSinks([]alert.Sink{sink}, repo, time.Hour, nil)
+	if err := other.Notify(ctx, newAlert(sentAt.Add(10*time.Minute))); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if sink.sent != 1 {
+		t.Errorf("alerts sent within the cooldown = %d, want 1", sink.sent)
+	}
+
+	if err := n.Resolve(ctx, scrapers.PlatformSpotify, "Test Show", alert.TriggerRunFailed, alert.TriggerZeroMetrics); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got := countRows(t, repo, stateCount); got != 0 {
+		t.Errorf("alert state rows after resolve = %d, want 0", got)
+	}
+
+	if err := other.Notify(ctx, newAlert(sentAt.Add(20*time.Minute))); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if sink.sent != 2 {
+		t.Errorf("alerts sent after resolve = %d, want 2", sink.sent)
+	}
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// MetricPoint is one day's value of a metric watched for anomalies
+type MetricPoint struct {
+	PodcastID int64
+	EpisodeID int64 // 0 for show metrics
+	Metric    string
+	Date      time.Time
+	Value     float64
+}
+
+// MetricHistory returns the daily values of the watched episode and show
+// metrics of a platform between since and until, ordered by series and date.
+// A podcastID of 0 covers every podcast on the platform. Metrics are always
+// read from the published tables, never a run's staging tables.
+func (r *PodcastRepository) MetricHistory(ctx context.Context, platform scrapers.Platform, podcastID int64, since, until time.Time) ([]*MetricPoint, error) {
+	query := `
+		SELECT e.podcast_id, m.episode_id, v.metric, m.metric_date, v.value
+		FROM raw.podcast_episode_metrics m
+		JOIN raw.podcast_episodes e ON e.id = m.episode_id
+		JOIN raw.podcasts p ON p.id = e.podcast_id
+		CROSS JOIN LATERAL (VALUES ('plays', m.plays), ('views', m.views)) AS v(metric, value)
+		WHERE p.platform = $1 AND ($2::bigint = 0 OR p.id = $2)
+		  AND m.metric_date BETWEEN $3::date AND $4::date
+		UNION ALL
+		SELECT s.podcast_id, 0, v.metric, s.metric_date, v.value
+		FROM raw.podcast_show_metrics s
+		JOIN raw.podcasts p ON p.id = s.podcast_id
+		CROSS JOIN LATERAL (VALUES
+			('total_plays', s.total_plays),
+			('total_views', s.total_views),
+			('followers_gained', s.followers_gained::bigint),
+			('subscribers_gained', s.subscribers_gained::bigint)
+		) AS v(metric, value)
+		WHERE p.platform = $1 AND ($2::bigint = 0 OR p.id = $2)
+		  AND s.metric_date BETWEEN $3::date AND $4::date
+		ORDER BY 1, 2, 3, 4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, platform, podcastID, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metric history: %w", err)
+	}
+	defer rows.Close()
+
+	var points []*MetricPoint
+	for rows.Next() {
+		p := &MetricPoint{}
+		if err := rows.Scan(&p.PodcastID, &p.EpisodeID, &p.Metric, &p.Date, &p.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan metric history: %w", err)
+		}
+		points = append(points, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query metric history: %w", err)
+	}
+
+	return points, nil
+}
+
+// ReplaceMetricAnomalies replaces the anomalies recorded for a platform's
+// metric dates from to to with anomalies, so days that no longer stand out
+// after a restatement are cleared. A podcastID of 0 covers every podcast on
+// the platform.
+func (r *PodcastRepository) ReplaceMetricAnomalies(ctx context.Context, platform scrapers.Platform, podcastID int64, from, to time.Time, anomalies []*scrapers.MetricAnomaly) error {
+	return r.InTx(ctx, func(tx Store) error {
+		db := tx.(*PodcastRepository).db
+
+		_, err := db.ExecContext(ctx, `
+			DELETE FROM raw.podcast_metric_anomalies
+			WHERE platform = $1 AND ($2::bigint = 0 OR podcast_id = $2)
+			  AND metric_date BETWEEN $3::date AND $4::date
+		`, platform, podcastID, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to clear metric anomalies: %w", err)
+		}
+
+		query := `
+			INSERT INTO raw.podcast_metric_anomalies (
+				run_id, platform, podcast_id, episode_id, metric, metric_date,
+				value, baseline, mad, score, direction, method, detected_at
+			)
+			VALUES (NULLIF($1::bigint, 0), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		`
+
+		for _, a := range anomalies {
+			_, err := db.ExecContext(ctx, query,
+				a.RunID,
+				a.Platform,
+				a.PodcastID,
+				a.EpisodeID,
+				a.Metric,
+				a.MetricDate,
+				a.Value,
+				a.Baseline,
+				a.MAD,
+				a.Score,
+				a.Direction,
+				a.Method,
+				a.DetectedAt,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to record metric anomaly: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// ListMetricAnomalies returns the anomalies recorded for metric dates from
+// to to, newest first, optionally limited to platforms
+func (r *PodcastRepository) ListMetricAnomalies(ctx context.Context, platforms []scrapers.Platform, from, to time.Time) ([]*scrapers.MetricAnomaly, error) {
+	query := `
+		SELECT a.id, COALESCE(a.run_id, 0), a.platform, a.podcast_id, a.episode_id,
+		       COALESCE(e.episode_title, ''), a.metric, a.metric_date, a.value,
+		       a.baseline, a.mad, a.score, a.direction, a.method, a.detected_at
+		FROM raw.podcast_metric_anomalies a
+		LEFT JOIN raw.podcast_episodes e ON e.id = a.episode_id
+		WHERE (cardinality($1::text[]) = 0 OR a.platform = ANY($1))
+		  AND a.metric_date BETWEEN $2::date AND $3::date
+		ORDER BY a.metric_date DESC, a.platform, ABS(a.score) DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(platformStrings(platforms)), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metric anomalies: %w", err)
+	}
+	defer rows.Close()
+
+	var anomalies []*scrapers.MetricAnomaly
+	for rows.Next() {
+		a := &scrapers.MetricAnomaly{}
+		var episodeID sql.NullInt64
+		if err := rows.Scan(
+			&a.ID,
+			&a.RunID,
+			&a.Platform,
+			&a.PodcastID,
+			&episodeID,
+			&a.EpisodeTitle,
+			&a.Metric,
+			&a.MetricDate,
+			&a.Value,
+			&a.Baseline,
+			&a.MAD,
+			&a.Score,
+			&a.Direction,
+			&a.Method,
+			&a.DetectedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan metric anomaly: %w", err)
+		}
+		if episodeID.Valid {
+			a.EpisodeID = &episodeID.Int64
+		}
+		anomalies = append(anomalies, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list metric anomalies: %w", err)
+	}
+
+	return anomalies, nil
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// ArchiveEntry indexes a platform API response archived during a run
+type ArchiveEntry struct {
+	ID       int64
+	RunID    int64 // 0 once the run has been pruned
+	Platform scrapers.Platform
+	Endpoint string
+
+	PodcastID         *int64
+	EpisodeID         *int64 // nil when the episode was new to the run
+	PlatformEpisodeID string
+
+	// StartDate and EndDate are the requested metrics window, if any
+	StartDate *time.Time
+	EndDate   *time.Time
+
+	Key        string
+	SizeBytes  int64
+	ArchivedAt time.Time
+}
+
+// RecordArchiveEntry adds an archived response to the index. Archiving the
+// same body twice in a run is recorded once.
+func (r *PodcastRepository) RecordArchiveEntry(ctx context.Context, entry *ArchiveEntry) error {
+	query := `
+		INSERT INTO raw.podcast_response_archive (
+			run_id, platform, endpoint, podcast_id, episode_id,
+			platform_episode_id, start_date, end_date, archive_key, size_bytes
+		)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10)
+		ON CONFLICT (run_id, archive_key) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		entry.RunID,
+		entry.Platform,
+		entry.Endpoint,
+		entry.PodcastID,
+		entry.EpisodeID,
+		entry.PlatformEpisodeID,
+		entry.StartDate,
+		entry.EndDate,
+		entry.Key,
+		entry.SizeBytes,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to record archive entry: %w", err)
+	}
+
+	return nil
+}
+
+// ArchiveFilter selects archived responses. Zero fields match everything;
+// Since keeps responses whose requested window ends on or after it.
+type ArchiveFilter struct {
+	RunID     int64
+	Platforms []scrapers.Platform
+	Since     time.Time
+}
+
+// ListArchiveEntries returns the archived responses matching the filter in
+// the order they were fetched
+func (r *PodcastRepository) ListArchiveEntries(ctx context.Context, filter ArchiveFilter) ([]*ArchiveEntry, error) {
+	query := `
+		SELECT id, COALESCE(run_id, 0), platform, endpoint, podcast_id, episode_id,
+		       COALESCE(platform_episode_id, ''), start_date, end_date,
+		       archive_key, size_bytes, archived_at
+		FROM raw.podcast_response_archive
+		WHERE ($1 = 0 OR run_id = $1)
+		  AND (cardinality($2::text[]) = 0 OR platform = ANY($2))
+		  AND ($3::date IS NULL OR end_date >= $3)
+		ORDER BY archived_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		filter.RunID,
+		pq.Array(platformStrings(filter.Platforms)),
+		nullTime(filter.Since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archive entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*ArchiveEntry
+	for rows.Next() {
+		e := &ArchiveEntry{}
+		var podcastID, episodeID sql.NullInt64
+		var startDate, endDate sql.NullTime
+		if err := rows.Scan(
+			&e.ID,
+			&e.RunID,
+			&e.Platform,
+			&e.Endpoint,
+			&podcastID,
+			&episodeID,
+			&e.PlatformEpisodeID,
+			&startDate,
+			&endDate,
+			&e.Key,
+			&e.SizeBytes,
+			&e.ArchivedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan archive entry: %w", err)
+		}
+		if podcastID.Valid {
+			e.PodcastID = &podcastID.Int64
+		}
+		if episodeID.Valid {
+			e.EpisodeID = &episodeID.Int64
+		}
+		if startDate.Valid {
+			e.StartDate = &startDate.Time
+		}
+		if endDate.Valid {
+			e.EndDate = &endDate.Time
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list archive entries: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// episodeMetricsStagingColumns is the column order COPY writes to the staging table
+var episodeMetricsStagingColumns = []string{
+	"episode_id", "metric_date", "plays", "listeners", "engaged_listeners",
+	"views", "likes", "dislikes", "comments_count", "shares", "watch_time_minutes",
+	"average_view_duration_seconds", "subscribers_gained", "subscribers_lost",
+	"downloads", "streams", "completion_rate", "average_listen_time_seconds",
+	"followers_total", "followers_gained", "followers_lost",
+	"top_countries", "top_cities", "device_breakdown", "raw_data", "updated_at", "run_id",
+}
+
+// dedupeEpisodeMetrics keeps the last row per (episode, date) so a single
+// merge never updates the same row twice. It returns the kept rows and, for
+// each input row, the index of the kept row that carries its data.
+func dedupeEpisodeMetrics(metrics []*scrapers.EpisodeMetrics) ([]*scrapers.EpisodeMetrics, []int) {
+	type key struct {
+		episodeID int64
+		date      string
+	}
+
+	last := make(map[key]int, len(metrics))
+	for i, m := range metrics {
+		last[key{m.EpisodeID, m.MetricDate.Format("2006-01-02")}] = i
+	}
+
+	var kept []*scrapers.EpisodeMetrics
+	keptIndex := make(map[int]int, len(last))
+	owner := make([]int, len(metrics))
+	for i, m := range metrics {
+		j := last[key{m.EpisodeID, m.MetricDate.Format("2006-01-02")}]
+		if _, ok := keptIndex[j]; !ok {
+			keptIndex[j] = len(kept)
+			kept = append(kept, metrics[j])
+		}
+		owner[i] = keptIndex[j]
+	}
+
+	return kept, owner
+}
+
+// UpsertEpisodeMetricsBatch writes many episode metric rows in one round trip:
+// the rows are COPYed into a temporary staging table and merged with a single
+// INSERT ... ON CONFLICT. If the merge fails outside a transaction, the rows
+// are retried one at a time so a single bad row does not sink the batch;
+// inside a transaction every row fails with the merge error. The returned
+// slice has one entry per input row, nil for rows that were written.
+func (r *PodcastRepository) UpsertEpisodeMetricsBatch(ctx context.Context, metrics []*scrapers.EpisodeMetrics) []error {
+	rowErrs := make([]error, len(metrics))
+	if len(metrics) == 0 {
+		return rowErrs
+	}
+
+	kept, owner := dedupeEpisodeMetrics(metrics)
+
+	ctx, span := scrapers.Tracer.Start(ctx, "UpsertEpisodeMetricsBatch", trace.WithAttributes(scrapers.AttrItems.Int(len(kept))))
+	defer span.End()
+
+	err := r.InTx(ctx, func(tx Store) error {
+		return tx.(*PodcastRepository).mergeEpisodeMetrics(ctx, kept)
+	})
+	if err == nil {
+		return rowErrs
+	}
+
+	if r.conn == nil || ctx.Err() != nil {
+		for i := range rowErrs {
+			rowErrs[i] = err
+		}
+		return rowErrs
+	}
+
+	slog.WarnContext(ctx, "Batched write of episode metrics failed, retrying row by row", "rows", len(kept), logging.KeyError, err)
+
+	keptErrs := make([]error, len(kept))
+	for i, m := range kept {
+		keptErrs[i] = r.UpsertEpisodeMetrics(ctx, m)
+	}
+	for i := range metrics {
+		rowErrs[i] = keptErrs[owner[i]]
+	}
+
+	return rowErrs
+}
+
+// mergeEpisodeMetrics stages rows with COPY and merges them; it must run
+// inside a transaction
+func (r *PodcastRepository) mergeEpisodeMetrics(ctx context.Context, metrics []*scrapers.EpisodeMetrics) error {
+	tx := r.db
+
+	_, err := tx.ExecContext(ctx, `
+		CREATE TEMP TABLE podcast_episode_metrics_staging ON COMMIT DROP AS
+		SELECT `+strings.Join(episodeMetricsStagingColumns, ", ")+`
+		FROM raw.podcast_episode_metrics
+		WITH NO DATA
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create staging table: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("podcast_episode_metrics_staging", episodeMetricsStagingColumns...))
+	if err != nil {
+		return fmt.Errorf("failed to start copy: %w", err)
+	}
+
+	now := time.Now()
+	for _, m := range metrics {
+		topCountriesJSON, _ := json.Marshal(m.TopCountries)
+		topCitiesJSON, _ := json.Marshal(m.TopCities)
+		deviceBreakdownJSON, _ := json.Marshal(m.DeviceBreakdown)
+		rawDataJSON, _ := json.Marshal(m.RawData)
+
+		_, err := stmt.ExecContext(ctx,
+			m.EpisodeID,
+			m.MetricDate,
+			m.Plays,
+			m.Listeners,
+			m.EngagedListeners,
+			m.Views,
+			m.Likes,
+			m.Dislikes,
+			m.CommentsCount,
+			m.Shares,
+			m.WatchTimeMinutes,
+			m.AverageViewDuration,
+			m.SubscribersGained,
+			m.SubscribersLost,
+			m.Downloads,
+			m.Streams,
+			m.CompletionRate,
+			m.AverageListenTime,
+			m.FollowersTotal,
+			m.FollowersGained,
+			m.FollowersLost,
+			string(topCountriesJSON),
+			string(topCitiesJSON),
+			string(deviceBreakdownJSON),
+			string(rawDataJSON),
+			now,
+			nullRunID(m.RunID),
+		)
+		if err != nil {
+			stmt.Close()
+			return fmt.Errorf("failed to copy episode metrics: %w", err)
+		}
+	}
+
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return fmt.Errorf("failed to flush copy: %w", err)
+	}
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("failed to close copy: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO `+r.episodeMetricsTable+` (`+strings.Join(episodeMetricsStagingColumns, ", ")+`)
+		SELECT `+strings.Join(episodeMetricsStagingColumns, ", ")+`
+		FROM podcast_episode_metrics_staging
+		ON CONFLICT (episode_id, metric_date)
+		DO UPDATE SET
+			plays = EXCLUDED.plays,
+			listeners = EXCLUDED.listeners,
+			engaged_listeners = EXCLUDED.engaged_listeners,
+			views = EXCLUDED.views,
+			likes = EXCLUDED.likes,
+			dislikes = EXCLUDED.dislikes,
+			comments_count = EXCLUDED.comments_count,
+			shares = EXCLUDED.shares,
+			watch_time_minutes = EXCLUDED.watch_time_minutes,
+			average_view_duration_seconds = EXCLUDED.average_view_duration_seconds,
+			subscribers_gained = EXCLUDED.subscribers_gained,
+			subscribers_lost = EXCLUDED.subscribers_lost,
+			downloads = EXCLUDED.downloads,
+			streams = EXCLUDED.streams,
+			completion_rate = EXCLUDED.completion_rate,
+			average_listen_time_seconds = EXCLUDED.average_listen_time_seconds,
+			followers_total = EXCLUDED.followers_total,
+			followers_gained = EXCLUDED.followers_gained,
+			followers_lost = EXCLUDED.followers_lost,
+			top_countries = EXCLUDED.top_countries,
+			top_cities = EXCLUDED.top_cities,
+			device_breakdown = EXCLUDED.device_breakdown,
+			raw_data = EXCLUDED.raw_data,
+			updated_at = EXCLUDED.updated_at,
+			run_id = EXCLUDED.run_id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to merge episode metrics: %w", err)
+	}
+
+	// Drop now rather than at commit so a transaction can merge more than once
+	if _, err := tx.ExecContext(ctx, `DROP TABLE podcast_episode_metrics_staging`); err != nil {
+		return fmt.Errorf("failed to drop staging table: %w", err)
+	}
+
+	return nil
+}
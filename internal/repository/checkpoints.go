@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// BackfillCheckpoint records the outcome of one backfill window for an
+// episode, or for the show when EpisodeID is 0
+type BackfillCheckpoint struct {
+	Platform         scrapers.Platform
+	PodcastID        int64
+	EpisodeID        int64
+	WindowStart      time.Time
+	WindowEnd        time.Time
+	Status           string
+	MetricsCollected int
+	ErrorMessage     *string
+	RunID            int64
+}
+
+// CheckpointKey identifies a backfill window independently of its outcome
+type CheckpointKey struct {
+	EpisodeID   int64
+	WindowStart string
+	WindowEnd   string
+}
+
+// Key returns the checkpoint's lookup key
+func (c *BackfillCheckpoint) Key() CheckpointKey {
+	return CheckpointKey{
+		EpisodeID:   c.EpisodeID,
+		WindowStart: c.WindowStart.Format("2006-01-02"),
+		WindowEnd:   c.WindowEnd.Format("2006-01-02"),
+	}
+}
+
+// CompletedCheckpoints returns the windows already backfilled for a podcast
+// within the given date range
+func (r *PodcastRepository) CompletedCheckpoints(ctx context.Context, platform scrapers.Platform, podcastID int64, startDate, endDate time.Time) (map[CheckpointKey]bool, error) {
+	query := `
+		SELECT episode_id, window_start, window_end
+		FROM raw.podcast_backfill_checkpoints
+		WHERE platform = $1
+		  AND podcast_id = $2
+		  AND status = 'completed'
+		  AND window_start >= $3
+		  AND window_end <= $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, platform, podcastID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load backfill checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	done := make(map[CheckpointKey]bool)
+	for rows.Next() {
+		cp := &BackfillCheckpoint{}
+		if err := rows.Scan(&cp.EpisodeID, &cp.WindowStart, &cp.WindowEnd); err != nil {
+			return nil, fmt.Errorf("failed to scan backfill checkpoint: %w", err)
+		}
+		done[cp.Key()] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load backfill checkpoints: %w", err)
+	}
+
+	return done, nil
+}
+
+// SaveCheckpoint inserts or updates a backfill checkpoint
+func (r *PodcastRepository) SaveCheckpoint(ctx context.Context, cp *BackfillCheckpoint) error {
+	query := `
+		INSERT INTO raw.podcast_backfill_checkpoints (
+			platform, podcast_id, episode_id, window_start, window_end,
+			status, metrics_collected, error_message, run_id, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (platform, podcast_id, episode_id, window_start, window_end)
+		DO UPDATE SET
+			status = EXCLUDED.status,
+			metrics_collected = EXCLUDED.metrics_collected,
+			error_message = EXCLUDED.error_message,
+			run_id = EXCLUDED.run_id,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		cp.Platform,
+		cp.PodcastID,
+		cp.EpisodeID,
+		cp.WindowStart,
+		cp.WindowEnd,
+		cp.Status,
+		cp.MetricsCollected,
+		cp.ErrorMessage,
+		cp.RunID,
+		time.Now(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to save backfill checkpoint: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// RecordScraperError adds a per-item failure to the run's error ledger
+func (r *PodcastRepository) RecordScraperError(ctx context.Context, e *scrapers.ScraperError) error {
+	query := `
+		INSERT INTO raw.podcast_scraper_errors (
+			run_id, platform, episode_id, episode_title, stage,
+			error_class, http_status, message, occurred_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		e.RunID,
+		e.Platform,
+		e.EpisodeID,
+		e.EpisodeTitle,
+		e.Stage,
+		e.ErrorClass,
+		e.HTTPStatus,
+		e.Message,
+		e.OccurredAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to record scraper error: %w", err)
+	}
+
+	return nil
+}
+
+// GetScraperRun returns a single scraper run, or ErrNotFound
+func (r *PodcastRepository) GetScraperRun(ctx context.Context, runID int64) (*scrapers.ScraperRun, error) {
+	query := `
+		SELECT r.id, r.platform, r.run_started_at, r.run_completed_at, r.status,
+		       r.episodes_processed, r.metrics_collected, r.error_message,
+		       (SELECT COUNT(*) FROM raw.podcast_schema_drift d WHERE d.run_id = r.id)
+		FROM raw.podcast_scraper_runs r
+		WHERE r.id = $1
+	`
+
+	run := &scrapers.ScraperRun{}
+	err := r.db.QueryRowContext(ctx, query, runID).Scan(
+		&run.ID,
+		&run.Platform,
+		&run.RunStartedAt,
+		&run.RunCompletedAt,
+		&run.Status,
+		&run.EpisodesProcessed,
+		&run.MetricsCollected,
+		&run.ErrorMessage,
+		&run.SchemaDrift,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scraper run: %w", err)
+	}
+
+	return run, nil
+}
+
+// ListScraperErrors returns the error ledger of a run in the order recorded
+func (r *PodcastRepository) ListScraperErrors(ctx context.Context, runID int64) ([]*scrapers.ScraperError, error) {
+	query := `
+		SELECT id, run_id, platform, episode_id, COALESCE(episode_title, ''), stage,
+		       error_class, http_status, message, occurred_at
+		FROM raw.podcast_scraper_errors
+		WHERE run_id = $1
+		ORDER BY occurred_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scraper errors: %w", err)
+	}
+	defer rows.Close()
+
+	var ledger []*scrapers.ScraperError
+	for rows.Next() {
+		e := &scrapers.ScraperError{}
+		var episodeID sql.NullInt64
+		var httpStatus sql.NullInt32
+		if err := rows.Scan(
+			&e.ID,
+			&e.RunID,
+			&e.Platform,
+			&episodeID,
+			&e.EpisodeTitle,
+			&e.Stage,
+			&e.ErrorClass,
+			&httpStatus,
+			&e.Message,
+			&e.OccurredAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan scraper error: %w", err)
+		}
+		if episodeID.Valid {
+			e.EpisodeID = &episodeID.Int64
+		}
+		if httpStatus.Valid {
+			status := int(httpStatus.Int32)
+			e.HTTPStatus = &status
+		}
+		ledger = append(ledger, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list scraper errors: %w", err)
+	}
+
+	return ledger, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// HeartbeatScraperRun marks a running run as alive and saves its progress so
+// far. Runs that already finished, or were abandoned, are left alone.
+func (r *PodcastRepository) HeartbeatScraperRun(ctx context.Context, runID int64, episodesProcessed, metricsCollected int) error {
+	query := `
+		UPDATE raw.podcast_scraper_runs
+		SET heartbeat_at = NOW(),
+		    episodes_processed = $1,
+		    metrics_collected = $2
+		WHERE id = $3 AND status = $4
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, episodesProcessed, metricsCollected, runID, scrapers.RunStatusRunning); err != nil {
+		return fmt.Errorf("failed to update run heartbeat: %w", err)
+	}
+	return nil
+}
+
+// AbandonStaleRuns marks running runs without a heartbeat for staleAfter as
+// abandoned, completed at their last heartbeat with the progress it saved,
+// and returns them. Runs from before heartbeats existed are judged by when
+// they started. Ages are measured on the database clock.
+func (r *PodcastRepository) AbandonStaleRuns(ctx context.Context, staleAfter time.Duration) ([]*scrapers.ScraperRun, error) {
+	query := `
+		UPDATE raw.podcast_scraper_runs
+		SET status = $1,
+		    run_completed_at = COALESCE(heartbeat_at, run_started_at),
+		    error_message = 'the collecting process stopped sending heartbeats'
+		WHERE status = $2
+		  AND COALESCE(heartbeat_at, run_started_at) < NOW() - make_interval(secs => $3)
+		RETURNING id, platform, run_started_at, run_completed_at, status,
+		          episodes_processed, metrics_collected, error_message
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, scrapers.RunStatusAbandoned, scrapers.RunStatusRunning, staleAfter.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to abandon stale runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*scrapers.ScraperRun
+	for rows.Next() {
+		run := &scrapers.ScraperRun{}
+		if err := rows.Scan(
+			&run.ID,
+			&run.Platform,
+			&run.RunStartedAt,
+			&run.RunCompletedAt,
+			&run.Status,
+			&run.EpisodesProcessed,
+			&run.MetricsCollected,
+			&run.ErrorMessage,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan abandoned run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to abandon stale runs: %w", err)
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+func TestAbandonStaleRuns(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+
+	runID, err := repo.RecordScraperRun(ctx, &scrapers.ScraperRun{
+		Platform:     scrapers.PlatformSpotify,
+		RunStartedAt: time.Now(),
+		Status:       scrapers.RunStatusRunning,
+	})
+	if err != nil {
+		t.Fatalf("RecordScraperRun() error = %v", err)
+	}
+	if err := repo.HeartbeatScraperRun(ctx, runID, 3, 12); err != nil {
+		t.Fatalf("HeartbeatScraperRun() error = %v", err)
+	}
+
+	runs, err := repo.AbandonStaleRuns(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("AbandonStaleRuns() error = %v", err)
+	}
+	if len(runs) != 0 {
+		t.Fatalf("AbandonStaleRuns() reaped %d runs with a fresh heartbeat, want 0", len(runs))
+	}
+
+	if _, err := repo.db.ExecContext(ctx, `UPDATE raw.podcast_scraper_runs SET heartbeat_at = NOW() - INTERVAL '2 hours' WHERE id = $1`, runID); err != nil {
+		t.Fatalf("failed to age heartbeat: %v", err)
+	}
+
+	runs, err = repo.AbandonStaleRuns(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("AbandonStaleRuns() error = %v", err)
+	}
+	if len(runs) != 1 || runs[0].ID != runID {
+		t.Fatalf("AbandonStaleRuns() = %v, want run %d", runs, runID)
+	}
+	run := runs[0]
+	if run.Status != scrapers.RunStatusAbandoned || run.EpisodesProcessed != 3 || run.MetricsCollected != 12 {
+		t.Errorf("abandoned run = %+v, want status abandoned with the heartbeat's progress", run)
+	}
+
+	// A late heartbeat from the dead process does not revive the run
+	if err := repo.HeartbeatScraperRun(ctx, runID, 4, 20); err != nil {
+		t.Fatalf("HeartbeatScraperRun() error = %v", err)
+	}
+	if got := countRows(t, repo, `SELECT COUNT(*) FROM raw.podcast_scraper_runs WHERE status = 'abandoned' AND metrics_collected = 12`); got != 1 {
+		t.Errorf("abandoned runs keeping their progress = %d, want 1", got)
+	}
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// ErrLockHeld is returned by TryLockRun when another session holds the lock
+var ErrLockHeld = errors.New("run lock is held by another process")
+
+// RunLock is a session-level advisory lock on collecting a show from a
+// platform. It is held for as long as its connection stays open, so it is
+// released if the process dies.
+type RunLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// runLockKey maps a show and platform to an advisory lock key
+func runLockKey(showName string, platform scrapers.Platform) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "podcast-scraper/%s/%s", platform, showName)
+	return int64(h.Sum64())
+}
+
+// TryLockRun takes the advisory lock for collecting showName from platform
+// without waiting, returning ErrLockHeld when another session holds it
+func (r *PodcastRepository) TryLockRun(ctx context.Context, showName string, platform scrapers.Platform) (*RunLock, error) {
+	if r.conn == nil {
+		return nil, fmt.Errorf("run locks cannot be taken inside a transaction")
+	}
+
+	// The lock belongs to the session, so it needs a connection of its own
+	conn, err := r.conn.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection for run lock: %w", err)
+	}
+
+	key := runLockKey(showName, platform)
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to take run lock: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrLockHeld
+	}
+
+	return &RunLock{conn: conn, key: key}, nil
+}
+
+// Release releases the lock and returns its connection to the pool. If the
+// lock cannot be released the connection is discarded instead, which ends
+// the session and so releases the lock with it.
+func (l *RunLock) Release(ctx context.Context) error {
+	defer l.conn.Close()
+
+	var unlocked bool
+	if err := l.conn.QueryRowContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key).Scan(&unlocked); err != nil {
+		l.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		return fmt.Errorf("failed to release run lock: %w", err)
+	}
+	if !unlocked {
+		return fmt.Errorf("run lock was not held")
+	}
+	return nil
+}
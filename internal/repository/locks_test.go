@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+func TestTryLockRun(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+
+	lock, err := repo.TryLockRun(ctx, "Test Show", scrapers.PlatformSpotify)
+	if err != nil {
+		t.Fatalf("TryLockRun() error = %v", err)
+	}
+
+	// Each lock holds its own session, so a second one is refused
+	if _, err := repo.TryLockRun(ctx, "Test Show", scrapers.PlatformSpotify); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("second TryLockRun() error = %v, want ErrLockHeld", err)
+	}
+
+	other, err := repo.TryLockRun(ctx, "Test Show", scrapers.PlatformYouTube)
+	if err != nil {
+		t.Fatalf("TryLockRun() of another platform error = %v", err)
+	}
+	if err := other.Release(ctx); err != nil {
+		t.Errorf("Release() error = %v", err)
+	}
+
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	lock, err = repo.TryLockRun(ctx, "Test Show", scrapers.PlatformSpotify)
+	if err != nil {
+		t.Fatalf("TryLockRun() after release error = %v", err)
+	}
+	lock.Release(ctx)
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// GetPodcast returns the stored podcast for a platform ID, or ErrNotFound
+func (r *PodcastRepository) GetPodcast(ctx context.Context, platform scrapers.Platform, platformID string) (*scrapers.Podcast, error) {
+	query := `
+		SELECT id, show_name, platform, COALESCE(platform_id, ''), COALESCE(description, ''),
+		       COALESCE(author, ''), categories, COALESCE(language, '')
+		FROM raw.podcasts
+		WHERE platform = $1 AND platform_id = $2
+	`
+
+	podcast := &scrapers.Podcast{}
+	var categoriesJSON []byte
+	err := r.db.QueryRowContext(ctx, query, platform, platformID).Scan(
+		&podcast.ID,
+		&podcast.ShowName,
+		&podcast.Platform,
+		&podcast.PlatformID,
+		&podcast.Description,
+		&podcast.Author,
+		&categoriesJSON,
+		&podcast.Language,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get podcast: %w", err)
+	}
+
+	if err := unmarshalJSONB(categoriesJSON, &podcast.Categories); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal categories: %w", err)
+	}
+
+	return podcast, nil
+}
+
+// GetEpisode returns the stored episode for a platform episode ID, or ErrNotFound
+func (r *PodcastRepository) GetEpisode(ctx context.Context, podcastID int64, platformEpisodeID string) (*scrapers.Episode, error) {
+	query := `
+		SELECT id, podcast_id, episode_title, COALESCE(platform_episode_id, ''),
+		       COALESCE(description, ''), COALESCE(duration_seconds, 0),
+		       publish_date, season_number, episode_number
+		FROM raw.podcast_episodes
+		WHERE podcast_id = $1 AND platform_episode_id = $2
+	`
+
+	episode := &scrapers.Episode{}
+	var publishDate sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, podcastID, platformEpisodeID).Scan(
+		&episode.ID,
+		&episode.PodcastID,
+		&episode.EpisodeTitle,
+		&episode.PlatformEpisodeID,
+		&episode.Description,
+		&episode.DurationSeconds,
+		&publishDate,
+		&episode.SeasonNumber,
+		&episode.EpisodeNumber,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get episode: %w", err)
+	}
+
+	episode.PublishDate = publishDate.Time
+
+	return episode, nil
+}
+
+// GetEpisodeMetrics returns the stored metrics for an episode and date, or ErrNotFound
+func (r *PodcastRepository) GetEpisodeMetrics(ctx context.Context, episodeID int64, metricDate time.Time) (*scrapers.EpisodeMetrics, error) {
+	query := `
+		SELECT episode_id, metric_date, COALESCE(plays, 0), COALESCE(listeners, 0),
+		       COALESCE(engaged_listeners, 0), COALESCE(views, 0), COALESCE(likes, 0),
+		       COALESCE(dislikes, 0), COALESCE(comments_count, 0), COALESCE(shares, 0),
+		       COALESCE(watch_time_minutes, 0), COALESCE(average_view_duration_seconds, 0),
+		       COALESCE(subscribers_gained, 0), COALESCE(subscribers_lost, 0),
+		       COALESCE(downloads, 0), COALESCE(streams, 0), completion_rate,
+		       average_listen_time_seconds, followers_total,
+		       COALESCE(followers_gained, 0), COALESCE(followers_lost, 0),
+		       top_countries, top_cities, device_breakdown, raw_data
+		FROM raw.podcast_episode_metrics
+		WHERE episode_id = $1 AND metric_date = $2::date
+	`
+
+	// Columns only some platforms report stay NULL and scan into nil pointers
+	m := &scrapers.EpisodeMetrics{}
+	var topCountriesJSON, topCitiesJSON, deviceBreakdownJSON, rawDataJSON []byte
+	err := r.db.QueryRowContext(ctx, query, episodeID, metricDate).Scan(
+		&m.EpisodeID,
+		&m.MetricDate,
+		&m.Plays,
+		&m.Listeners,
+		&m.EngagedListeners,
+		&m.Views,
+		&m.Likes,
+		&m.Dislikes,
+		&m.CommentsCount,
+		&m.Shares,
+		&m.WatchTimeMinutes,
+		&m.AverageViewDuration,
+		&m.SubscribersGained,
+		&m.SubscribersLost,
+		&m.Downloads,
+		&m.Streams,
+		&m.CompletionRate,
+		&m.AverageListenTime,
+		&m.FollowersTotal,
+		&m.FollowersGained,
+		&m.FollowersLost,
+		&topCountriesJSON,
+		&topCitiesJSON,
+		&deviceBreakdownJSON,
+		&rawDataJSON,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get episode metrics: %w", err)
+	}
+
+	for _, col := range []struct {
+		data []byte
+		dst  *map[string]interface{}
+	}{
+		{topCountriesJSON, &m.TopCountries},
+		{topCitiesJSON, &m.TopCities},
+		{deviceBreakdownJSON, &m.DeviceBreakdown},
+		{rawDataJSON, &m.RawData},
+	} {
+		if err := unmarshalJSONB(col.data, col.dst); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal episode metrics: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// GetShowMetrics returns the stored show metrics for a podcast and date, or ErrNotFound
+func (r *PodcastRepository) GetShowMetrics(ctx context.Context, podcastID int64, metricDate time.Time) (*scrapers.ShowMetrics, error) {
+	query := `
+		SELECT podcast_id, metric_date, COALESCE(total_plays, 0), COALESCE(total_listeners, 0),
+		       COALESCE(total_engaged_listeners, 0), COALESCE(total_views, 0),
+		       COALESCE(total_downloads, 0), followers_total, COALESCE(followers_gained, 0),
+		       COALESCE(followers_lost, 0), subscribers_total, COALESCE(subscribers_gained, 0),
+		       COALESCE(subscribers_lost, 0), average_completion_rate, COALESCE(total_comments, 0),
+		       COALESCE(total_likes, 0), COALESCE(total_shares, 0), top_countries, top_cities, raw_data
+		FROM raw.podcast_show_metrics
+		WHERE podcast_id = $1 AND metric_date = $2::date
+	`
+
+	m := &scrapers.ShowMetrics{}
+	var topCountriesJSON, topCitiesJSON, rawDataJSON []byte
+	err := r.db.QueryRowContext(ctx, query, podcastID, metricDate).Scan(
+		&m.PodcastID,
+		&m.MetricDate,
+		&m.TotalPlays,
+		&m.TotalListeners,
+		&m.TotalEngagedListeners,
+		&m.TotalViews,
+		&m.TotalDownloads,
+		&m.FollowersTotal,
+		&m.FollowersGained,
+		&m.FollowersLost,
+		&m.SubscribersTotal,
+		&m.SubscribersGained,
+		&m.SubscribersLost,
+		&m.AverageCompletionRate,
+		&m.TotalComments,
+		&m.TotalLikes,
+		&m.TotalShares,
+		&topCountriesJSON,
+		&topCitiesJSON,
+		&rawDataJSON,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get show metrics: %w", err)
+	}
+
+	for _, col := range []struct {
+		data []byte
+		dst  *map[string]interface{}
+	}{
+		{topCountriesJSON, &m.TopCountries},
+		{topCitiesJSON, &m.TopCities},
+		{rawDataJSON, &m.RawData},
+	} {
+		if err := unmarshalJSONB(col.data, col.dst); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal show metrics: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// CommentExists reports whether a comment has already been stored
+func (r *PodcastRepository) CommentExists(ctx context.Context, episodeID int64, platformCommentID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM raw.podcast_comments
+			WHERE episode_id = $1 AND platform_comment_id = $2
+		)
+	`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, episodeID, platformCommentID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check comment: %w", err)
+	}
+
+	return exists, nil
+}
+
+// unmarshalJSONB decodes a nullable JSONB column into a map
+func unmarshalJSONB(data []byte, dst *map[string]interface{}) error {
+	if len(data) == 0 || string(data) == "null" {
+		*dst = nil
+		return nil
+	}
+	return json.Unmarshal(data, dst)
+}
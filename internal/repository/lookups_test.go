@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetEpisodeMetricsNullColumns(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+	_, episodeID := createTestEpisode(t, repo)
+
+	_, err := repo.db.ExecContext(ctx, `
+		INSERT INTO raw.podcast_episode_metrics (episode_id, metric_date, plays, views, completion_rate)
+		VALUES ($1, '2026-01-01', 12, NULL, NULL)
+	`, episodeID)
+	if err != nil {
+		t.Fatalf("failed to insert metrics: %v", err)
+	}
+
+	m, err := repo.GetEpisodeMetrics(ctx, episodeID, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetEpisodeMetrics() error = %v", err)
+	}
+	if m.Plays != 12 || m.Views != 0 || m.CompletionRate != nil {
+		t.Errorf("metrics = plays %d, views %d, completion rate %v, want 12, 0, nil", m.Plays, m.Views, m.CompletionRate)
+	}
+
+	_, err = repo.GetEpisodeMetrics(ctx, episodeID, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC))
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetEpisodeMetrics() of a missing day error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetShowMetricsNullColumns(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+	podcastID, _ := createTestEpisode(t, repo)
+
+	_, err := repo.db.ExecContext(ctx, `
+		INSERT INTO raw.podcast_show_metrics (podcast_id, metric_date, total_plays, total_views, followers_total)
+		VALUES ($1, '2026-01-01', 30, NULL, NULL)
+	`, podcastID)
+	if err != nil {
+		t.Fatalf("failed to insert show metrics: %v", err)
+	}
+
+	m, err := repo.GetShowMetrics(ctx, podcastID, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetShowMetrics() error = %v", err)
+	}
+	if m.TotalPlays != 30 || m.TotalViews != 0 || m.FollowersTotal != nil {
+		t.Errorf("show metrics = plays %d, views %d, followers %v, want 30, 0, nil", m.TotalPlays, m.TotalViews, m.FollowersTotal)
+	}
+}
@@ -0,0 +1,346 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// MemoryStore is an in-memory Store for unit tests. It applies the same
+// uniqueness rules as the database so upserts behave like the real thing.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	nextID int64
+
+	podcasts       map[int64]*scrapers.Podcast
+	podcastKeys    map[string]int64
+	episodes       map[int64]*scrapers.Episode
+	episodeKeys    map[string]int64
+	episodeMetrics map[string]*scrapers.EpisodeMetrics
+	showMetrics    map[string]*scrapers.ShowMetrics
+	comments       map[string]*scrapers.Comment
+	runs           map[int64]*scrapers.ScraperRun
+	errors         []*scrapers.ScraperError
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		podcasts:       make(map[int64]*scrapers.Podcast),
+		podcastKeys:    make(map[string]int64),
+		episodes:       make(map[int64]*scrapers.Episode),
+		episodeKeys:    make(map[string]int64),
+		episodeMetrics: make(map[string]*scrapers.EpisodeMetrics),
+		showMetrics:    make(map[string]*scrapers.ShowMetrics),
+		comments:       make(map[string]*scrapers.Comment),
+		runs:           make(map[int64]*scrapers.ScraperRun),
+	}
+}
+
+// InTx runs fn against the store and restores the previous contents if fn
+// returns an error. It does not isolate fn from concurrent writers.
+func (s *MemoryStore) InTx(ctx context.Context, fn func(tx Store) error) error {
+	s.mu.Lock()
+	snapshot := s.clone()
+	s.mu.Unlock()
+
+	if err := fn(s); err != nil {
+		s.mu.Lock()
+		s.nextID = snapshot.nextID
+		s.podcasts, s.podcastKeys = snapshot.podcasts, snapshot.podcastKeys
+		s.episodes, s.episodeKeys = snapshot.episodes, snapshot.episodeKeys
+		s.episodeMetrics, s.showMetrics = snapshot.episodeMetrics, snapshot.showMetrics
+		s.comments, s.runs = snapshot.comments, snapshot.runs
+		s.errors = snapshot.errors
+		s.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// clone copies the maps; stored values are never mutated in place except
+// runs, which are copied too
+func (s *MemoryStore) clone() *MemoryStore {
+	c := NewMemoryStore()
+	c.nextID = s.nextID
+	for k, v := range s.podcasts {
+		c.podcasts[k] = v
+	}
+	for k, v := range s.podcastKeys {
+		c.podcastKeys[k] = v
+	}
+	for k, v := range s.episodes {
+		c.episodes[k] = v
+	}
+	for k, v := range s.episodeKeys {
+		c.episodeKeys[k] = v
+	}
+	for k, v := range s.episodeMetrics {
+		c.episodeMetrics[k] = v
+	}
+	for k, v := range s.showMetrics {
+		c.showMetrics[k] = v
+	}
+	for k, v := range s.comments {
+		c.comments[k] = v
+	}
+	for k, v := range s.runs {
+		run := *v
+		c.runs[k] = &run
+	}
+	c.errors = append(c.errors, s.errors...)
+	return c
+}
+
+// LookupEpisodeID returns the ID of an existing episode, or ErrNotFound
+func (s *MemoryStore) LookupEpisodeID(ctx context.Context, podcastID int64, platformEpisodeID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.episodeKeys[fmt.Sprintf("%d/%s", podcastID, platformEpisodeID)]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return id, nil
+}
+
+func (s *MemoryStore) id() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+// UpsertPodcast inserts or updates a podcast
+func (s *MemoryStore) UpsertPodcast(ctx context.Context, podcast *scrapers.Podcast) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := fmt.Sprintf("%s/%s", podcast.Platform, podcast.PlatformID)
+	id, ok := s.podcastKeys[key]
+	if !ok {
+		id = s.id()
+		s.podcastKeys[key] = id
+	}
+
+	stored := *podcast
+	stored.ID = id
+	s.podcasts[id] = &stored
+	return id, nil
+}
+
+// UpsertEpisode inserts or updates an episode
+func (s *MemoryStore) UpsertEpisode(ctx context.Context, episode *scrapers.Episode) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.podcasts[episode.PodcastID]; !ok {
+		return 0, fmt.Errorf("failed to upsert episode: podcast %d does not exist", episode.PodcastID)
+	}
+
+	key := fmt.Sprintf("%d/%s", episode.PodcastID, episode.PlatformEpisodeID)
+	id, ok := s.episodeKeys[key]
+	if !ok {
+		id = s.id()
+		s.episodeKeys[key] = id
+	}
+
+	stored := *episode
+	stored.ID = id
+	s.episodes[id] = &stored
+	return id, nil
+}
+
+// UpsertEpisodeMetrics inserts or updates episode metrics
+func (s *MemoryStore) UpsertEpisodeMetrics(ctx context.Context, metrics *scrapers.EpisodeMetrics) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.episodes[metrics.EpisodeID]; !ok {
+		return fmt.Errorf("failed to upsert episode metrics: episode %d does not exist", metrics.EpisodeID)
+	}
+
+	stored := *metrics
+	s.episodeMetrics[fmt.Sprintf("%d/%s", metrics.EpisodeID, metrics.MetricDate.Format("2006-01-02"))] = &stored
+	return nil
+}
+
+// UpsertEpisodeMetricsBatch upserts each row in turn
+func (s *MemoryStore) UpsertEpisodeMetricsBatch(ctx context.Context, metrics []*scrapers.EpisodeMetrics) []error {
+	rowErrs := make([]error, len(metrics))
+	for i, m := range metrics {
+		rowErrs[i] = s.UpsertEpisodeMetrics(ctx, m)
+	}
+	return rowErrs
+}
+
+// UpsertShowMetrics inserts or updates show-level metrics
+func (s *MemoryStore) UpsertShowMetrics(ctx context.Context, metrics *scrapers.ShowMetrics) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.podcasts[metrics.PodcastID]; !ok {
+		return fmt.Errorf("failed to upsert show metrics: podcast %d does not exist", metrics.PodcastID)
+	}
+
+	stored := *metrics
+	s.showMetrics[fmt.Sprintf("%d/%s", metrics.PodcastID, metrics.MetricDate.Format("2006-01-02"))] = &stored
+	return nil
+}
+
+// InsertComment inserts a comment, ignoring duplicates
+func (s *MemoryStore) InsertComment(ctx context.Context, comment *scrapers.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.episodes[comment.EpisodeID]; !ok {
+		return fmt.Errorf("failed to insert comment: episode %d does not exist", comment.EpisodeID)
+	}
+
+	key := fmt.Sprintf("%d/%s", comment.EpisodeID, comment.PlatformCommentID)
+	if _, ok := s.comments[key]; ok {
+		return nil
+	}
+
+	stored := *comment
+	s.comments[key] = &stored
+	return nil
+}
+
+// RecordScraperRun records a scraper run
+func (s *MemoryStore) RecordScraperRun(ctx context.Context, run *scrapers.ScraperRun) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.id()
+	stored := *run
+	stored.ID = id
+	s.runs[id] = &stored
+	return id, nil
+}
+
+// UpdateScraperRun updates a scraper run status
+func (s *MemoryStore) UpdateScraperRun(ctx context.Context, runID int64, run *scrapers.ScraperRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.runs[runID]
+	if !ok {
+		return fmt.Errorf("failed to update scraper run: run %d does not exist", runID)
+	}
+
+	stored.RunCompletedAt = run.RunCompletedAt
+	stored.Status = run.Status
+	stored.EpisodesProcessed = run.EpisodesProcessed
+	stored.MetricsCollected = run.MetricsCollected
+	stored.ErrorMessage = run.ErrorMessage
+	return nil
+}
+
+// RecordScraperError adds a per-item failure to the run's error ledger
+func (s *MemoryStore) RecordScraperError(ctx context.Context, e *scrapers.ScraperError) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.runs[e.RunID]; !ok {
+		return fmt.Errorf("failed to record scraper error: run %d does not exist", e.RunID)
+	}
+
+	stored := *e
+	stored.ID = s.id()
+	s.errors = append(s.errors, &stored)
+	return nil
+}
+
+// Podcasts returns a copy of every stored podcast
+func (s *MemoryStore) Podcasts() []*scrapers.Podcast {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*scrapers.Podcast, 0, len(s.podcasts))
+	for _, p := range s.podcasts {
+		c := *p
+		out = append(out, &c)
+	}
+	return out
+}
+
+// Episodes returns a copy of every stored episode
+func (s *MemoryStore) Episodes() []*scrapers.Episode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*scrapers.Episode, 0, len(s.episodes))
+	for _, e := range s.episodes {
+		c := *e
+		out = append(out, &c)
+	}
+	return out
+}
+
+// EpisodeMetrics returns a copy of every stored episode metrics row
+func (s *MemoryStore) EpisodeMetrics() []*scrapers.EpisodeMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*scrapers.EpisodeMetrics, 0, len(s.episodeMetrics))
+	for _, m := range s.episodeMetrics {
+		c := *m
+		out = append(out, &c)
+	}
+	return out
+}
+
+// ShowMetrics returns a copy of every stored show metrics row
+func (s *MemoryStore) ShowMetrics() []*scrapers.ShowMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*scrapers.ShowMetrics, 0, len(s.showMetrics))
+	for _, m := range s.showMetrics {
+		c := *m
+		out = append(out, &c)
+	}
+	return out
+}
+
+// Comments returns a copy of every stored comment
+func (s *MemoryStore) Comments() []*scrapers.Comment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*scrapers.Comment, 0, len(s.comments))
+	for _, c := range s.comments {
+		cc := *c
+		out = append(out, &cc)
+	}
+	return out
+}
+
+// Runs returns a copy of every recorded scraper run
+func (s *MemoryStore) Runs() []*scrapers.ScraperRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*scrapers.ScraperRun, 0, len(s.runs))
+	for _, r := range s.runs {
+		c := *r
+		out = append(out, &c)
+	}
+	return out
+}
+
+// Errors returns a copy of every recorded scraper error
+func (s *MemoryStore) Errors() []*scrapers.ScraperError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*scrapers.ScraperError, 0, len(s.errors))
+	for _, e := range s.errors {
+		c := *e
+		out = append(out, &c)
+	}
+	return out
+}
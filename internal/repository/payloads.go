@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// StoredPayload is a response kept in the raw_data of metric rows, from
+// before responses were archived, with the range of rows that carry it
+type StoredPayload struct {
+	Platform          scrapers.Platform
+	PodcastID         int64
+	EpisodeID         int64 // 0 for show metrics
+	PlatformEpisodeID string
+
+	// FirstDate and LastDate bound the metric dates of the rows carrying the payload
+	FirstDate time.Time
+	LastDate  time.Time
+
+	RunID   int64 // Latest run that wrote one of the rows, 0 when unknown
+	RawData []byte
+}
+
+// ListEpisodeMetricsPayloads returns the distinct raw_data payloads stored on
+// episode metric rows matching the filter. Rows that only point at an
+// archived response are left out; their payload is in the archive.
+func (r *PodcastRepository) ListEpisodeMetricsPayloads(ctx context.Context, filter MetricsFilter) ([]*StoredPayload, error) {
+	query := `
+		SELECT p.platform, pe.podcast_id, pe.id, COALESCE(pe.platform_episode_id, ''),
+		       MIN(pem.metric_date), MAX(pem.metric_date), COALESCE(MAX(pem.run_id), 0),
+		       pem.raw_data::text
+		FROM raw.podcast_episode_metrics pem
+		JOIN raw.podcast_episodes pe ON pem.episode_id = pe.id
+		JOIN raw.podcasts p ON pe.podcast_id = p.id
+		WHERE pem.raw_data IS NOT NULL
+		  AND pem.raw_data <> '{}'::jsonb
+		  AND NOT pem.raw_data ? 'archive_key'
+		  AND ($1 = '' OR p.show_name = $1)
+		  AND (cardinality($2::text[]) = 0 OR p.platform = ANY($2))
+		  AND ($3::date IS NULL OR pem.metric_date >= $3)
+		  AND ($4::date IS NULL OR pem.metric_date <= $4)
+		GROUP BY p.platform, pe.podcast_id, pe.id, pe.platform_episode_id, pem.raw_data
+		ORDER BY p.platform, pe.id, MIN(pem.metric_date)
+	`
+
+	return r.listPayloads(ctx, query, filter)
+}
+
+// ListShowMetricsPayloads returns the distinct raw_data payloads stored on
+// show metric rows matching the filter, leaving out archived responses
+func (r *PodcastRepository) ListShowMetricsPayloads(ctx context.Context, filter MetricsFilter) ([]*StoredPayload, error) {
+	query := `
+		SELECT p.platform, p.id, 0, '',
+		       MIN(psm.metric_date), MAX(psm.metric_date), 0,
+		       psm.raw_data::text
+		FROM raw.podcast_show_metrics psm
+		JOIN raw.podcasts p ON psm.podcast_id = p.id
+		WHERE psm.raw_data IS NOT NULL
+		  AND psm.raw_data <> '{}'::jsonb
+		  AND NOT psm.raw_data ? 'archive_key'
+		  AND ($1 = '' OR p.show_name = $1)
+		  AND (cardinality($2::text[]) = 0 OR p.platform = ANY($2))
+		  AND ($3::date IS NULL OR psm.metric_date >= $3)
+		  AND ($4::date IS NULL OR psm.metric_date <= $4)
+		GROUP BY p.platform, p.id, psm.raw_data
+		ORDER BY p.platform, p.id, MIN(psm.metric_date)
+	`
+
+	return r.listPayloads(ctx, query, filter)
+}
+
+func (r *PodcastRepository) listPayloads(ctx context.Context, query string, filter MetricsFilter) ([]*StoredPayload, error) {
+	rows, err := r.db.QueryContext(ctx, query,
+		filter.ShowName,
+		pq.Array(platformStrings(filter.Platforms)),
+		nullTime(filter.StartDate),
+		nullTime(filter.EndDate),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored payloads: %w", err)
+	}
+	defer rows.Close()
+
+	var payloads []*StoredPayload
+	for rows.Next() {
+		p := &StoredPayload{}
+		var rawData string
+		if err := rows.Scan(
+			&p.Platform,
+			&p.PodcastID,
+			&p.EpisodeID,
+			&p.PlatformEpisodeID,
+			&p.FirstDate,
+			&p.LastDate,
+			&p.RunID,
+			&rawData,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan stored payload: %w", err)
+		}
+		p.RawData = []byte(rawData)
+		payloads = append(payloads, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list stored payloads: %w", err)
+	}
+
+	return payloads, nil
+}
@@ -4,20 +4,103 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
 )
 
+// ErrNotFound is returned when a lookup matches no rows
+var ErrNotFound = errors.New("not found")
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 // PodcastRepository handles database operations for podcast metrics
 type PodcastRepository struct {
-	db *sql.DB
+	db dbtx
+
+	// conn is the connection pool, nil when the repository is bound to a transaction
+	conn *sql.DB
+
+	// Tables metric and comment writes go to; swapped for staging tables by StageRun
+	episodeMetricsTable string
+	showMetricsTable    string
+	commentsTable       string
 }
 
 // NewPodcastRepository creates a new podcast repository
 func NewPodcastRepository(db *sql.DB) *PodcastRepository {
-	return &PodcastRepository{db: db}
+	return &PodcastRepository{
+		db:                  traceDB(db, systemPostgres),
+		conn:                db,
+		episodeMetricsTable: "raw.podcast_episode_metrics",
+		showMetricsTable:    "raw.podcast_show_metrics",
+		commentsTable:       "raw.podcast_comments",
+	}
+}
+
+// Ping checks the database connection
+func (r *PodcastRepository) Ping(ctx context.Context) error {
+	if r.conn == nil {
+		return nil
+	}
+	return r.conn.PingContext(ctx)
+}
+
+// InTx runs fn with a repository bound to a single transaction, committing if
+// fn returns nil and rolling back otherwise. Inside a transaction fn joins it.
+func (r *PodcastRepository) InTx(ctx context.Context, fn func(tx Store) error) error {
+	if r.conn == nil {
+		return fn(r)
+	}
+
+	sqlTx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	txRepo := *r
+	txRepo.db = traceDB(sqlTx, systemPostgres)
+	txRepo.conn = nil
+
+	if err := fn(&txRepo); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// LookupEpisodeID returns the ID of an existing episode, or ErrNotFound
+func (r *PodcastRepository) LookupEpisodeID(ctx context.Context, podcastID int64, platformEpisodeID string) (int64, error) {
+	query := `
+		SELECT id
+		FROM raw.podcast_episodes
+		WHERE podcast_id = $1 AND platform_episode_id = $2
+	`
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, query, podcastID, platformEpisodeID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up episode: %w", err)
+	}
+
+	return id, nil
 }
 
 // UpsertPodcast inserts or updates a podcast
@@ -108,15 +191,15 @@ func (r *PodcastRepository) UpsertEpisodeMetrics(ctx context.Context, metrics *s
 	rawDataJSON, _ := json.Marshal(metrics.RawData)
 
 	query := `
-		INSERT INTO raw.podcast_episode_metrics (
+		INSERT INTO ` + r.episodeMetricsTable + ` (
 			episode_id, metric_date, plays, listeners, engaged_listeners,
 			views, likes, dislikes, comments_count, shares, watch_time_minutes,
 			average_view_duration_seconds, subscribers_gained, subscribers_lost,
 			downloads, streams, completion_rate, average_listen_time_seconds,
 			followers_total, followers_gained, followers_lost,
-			top_countries, top_cities, device_breakdown, raw_data, updated_at
+			top_countries, top_cities, device_breakdown, raw_data, updated_at, run_id
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27)
 		ON CONFLICT (episode_id, metric_date)
 		DO UPDATE SET
 			plays = EXCLUDED.plays,
@@ -142,7 +225,8 @@ func (r *PodcastRepository) UpsertEpisodeMetrics(ctx context.Context, metrics *s
 			top_cities = EXCLUDED.top_cities,
 			device_breakdown = EXCLUDED.device_breakdown,
 			raw_data = EXCLUDED.raw_data,
-			updated_at = EXCLUDED.updated_at
+			updated_at = EXCLUDED.updated_at,
+			run_id = EXCLUDED.run_id
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -172,6 +256,7 @@ func (r *PodcastRepository) UpsertEpisodeMetrics(ctx context.Context, metrics *s
 		deviceBreakdownJSON,
 		rawDataJSON,
 		time.Now(),
+		nullRunID(metrics.RunID),
 	)
 
 	if err != nil {
@@ -188,7 +273,7 @@ func (r *PodcastRepository) UpsertShowMetrics(ctx context.Context, metrics *scra
 	rawDataJSON, _ := json.Marshal(metrics.RawData)
 
 	query := `
-		INSERT INTO raw.podcast_show_metrics (
+		INSERT INTO ` + r.showMetricsTable + ` (
 			podcast_id, metric_date, total_plays, total_listeners, total_engaged_listeners,
 			total_views, total_downloads, followers_total, followers_gained, followers_lost,
 			subscribers_total, subscribers_gained, subscribers_lost, average_completion_rate,
@@ -252,7 +337,7 @@ func (r *PodcastRepository) UpsertShowMetrics(ctx context.Context, metrics *scra
 // InsertComment inserts a comment
 func (r *PodcastRepository) InsertComment(ctx context.Context, comment *scrapers.Comment) error {
 	query := `
-		INSERT INTO raw.podcast_comments (
+		INSERT INTO ` + r.commentsTable + ` (
 			episode_id, platform_comment_id, author_name, author_id,
 			comment_text, likes_count, reply_count, parent_comment_id, published_at
 		)
@@ -335,3 +420,222 @@ func (r *PodcastRepository) UpdateScraperRun(ctx context.Context, runID int64, r
 
 	return nil
 }
+
+// nullRunID stores an unknown run (0) as NULL
+func nullRunID(runID int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: runID, Valid: runID > 0}
+}
+
+// RunFilter narrows ListScraperRuns
+type RunFilter struct {
+	Platforms []scrapers.Platform
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// ListScraperRuns returns scraper runs, newest first
+func (r *PodcastRepository) ListScraperRuns(ctx context.Context, filter RunFilter) ([]*scrapers.ScraperRun, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `
+		SELECT r.id, r.platform, r.run_started_at, r.run_completed_at, r.status,
+		       r.episodes_processed, r.metrics_collected, r.error_message,
+		       (SELECT COUNT(*) FROM raw.podcast_schema_drift d WHERE d.run_id = r.id)
+		FROM raw.podcast_scraper_runs r
+		WHERE (cardinality($1::text[]) = 0 OR r.platform = ANY($1))
+		  AND ($2::timestamptz IS NULL OR r.run_started_at >= $2)
+		  AND ($3::timestamptz IS NULL OR r.run_started_at < $3)
+		ORDER BY r.run_started_at DESC
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		pq.Array(platformStrings(filter.Platforms)),
+		nullTime(filter.Since),
+		nullTime(filter.Until),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scraper runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*scrapers.ScraperRun
+	for rows.Next() {
+		run := &scrapers.ScraperRun{}
+		if err := rows.Scan(
+			&run.ID,
+			&run.Platform,
+			&run.RunStartedAt,
+			&run.RunCompletedAt,
+			&run.Status,
+			&run.EpisodesProcessed,
+			&run.MetricsCollected,
+			&run.ErrorMessage,
+			&run.SchemaDrift,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan scraper run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list scraper runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// MetricsFilter narrows episode metrics queries
+type MetricsFilter struct {
+	ShowName  string
+	Platforms []scrapers.Platform
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// EpisodeMetricsRecord is an episode metrics row together with the
+// identifiers needed to match it back to an episode on import
+type EpisodeMetricsRecord struct {
+	ShowName          string
+	Platform          scrapers.Platform
+	PodcastPlatformID string
+	PlatformEpisodeID string
+	EpisodeTitle      string
+	Metrics           *scrapers.EpisodeMetrics
+}
+
+// ListEpisodeMetrics returns the typed episode metrics matching the filter
+func (r *PodcastRepository) ListEpisodeMetrics(ctx context.Context, filter MetricsFilter) ([]*EpisodeMetricsRecord, error) {
+	query := `
+		SELECT p.show_name, p.platform, COALESCE(p.platform_id, ''),
+		       COALESCE(pe.platform_episode_id, ''), pe.episode_title,
+		       pem.episode_id, pem.metric_date, COALESCE(pem.plays, 0), COALESCE(pem.listeners, 0),
+		       COALESCE(pem.engaged_listeners, 0), COALESCE(pem.views, 0), COALESCE(pem.likes, 0),
+		       COALESCE(pem.dislikes, 0), COALESCE(pem.comments_count, 0), COALESCE(pem.shares, 0),
+		       COALESCE(pem.watch_time_minutes, 0), COALESCE(pem.average_view_duration_seconds, 0),
+		       COALESCE(pem.subscribers_gained, 0), COALESCE(pem.subscribers_lost, 0),
+		       COALESCE(pem.downloads, 0), COALESCE(pem.streams, 0),
+		       pem.completion_rate, pem.average_listen_time_seconds, pem.followers_total,
+		       COALESCE(pem.followers_gained, 0), COALESCE(pem.followers_lost, 0)
+		FROM raw.podcast_episode_metrics pem
+		JOIN raw.podcast_episodes pe ON pem.episode_id = pe.id
+		JOIN raw.podcasts p ON pe.podcast_id = p.id
+		WHERE ($1 = '' OR p.show_name = $1)
+		  AND (cardinality($2::text[]) = 0 OR p.platform = ANY($2))
+		  AND ($3::date IS NULL OR pem.metric_date >= $3)
+		  AND ($4::date IS NULL OR pem.metric_date <= $4)
+		ORDER BY p.platform, pe.platform_episode_id, pem.metric_date
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		filter.ShowName,
+		pq.Array(platformStrings(filter.Platforms)),
+		nullTime(filter.StartDate),
+		nullTime(filter.EndDate),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list episode metrics: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*EpisodeMetricsRecord
+	for rows.Next() {
+		m := &scrapers.EpisodeMetrics{}
+		rec := &EpisodeMetricsRecord{Metrics: m}
+		// Only some platforms report these, so they are often NULL
+		var completionRate sql.NullFloat64
+		var averageListenTime, followersTotal sql.NullInt64
+		if err := rows.Scan(
+			&rec.ShowName,
+			&rec.Platform,
+			&rec.PodcastPlatformID,
+			&rec.PlatformEpisodeID,
+			&rec.EpisodeTitle,
+			&m.EpisodeID,
+			&m.MetricDate,
+			&m.Plays,
+			&m.Listeners,
+			&m.EngagedListeners,
+			&m.Views,
+			&m.Likes,
+			&m.Dislikes,
+			&m.CommentsCount,
+			&m.Shares,
+			&m.WatchTimeMinutes,
+			&m.AverageViewDuration,
+			&m.SubscribersGained,
+			&m.SubscribersLost,
+			&m.Downloads,
+			&m.Streams,
+			&completionRate,
+			&averageListenTime,
+			&followersTotal,
+			&m.FollowersGained,
+			&m.FollowersLost,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan episode metrics: %w", err)
+		}
+		if completionRate.Valid {
+			m.CompletionRate = &completionRate.Float64
+		}
+		if averageListenTime.Valid {
+			seconds := int(averageListenTime.Int64)
+			m.AverageListenTime = &seconds
+		}
+		if followersTotal.Valid {
+			m.FollowersTotal = &followersTotal.Int64
+		}
+		records = append(records, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list episode metrics: %w", err)
+	}
+
+	return records, nil
+}
+
+// FindEpisodeID looks up an existing episode by its platform identifiers
+func (r *PodcastRepository) FindEpisodeID(ctx context.Context, platform scrapers.Platform, podcastPlatformID, platformEpisodeID string) (int64, error) {
+	query := `
+		SELECT pe.id
+		FROM raw.podcast_episodes pe
+		JOIN raw.podcasts p ON pe.podcast_id = p.id
+		WHERE p.platform = $1
+		  AND COALESCE(p.platform_id, '') = $2
+		  AND pe.platform_episode_id = $3
+	`
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, query, platform, podcastPlatformID, platformEpisodeID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find episode: %w", err)
+	}
+
+	return id, nil
+}
+
+// platformStrings converts platforms for use with pq.Array
+func platformStrings(platforms []scrapers.Platform) []string {
+	out := make([]string, len(platforms))
+	for i, p := range platforms {
+		out[i] = string(p)
+	}
+	return out
+}
+
+// nullTime maps the zero time to NULL so optional bounds can be left open
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"testing"
+)
+
+func TestListEpisodeMetricsNullColumns(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+	_, episodeID := createTestEpisode(t, repo)
+
+	// Spotify, Apple and Amazon leave the rate, listen time and follower columns NULL
+	_, err := repo.db.ExecContext(ctx, `
+		INSERT INTO raw.podcast_episode_metrics (episode_id, metric_date, plays, views, completion_rate, average_listen_time_seconds, followers_total)
+		VALUES ($1, '2026-01-01', 12, NULL, NULL, NULL, NULL),
+		       ($1, '2026-01-02', 15, 0, 42.5, 300, 1000)
+	`, episodeID)
+	if err != nil {
+		t.Fatalf("failed to insert metrics: %v", err)
+	}
+
+	records, err := repo.ListEpisodeMetrics(ctx, MetricsFilter{})
+	if err != nil {
+		t.Fatalf("ListEpisodeMetrics() error = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("ListEpisodeMetrics() = %d records, want 2", len(records))
+	}
+
+	sparse, full := records[0].Metrics, records[1].Metrics
+	if sparse.Plays != 12 || sparse.Views != 0 {
+		t.Errorf("plays, views = %d, %d, want 12, 0", sparse.Plays, sparse.Views)
+	}
+	if sparse.CompletionRate != nil || sparse.AverageListenTime != nil || sparse.FollowersTotal != nil {
+		t.Errorf("NULL columns = %v, %v, %v, want nil", sparse.CompletionRate, sparse.AverageListenTime, sparse.FollowersTotal)
+	}
+	if full.CompletionRate == nil || *full.CompletionRate != 42.5 {
+		t.Errorf("completion rate = %v, want 42.5", full.CompletionRate)
+	}
+	if full.AverageListenTime == nil || *full.AverageListenTime != 300 {
+		t.Errorf("average listen time = %v, want 300", full.AverageListenTime)
+	}
+	if full.FollowersTotal == nil || *full.FollowersTotal != 1000 {
+		t.Errorf("followers total = %v, want 1000", full.FollowersTotal)
+	}
+}
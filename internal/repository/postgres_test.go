@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+
+	"github.com/soypete/eleduck-analytics-connector/database"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// openTestRepository connects to the scratch database named by
+// TEST_DATABASE_URL, migrates it and empties every raw table. Tests that need
+// Postgres are skipped without it. The first migration needs the pg_duckdb
+// extension to be available, as in production.
+func openTestRepository(t *testing.T) *PodcastRepository {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, database.Migrations())
+	if err != nil {
+		t.Fatalf("failed to create migration provider: %v", err)
+	}
+	if _, err := provider.Up(ctx); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	// Staging tables of earlier tests are dropped, every other table emptied
+	var staging, tables sql.NullString
+	err = db.QueryRowContext(ctx, `
+		SELECT string_agg(format('%I.%I', schemaname, tablename), ', ') FILTER (WHERE tablename ~ '_run_[0-9]+$'),
+		       string_agg(format('%I.%I', schemaname, tablename), ', ') FILTER (WHERE tablename !~ '_run_[0-9]+$')
+		FROM pg_tables
+		WHERE schemaname = 'raw'
+	`).Scan(&staging, &tables)
+	if err != nil {
+		t.Fatalf("failed to list test tables: %v", err)
+	}
+	if staging.Valid {
+		if _, err := db.ExecContext(ctx, `DROP TABLE `+staging.String); err != nil {
+			t.Fatalf("failed to drop staging tables: %v", err)
+		}
+	}
+	if tables.Valid {
+		if _, err := db.ExecContext(ctx, `TRUNCATE `+tables.String+` RESTART IDENTITY CASCADE`); err != nil {
+			t.Fatalf("failed to empty test tables: %v", err)
+		}
+	}
+
+	return NewPodcastRepository(db)
+}
+
+// createTestEpisode stores a podcast with one episode and returns both IDs
+func createTestEpisode(t *testing.T, repo *PodcastRepository) (podcastID, episodeID int64) {
+	t.Helper()
+	ctx := context.Background()
+
+	podcastID, err := repo.UpsertPodcast(ctx, &scrapers.Podcast{
+		ShowName:   "Test Show",
+		Platform:   scrapers.PlatformSpotify,
+		PlatformID: "show-1",
+	})
+	if err != nil {
+		t.Fatalf("UpsertPodcast() error = %v", err)
+	}
+
+	episodeID, err = repo.UpsertEpisode(ctx, &scrapers.Episode{
+		PodcastID:         podcastID,
+		EpisodeTitle:      "Pilot",
+		PlatformEpisodeID: "ep-1",
+	})
+	if err != nil {
+		t.Fatalf("UpsertEpisode() error = %v", err)
+	}
+
+	return podcastID, episodeID
+}
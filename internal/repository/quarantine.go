@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// QuarantineMetrics stores metric rows that broke data quality rules, with
+// the reasons they were held back
+func (r *PodcastRepository) QuarantineMetrics(ctx context.Context, rows []*scrapers.QuarantinedMetric) error {
+	query := `
+		INSERT INTO raw.podcast_metrics_quarantine (
+			run_id, platform, metric_kind, podcast_id, episode_id,
+			platform_episode_id, metric_date, reasons, row_data, quarantined_at
+		)
+		VALUES ($1, $2, $3, NULLIF($4, 0), $5, NULLIF($6, ''), $7, $8, $9, $10)
+	`
+
+	for _, q := range rows {
+		rowJSON, err := json.Marshal(q.Row)
+		if err != nil {
+			return fmt.Errorf("failed to marshal quarantined row: %w", err)
+		}
+
+		_, err = r.db.ExecContext(ctx, query,
+			nullRunID(q.RunID),
+			q.Platform,
+			q.Kind,
+			q.PodcastID,
+			q.EpisodeID,
+			q.PlatformEpisodeID,
+			q.MetricDate,
+			pq.Array(q.Reasons),
+			rowJSON,
+			q.QuarantinedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to quarantine metrics: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// SaveQuotaUsage records the API quota units a run has consumed on the UTC
+// day day, replacing what was recorded for that run and day before
+func (r *PodcastRepository) SaveQuotaUsage(ctx context.Context, runID int64, platform scrapers.Platform, day time.Time, units int64) error {
+	query := `
+		INSERT INTO raw.podcast_api_quota_usage (run_id, usage_date, platform, units)
+		VALUES ($1, $2::date, $3, $4)
+		ON CONFLICT (run_id, usage_date) DO UPDATE SET
+			units = EXCLUDED.units,
+			updated_at = NOW()
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, runID, day, platform, units); err != nil {
+		return fmt.Errorf("failed to save quota usage: %w", err)
+	}
+	return nil
+}
+
+// QuotaUsedOn returns the API quota units every run of a platform consumed
+// on the UTC day day
+func (r *PodcastRepository) QuotaUsedOn(ctx context.Context, platform scrapers.Platform, day time.Time) (int64, error) {
+	query := `
+		SELECT COALESCE(SUM(units), 0)
+		FROM raw.podcast_api_quota_usage
+		WHERE platform = $1 AND usage_date = $2::date
+	`
+
+	var units int64
+	if err := r.db.QueryRowContext(ctx, query, platform, day).Scan(&units); err != nil {
+		return 0, fmt.Errorf("failed to query quota usage: %w", err)
+	}
+	return units, nil
+}
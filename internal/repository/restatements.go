@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// RestatementSummary describes how much and how late a platform revised its
+// episode metrics, from analytics.podcast_restatement_summary
+type RestatementSummary struct {
+	Platform        scrapers.Platform
+	MetricDays      int64
+	RestatedDays    int64
+	RestatedPct     *float64
+	Restatements    int64
+	AvgDaysAfter    *float64
+	MaxDaysAfter    *int64
+	TotalAbsDelta   int64
+	AvgAbsChangePct *float64
+}
+
+// RestatementSummaries returns the restatement summary for each platform,
+// optionally limited to platforms
+func (r *PodcastRepository) RestatementSummaries(ctx context.Context, platforms []scrapers.Platform) ([]*RestatementSummary, error) {
+	query := `
+		SELECT platform, metric_days, restated_days, restated_pct, restatements,
+		       avg_days_after, max_days_after, COALESCE(total_abs_delta, 0), avg_abs_change_pct
+		FROM analytics.podcast_restatement_summary
+		WHERE cardinality($1::text[]) = 0 OR platform = ANY($1)
+		ORDER BY platform
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(platformStrings(platforms)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query restatements: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []*RestatementSummary
+	for rows.Next() {
+		s := &RestatementSummary{}
+		var restatedPct, avgDaysAfter, avgAbsChangePct sql.NullFloat64
+		var maxDaysAfter sql.NullInt64
+		if err := rows.Scan(
+			&s.Platform,
+			&s.MetricDays,
+			&s.RestatedDays,
+			&restatedPct,
+			&s.Restatements,
+			&avgDaysAfter,
+			&maxDaysAfter,
+			&s.TotalAbsDelta,
+			&avgAbsChangePct,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan restatement summary: %w", err)
+		}
+		if restatedPct.Valid {
+			s.RestatedPct = &restatedPct.Float64
+		}
+		if avgDaysAfter.Valid {
+			s.AvgDaysAfter = &avgDaysAfter.Float64
+		}
+		if maxDaysAfter.Valid {
+			s.MaxDaysAfter = &maxDaysAfter.Int64
+		}
+		if avgAbsChangePct.Valid {
+			s.AvgAbsChangePct = &avgAbsChangePct.Float64
+		}
+		summaries = append(summaries, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query restatements: %w", err)
+	}
+
+	return summaries, nil
+}
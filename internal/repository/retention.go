@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// RetentionPolicy bounds how long each kind of data is kept. A zero number
+// of days keeps that data forever.
+type RetentionPolicy struct {
+	// RawDataDays clears raw_data on episode and show metrics dated this
+	// many days ago or earlier, where it only references an archived
+	// response. Payloads stored inline are kept, since reparse needs them.
+	RawDataDays int
+
+	// RollupDays rolls daily episode metrics into monthly aggregates once
+	// their whole month is at least this many days old
+	RollupDays int
+
+	// SnapshotDays deletes episode metric snapshot history dated this many
+	// days ago or earlier
+	SnapshotDays int
+
+	// RunDays deletes scraper runs, with their errors and drift, started
+	// this long ago. Their archive index entries are kept.
+	RunDays int
+
+	// ErrorDays deletes per-item scraper errors recorded this long ago
+	ErrorDays int
+}
+
+// PruneResult counts the rows a prune changed or removed
+type PruneResult struct {
+	RawDataCleared     int64
+	DailyRowsRolledUp  int64
+	MonthlyRowsWritten int64
+	SnapshotsDeleted   int64
+	ErrorsDeleted      int64
+	RunsDeleted        int64
+}
+
+// errPruneDryRun rolls back a dry-run prune once it has been counted
+var errPruneDryRun = errors.New("dry run")
+
+// Columns of daily episode metrics summed into monthly aggregates
+var rollupSumColumns = []string{
+	"plays", "listeners", "engaged_listeners", "views", "likes", "dislikes",
+	"comments_count", "shares", "watch_time_minutes", "subscribers_gained",
+	"subscribers_lost", "downloads", "streams", "followers_gained", "followers_lost",
+}
+
+// Columns of daily episode metrics averaged into monthly aggregates, with
+// the SQL type each average is stored as
+var rollupAvgColumns = [][2]string{
+	{"average_view_duration_seconds", "INTEGER"},
+	{"completion_rate", "DECIMAL(5,2)"},
+	{"average_listen_time_seconds", "INTEGER"},
+}
+
+// Prune applies the retention policy as of now in one transaction. With
+// dryRun set the changes are counted and rolled back.
+func (r *PodcastRepository) Prune(ctx context.Context, policy RetentionPolicy, now time.Time, dryRun bool) (*PruneResult, error) {
+	result := &PruneResult{}
+
+	err := r.InTx(ctx, func(tx Store) error {
+		db := tx.(*PodcastRepository).db
+
+		exec := func(query string, arg interface{}) (int64, error) {
+			res, err := db.ExecContext(ctx, query, arg)
+			if err != nil {
+				return 0, err
+			}
+			return res.RowsAffected()
+		}
+
+		if policy.RawDataDays > 0 {
+			cutoff := now.AddDate(0, 0, -policy.RawDataDays)
+			for _, table := range []string{"raw.podcast_episode_metrics", "raw.podcast_show_metrics"} {
+				n, err := exec(fmt.Sprintf(`
+					UPDATE %s SET raw_data = NULL
+					WHERE raw_data ? '%s' AND metric_date <= $1
+				`, table, scrapers.ArchiveKeyField), cutoff)
+				if err != nil {
+					return fmt.Errorf("failed to clear raw_data in %s: %w", table, err)
+				}
+				result.RawDataCleared += n
+			}
+		}
+
+		if policy.RollupDays > 0 {
+			// Only whole months are rolled up
+			cutoff := now.AddDate(0, 0, -policy.RollupDays)
+			cutoff = time.Date(cutoff.Year(), cutoff.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+			if err := db.QueryRowContext(ctx, rollupQuery(), cutoff).Scan(&result.DailyRowsRolledUp, &result.MonthlyRowsWritten); err != nil {
+				return fmt.Errorf("failed to roll up episode metrics: %w", err)
+			}
+		}
+
+		if policy.SnapshotDays > 0 {
+			n, err := exec(`DELETE FROM raw.podcast_episode_metric_snapshots WHERE metric_date <= $1`, now.AddDate(0, 0, -policy.SnapshotDays))
+			if err != nil {
+				return fmt.Errorf("failed to delete metric snapshots: %w", err)
+			}
+			result.SnapshotsDeleted = n
+		}
+
+		if policy.ErrorDays > 0 {
+			n, err := exec(`DELETE FROM raw.podcast_scraper_errors WHERE occurred_at < $1`, now.AddDate(0, 0, -policy.ErrorDays))
+			if err != nil {
+				return fmt.Errorf("failed to delete scraper errors: %w", err)
+			}
+			result.ErrorsDeleted = n
+		}
+
+		if policy.RunDays > 0 {
+			n, err := exec(`
+				DELETE FROM raw.podcast_scraper_runs
+				WHERE run_started_at < $1 AND status <> 'running'
+			`, now.AddDate(0, 0, -policy.RunDays))
+			if err != nil {
+				return fmt.Errorf("failed to delete scraper runs: %w", err)
+			}
+			result.RunsDeleted = n
+		}
+
+		if dryRun {
+			return errPruneDryRun
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errPruneDryRun) {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// rollupQuery moves daily episode metrics dated before $1 into their monthly
+// aggregates and returns the daily rows removed and the monthly rows
+// written. Once a month is rolled up, new daily rows for it are dropped on
+// insert (migration 0015), so a month is only added to by rows written
+// before that guard existed.
+func rollupQuery() string {
+	columns := []string{"episode_id", "month", "days"}
+	selects := []string{"episode_id", "date_trunc('month', metric_date)::date", "COUNT(*)"}
+	updates := []string{"days = m.days + EXCLUDED.days"}
+
+	for _, c := range rollupSumColumns {
+		columns = append(columns, c)
+		selects = append(selects, fmt.Sprintf("SUM(COALESCE(%s, 0))", c))
+		updates = append(updates, fmt.Sprintf("%[1]s = m.%[1]s + EXCLUDED.%[1]s", c))
+	}
+
+	// Averages are weighted by the days each side covers
+	for _, c := range rollupAvgColumns {
+		columns = append(columns, c[0])
+		selects = append(selects, fmt.Sprintf("AVG(%s)::%s", c[0], c[1]))
+		updates = append(updates, fmt.Sprintf(
+			"%[1]s = COALESCE(((m.%[1]s * m.days + EXCLUDED.%[1]s * EXCLUDED.days) / (m.days + EXCLUDED.days))::%[2]s, m.%[1]s, EXCLUDED.%[1]s)",
+			c[0], c[1]))
+	}
+
+	columns = append(columns, "followers_total")
+	selects = append(selects, "(ARRAY_AGG(followers_total ORDER BY metric_date DESC) FILTER (WHERE followers_total IS NOT NULL))[1]")
+	updates = append(updates, "followers_total = COALESCE(EXCLUDED.followers_total, m.followers_total)", "updated_at = NOW()")
+
+	return fmt.Sprintf(`
+		WITH rolled AS (
+			DELETE FROM raw.podcast_episode_metrics
+			WHERE metric_date < $1
+			RETURNING *
+		),
+		merged AS (
+			INSERT INTO raw.podcast_episode_metrics_monthly AS m (%s)
+			SELECT %s
+			FROM rolled
+			GROUP BY 1, 2
+			ON CONFLICT (episode_id, month) DO UPDATE SET %s
+			RETURNING 1
+		)
+		SELECT (SELECT COUNT(*) FROM rolled), (SELECT COUNT(*) FROM merged)
+	`, strings.Join(columns, ", "), strings.Join(selects, ", "), strings.Join(updates, ", "))
+}
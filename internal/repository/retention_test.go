@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+func upsertTestMetric(t *testing.T, repo *PodcastRepository, metric *scrapers.EpisodeMetrics) {
+	t.Helper()
+	if err := repo.UpsertEpisodeMetrics(context.Background(), metric); err != nil {
+		t.Fatalf("UpsertEpisodeMetrics() error = %v", err)
+	}
+}
+
+func TestPruneRollupOverlappingData(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+	_, episodeID := createTestEpisode(t, repo)
+
+	upsertTestMetric(t, repo, &scrapers.EpisodeMetrics{EpisodeID: episodeID, MetricDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Plays: 10})
+	upsertTestMetric(t, repo, &scrapers.EpisodeMetrics{EpisodeID: episodeID, MetricDate: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Plays: 10})
+	upsertTestMetric(t, repo, &scrapers.EpisodeMetrics{EpisodeID: episodeID, MetricDate: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Plays: 5})
+
+	// January is a whole month older than 30 days, February is not
+	policy := RetentionPolicy{RollupDays: 30}
+	now := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	result, err := repo.Prune(ctx, policy, now, false)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if result.DailyRowsRolledUp != 2 || result.MonthlyRowsWritten != 1 {
+		t.Errorf("Prune() rolled up %d rows into %d months, want 2 into 1", result.DailyRowsRolledUp, result.MonthlyRowsWritten)
+	}
+
+	// A backfill overlapping the rolled-up month writes nothing into it
+	upsertTestMetric(t, repo, &scrapers.EpisodeMetrics{EpisodeID: episodeID, MetricDate: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Plays: 10})
+	upsertTestMetric(t, repo, &scrapers.EpisodeMetrics{EpisodeID: episodeID, MetricDate: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC), Plays: 7})
+	if got := countRows(t, repo, `SELECT COUNT(*) FROM raw.podcast_episode_metrics WHERE metric_date < '2026-02-01'`); got != 0 {
+		t.Errorf("daily rows in the rolled-up month = %d, want 0", got)
+	}
+
+	result, err = repo.Prune(ctx, policy, now, false)
+	if err != nil {
+		t.Fatalf("second Prune() error = %v", err)
+	}
+	if result.DailyRowsRolledUp != 0 {
+		t.Errorf("second Prune() rolled up %d rows, want 0", result.DailyRowsRolledUp)
+	}
+
+	if got := countRows(t, repo, `SELECT plays FROM raw.podcast_episode_metrics_monthly WHERE month = '2026-01-01'`); got != 20 {
+		t.Errorf("January plays = %d, want 20", got)
+	}
+	if got := countRows(t, repo, `SELECT days FROM raw.podcast_episode_metrics_monthly WHERE month = '2026-01-01'`); got != 2 {
+		t.Errorf("January days = %d, want 2", got)
+	}
+	if got := countRows(t, repo, `SELECT COUNT(*) FROM raw.podcast_episode_metrics WHERE metric_date = '2026-02-01'`); got != 1 {
+		t.Errorf("February daily rows = %d, want 1", got)
+	}
+}
+
+func TestPruneKeepsWhatReparseNeeds(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+	podcastID, episodeID := createTestEpisode(t, repo)
+
+	runID, err := repo.RecordScraperRun(ctx, &scrapers.ScraperRun{
+		Platform:     scrapers.PlatformSpotify,
+		RunStartedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Status:       scrapers.RunStatusCompleted,
+	})
+	if err != nil {
+		t.Fatalf("RecordScraperRun() error = %v", err)
+	}
+
+	err = repo.RecordArchiveEntry(ctx, &ArchiveEntry{
+		RunID:     runID,
+		Platform:  scrapers.PlatformSpotify,
+		Endpoint:  scrapers.EndpointEpisodeMetrics,
+		PodcastID: &podcastID,
+		EpisodeID: &episodeID,
+		Key:       "spotify/episode_metrics/1.json.gz",
+		SizeBytes: 100,
+	})
+	if err != nil {
+		t.Fatalf("RecordArchiveEntry() error = %v", err)
+	}
+
+	upsertTestMetric(t, repo, &scrapers.EpisodeMetrics{
+		EpisodeID:  episodeID,
+		MetricDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		RawData:    scrapers.ArchiveRef("spotify/episode_metrics/1.json.gz"),
+		RunID:      runID,
+	})
+	upsertTestMetric(t, repo, &scrapers.EpisodeMetrics{
+		EpisodeID:  episodeID,
+		MetricDate: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+		RawData:    map[string]interface{}{"plays": 1},
+		RunID:      runID,
+	})
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	result, err := repo.Prune(ctx, RetentionPolicy{RawDataDays: 30, RunDays: 30}, now, false)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if result.RawDataCleared != 1 || result.RunsDeleted != 1 || result.SnapshotsDeleted != 0 {
+		t.Errorf("Prune() = %+v, want 1 raw_data cleared, 1 run deleted and no snapshots deleted", result)
+	}
+
+	if got := countRows(t, repo, `SELECT COUNT(*) FROM raw.podcast_episode_metrics WHERE raw_data IS NOT NULL`); got != 1 {
+		t.Errorf("rows keeping an inline payload = %d, want 1", got)
+	}
+
+	entries, err := repo.ListArchiveEntries(ctx, ArchiveFilter{})
+	if err != nil {
+		t.Fatalf("ListArchiveEntries() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].RunID != 0 {
+		t.Errorf("archive entries after pruning their run = %+v, want one without a run", entries)
+	}
+
+	if got := countRows(t, repo, `SELECT COUNT(*) FROM raw.podcast_episode_metric_snapshots`); got != 2 {
+		t.Fatalf("snapshots without a snapshot retention = %d, want 2", got)
+	}
+	result, err = repo.Prune(ctx, RetentionPolicy{SnapshotDays: 30}, now, false)
+	if err != nil {
+		t.Fatalf("Prune() with snapshot retention error = %v", err)
+	}
+	if result.SnapshotsDeleted != 2 {
+		t.Errorf("SnapshotsDeleted = %d, want 2", result.SnapshotsDeleted)
+	}
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// RecordSchemaDrift records a difference between a response and its declared
+// shape. Drift already recorded for the run is kept as first seen.
+func (r *PodcastRepository) RecordSchemaDrift(ctx context.Context, d *scrapers.SchemaDrift) error {
+	query := `
+		INSERT INTO raw.podcast_schema_drift (
+			run_id, platform, endpoint, field_path, drift_kind,
+			expected_type, observed_type, sample, detected_at
+		)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9)
+		ON CONFLICT (run_id, platform, endpoint, field_path, drift_kind) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		d.RunID,
+		d.Platform,
+		d.Endpoint,
+		d.Path,
+		d.Kind,
+		d.ExpectedType,
+		d.ObservedType,
+		d.Sample,
+		d.DetectedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to record schema drift: %w", err)
+	}
+
+	return nil
+}
+
+// ListSchemaDrift returns the schema drift found during a run, by endpoint
+// and field
+func (r *PodcastRepository) ListSchemaDrift(ctx context.Context, runID int64) ([]*scrapers.SchemaDrift, error) {
+	query := `
+		SELECT id, run_id, platform, endpoint, field_path, drift_kind,
+		       COALESCE(expected_type, ''), COALESCE(observed_type, ''),
+		       COALESCE(sample, ''), detected_at
+		FROM raw.podcast_schema_drift
+		WHERE run_id = $1
+		ORDER BY endpoint, field_path, drift_kind
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schema drift: %w", err)
+	}
+	defer rows.Close()
+
+	var drift []*scrapers.SchemaDrift
+	for rows.Next() {
+		d := &scrapers.SchemaDrift{}
+		if err := rows.Scan(
+			&d.ID,
+			&d.RunID,
+			&d.Platform,
+			&d.Endpoint,
+			&d.Path,
+			&d.Kind,
+			&d.ExpectedType,
+			&d.ObservedType,
+			&d.Sample,
+			&d.DetectedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan schema drift: %w", err)
+		}
+		drift = append(drift, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list schema drift: %w", err)
+	}
+
+	return drift, nil
+}
@@ -0,0 +1,661 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema mirrors the raw podcast tables from database/migrations in a
+// single SQLite file. JSON columns are stored as TEXT.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS podcasts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    show_name TEXT NOT NULL,
+    platform TEXT NOT NULL,
+    platform_id TEXT,
+    description TEXT,
+    author TEXT,
+    categories TEXT,
+    language TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(platform, platform_id)
+);
+
+CREATE TABLE IF NOT EXISTS podcast_episodes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    podcast_id INTEGER NOT NULL REFERENCES podcasts(id) ON DELETE CASCADE,
+    episode_title TEXT NOT NULL,
+    platform_episode_id TEXT,
+    description TEXT,
+    duration_seconds INTEGER,
+    publish_date TIMESTAMP,
+    season_number INTEGER,
+    episode_number INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(podcast_id, platform_episode_id)
+);
+
+CREATE TABLE IF NOT EXISTS podcast_episode_metrics (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    episode_id INTEGER NOT NULL REFERENCES podcast_episodes(id) ON DELETE CASCADE,
+    metric_date DATE NOT NULL,
+    plays INTEGER DEFAULT 0,
+    listeners INTEGER DEFAULT 0,
+    engaged_listeners INTEGER DEFAULT 0,
+    views INTEGER DEFAULT 0,
+    likes INTEGER DEFAULT 0,
+    dislikes INTEGER DEFAULT 0,
+    comments_count INTEGER DEFAULT 0,
+    shares INTEGER DEFAULT 0,
+    watch_time_minutes INTEGER DEFAULT 0,
+    average_view_duration_seconds INTEGER DEFAULT 0,
+    subscribers_gained INTEGER DEFAULT 0,
+    subscribers_lost INTEGER DEFAULT 0,
+    downloads INTEGER DEFAULT 0,
+    streams INTEGER DEFAULT 0,
+    completion_rate REAL,
+    average_listen_time_seconds INTEGER,
+    followers_total INTEGER,
+    followers_gained INTEGER DEFAULT 0,
+    followers_lost INTEGER DEFAULT 0,
+    top_countries TEXT,
+    top_cities TEXT,
+    device_breakdown TEXT,
+    raw_data TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(episode_id, metric_date)
+);
+
+CREATE TABLE IF NOT EXISTS podcast_show_metrics (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    podcast_id INTEGER NOT NULL REFERENCES podcasts(id) ON DELETE CASCADE,
+    metric_date DATE NOT NULL,
+    total_plays INTEGER DEFAULT 0,
+    total_listeners INTEGER DEFAULT 0,
+    total_engaged_listeners INTEGER DEFAULT 0,
+    total_views INTEGER DEFAULT 0,
+    total_downloads INTEGER DEFAULT 0,
+    followers_total INTEGER,
+    followers_gained INTEGER DEFAULT 0,
+    followers_lost INTEGER DEFAULT 0,
+    subscribers_total INTEGER,
+    subscribers_gained INTEGER DEFAULT 0,
+    subscribers_lost INTEGER DEFAULT 0,
+    average_completion_rate REAL,
+    total_comments INTEGER DEFAULT 0,
+    total_likes INTEGER DEFAULT 0,
+    total_shares INTEGER DEFAULT 0,
+    top_countries TEXT,
+    top_cities TEXT,
+    raw_data TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(podcast_id, metric_date)
+);
+
+CREATE TABLE IF NOT EXISTS podcast_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    episode_id INTEGER NOT NULL REFERENCES podcast_episodes(id) ON DELETE CASCADE,
+    platform_comment_id TEXT NOT NULL,
+    author_name TEXT,
+    author_id TEXT,
+    comment_text TEXT,
+    likes_count INTEGER DEFAULT 0,
+    reply_count INTEGER DEFAULT 0,
+    parent_comment_id INTEGER REFERENCES podcast_comments(id),
+    published_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(episode_id, platform_comment_id)
+);
+
+CREATE TABLE IF NOT EXISTS podcast_scraper_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    platform TEXT NOT NULL,
+    run_started_at TIMESTAMP NOT NULL,
+    run_completed_at TIMESTAMP,
+    status TEXT NOT NULL,
+    episodes_processed INTEGER DEFAULT 0,
+    metrics_collected INTEGER DEFAULT 0,
+    error_message TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS podcast_scraper_errors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id INTEGER NOT NULL REFERENCES podcast_scraper_runs(id) ON DELETE CASCADE,
+    platform TEXT NOT NULL,
+    episode_id INTEGER,
+    episode_title TEXT,
+    stage TEXT NOT NULL,
+    error_class TEXT NOT NULL,
+    http_status INTEGER,
+    message TEXT NOT NULL,
+    occurred_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS podcast_metrics_quarantine (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id INTEGER REFERENCES podcast_scraper_runs(id) ON DELETE SET NULL,
+    platform TEXT NOT NULL,
+    metric_kind TEXT NOT NULL,
+    podcast_id INTEGER,
+    episode_id INTEGER,
+    platform_episode_id TEXT,
+    metric_date DATE,
+    reasons TEXT NOT NULL,
+    row_data TEXT NOT NULL,
+    quarantined_at TIMESTAMP NOT NULL
+);
+`
+
+// sqliteDB is satisfied by both *sql.DB and *sql.Tx
+type sqliteDB interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// SQLiteStore writes scraped data to a local SQLite file, for running the
+// scraper on a laptop without a Postgres instance
+type SQLiteStore struct {
+	db sqliteDB
+
+	// conn is the database handle, nil when the store is bound to a transaction
+	conn *sql.DB
+}
+
+// OpenSQLiteStore opens (creating if needed) the SQLite file at path and
+// ensures the schema exists
+func OpenSQLiteStore(ctx context.Context, path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)", path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	// SQLite allows a single writer; serialise access instead of failing with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create sqlite schema: %w", err)
+	}
+
+	return &SQLiteStore{db: traceDB(db, systemSQLite), conn: db}, nil
+}
+
+// Close closes the underlying database
+func (s *SQLiteStore) Close() error {
+	return s.conn.Close()
+}
+
+// Ping checks the database file can still be reached
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.conn.PingContext(ctx)
+}
+
+// InTx runs fn with a store bound to a single transaction, committing if fn
+// returns nil and rolling back otherwise. Inside a transaction fn joins it.
+func (s *SQLiteStore) InTx(ctx context.Context, fn func(tx Store) error) error {
+	if s.conn == nil {
+		return fn(s)
+	}
+
+	sqlTx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(&SQLiteStore{db: traceDB(sqlTx, systemSQLite)}); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// LookupEpisodeID returns the ID of an existing episode, or ErrNotFound
+func (s *SQLiteStore) LookupEpisodeID(ctx context.Context, podcastID int64, platformEpisodeID string) (int64, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id FROM podcast_episodes WHERE podcast_id = ? AND platform_episode_id = ?`,
+		podcastID, platformEpisodeID,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up episode: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpsertPodcast inserts or updates a podcast
+func (s *SQLiteStore) UpsertPodcast(ctx context.Context, podcast *scrapers.Podcast) (int64, error) {
+	categoriesJSON, err := json.Marshal(podcast.Categories)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal categories: %w", err)
+	}
+
+	query := `
+		INSERT INTO podcasts (show_name, platform, platform_id, description, author, categories, language, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (platform, platform_id)
+		DO UPDATE SET
+			show_name = excluded.show_name,
+			description = excluded.description,
+			author = excluded.author,
+			categories = excluded.categories,
+			language = excluded.language,
+			updated_at = excluded.updated_at
+		RETURNING id
+	`
+
+	var id int64
+	err = s.db.QueryRowContext(ctx, query,
+		podcast.ShowName,
+		podcast.Platform,
+		podcast.PlatformID,
+		podcast.Description,
+		podcast.Author,
+		string(categoriesJSON),
+		podcast.Language,
+		time.Now(),
+	).Scan(&id)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to upsert podcast: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpsertEpisode inserts or updates an episode
+func (s *SQLiteStore) UpsertEpisode(ctx context.Context, episode *scrapers.Episode) (int64, error) {
+	query := `
+		INSERT INTO podcast_episodes (
+			podcast_id, episode_title, platform_episode_id, description,
+			duration_seconds, publish_date, season_number, episode_number, updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (podcast_id, platform_episode_id)
+		DO UPDATE SET
+			episode_title = excluded.episode_title,
+			description = excluded.description,
+			duration_seconds = excluded.duration_seconds,
+			publish_date = excluded.publish_date,
+			season_number = excluded.season_number,
+			episode_number = excluded.episode_number,
+			updated_at = excluded.updated_at
+		RETURNING id
+	`
+
+	var id int64
+	err := s.db.QueryRowContext(ctx, query,
+		episode.PodcastID,
+		episode.EpisodeTitle,
+		episode.PlatformEpisodeID,
+		episode.Description,
+		episode.DurationSeconds,
+		episode.PublishDate,
+		episode.SeasonNumber,
+		episode.EpisodeNumber,
+		time.Now(),
+	).Scan(&id)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to upsert episode: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpsertEpisodeMetrics inserts or updates episode metrics
+func (s *SQLiteStore) UpsertEpisodeMetrics(ctx context.Context, metrics *scrapers.EpisodeMetrics) error {
+	return upsertSQLiteEpisodeMetrics(ctx, s.db, metrics)
+}
+
+// UpsertEpisodeMetricsBatch writes many episode metric rows. Outside a
+// transaction the rows share one, and a failing row does not abort the others.
+func (s *SQLiteStore) UpsertEpisodeMetricsBatch(ctx context.Context, metrics []*scrapers.EpisodeMetrics) []error {
+	rowErrs := make([]error, len(metrics))
+
+	err := s.InTx(ctx, func(tx Store) error {
+		db := tx.(*SQLiteStore).db
+		for i, m := range metrics {
+			rowErrs[i] = upsertSQLiteEpisodeMetrics(ctx, db, m)
+		}
+		return nil
+	})
+	if err != nil {
+		for i := range rowErrs {
+			rowErrs[i] = err
+		}
+	}
+
+	return rowErrs
+}
+
+func upsertSQLiteEpisodeMetrics(ctx context.Context, db sqliteDB, metrics *scrapers.EpisodeMetrics) error {
+	topCountriesJSON, _ := json.Marshal(metrics.TopCountries)
+	topCitiesJSON, _ := json.Marshal(metrics.TopCities)
+	deviceBreakdownJSON, _ := json.Marshal(metrics.DeviceBreakdown)
+	rawDataJSON, _ := json.Marshal(metrics.RawData)
+
+	query := `
+		INSERT INTO podcast_episode_metrics (
+			episode_id, metric_date, plays, listeners, engaged_listeners,
+			views, likes, dislikes, comments_count, shares, watch_time_minutes,
+			average_view_duration_seconds, subscribers_gained, subscribers_lost,
+			downloads, streams, completion_rate, average_listen_time_seconds,
+			followers_total, followers_gained, followers_lost,
+			top_countries, top_cities, device_breakdown, raw_data, updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (episode_id, metric_date)
+		DO UPDATE SET
+			plays = excluded.plays,
+			listeners = excluded.listeners,
+			engaged_listeners = excluded.engaged_listeners,
+			views = excluded.views,
+			likes = excluded.likes,
+			dislikes = excluded.dislikes,
+			comments_count = excluded.comments_count,
+			shares = excluded.shares,
+			watch_time_minutes = excluded.watch_time_minutes,
+			average_view_duration_seconds = excluded.average_view_duration_seconds,
+			subscribers_gained = excluded.subscribers_gained,
+			subscribers_lost = excluded.subscribers_lost,
+			downloads = excluded.downloads,
+			streams = excluded.streams,
+			completion_rate = excluded.completion_rate,
+			average_listen_time_seconds = excluded.average_listen_time_seconds,
+			followers_total = excluded.followers_total,
+			followers_gained = excluded.followers_gained,
+			followers_lost = excluded.followers_lost,
+			top_countries = excluded.top_countries,
+			top_cities = excluded.top_cities,
+			device_breakdown = excluded.device_breakdown,
+			raw_data = excluded.raw_data,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := db.ExecContext(ctx, query,
+		metrics.EpisodeID,
+		metrics.MetricDate.Format("2006-01-02"),
+		metrics.Plays,
+		metrics.Listeners,
+		metrics.EngagedListeners,
+		metrics.Views,
+		metrics.Likes,
+		metrics.Dislikes,
+		metrics.CommentsCount,
+		metrics.Shares,
+		metrics.WatchTimeMinutes,
+		metrics.AverageViewDuration,
+		metrics.SubscribersGained,
+		metrics.SubscribersLost,
+		metrics.Downloads,
+		metrics.Streams,
+		metrics.CompletionRate,
+		metrics.AverageListenTime,
+		metrics.FollowersTotal,
+		metrics.FollowersGained,
+		metrics.FollowersLost,
+		string(topCountriesJSON),
+		string(topCitiesJSON),
+		string(deviceBreakdownJSON),
+		string(rawDataJSON),
+		time.Now(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to upsert episode metrics: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertShowMetrics inserts or updates show-level metrics
+func (s *SQLiteStore) UpsertShowMetrics(ctx context.Context, metrics *scrapers.ShowMetrics) error {
+	topCountriesJSON, _ := json.Marshal(metrics.TopCountries)
+	topCitiesJSON, _ := json.Marshal(metrics.TopCities)
+	rawDataJSON, _ := json.Marshal(metrics.RawData)
+
+	query := `
+		INSERT INTO podcast_show_metrics (
+			podcast_id, metric_date, total_plays, total_listeners, total_engaged_listeners,
+			total_views, total_downloads, followers_total, followers_gained, followers_lost,
+			subscribers_total, subscribers_gained, subscribers_lost, average_completion_rate,
+			total_comments, total_likes, total_shares, top_countries, top_cities, raw_data, updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (podcast_id, metric_date)
+		DO UPDATE SET
+			total_plays = excluded.total_plays,
+			total_listeners = excluded.total_listeners,
+			total_engaged_listeners = excluded.total_engaged_listeners,
+			total_views = excluded.total_views,
+			total_downloads = excluded.total_downloads,
+			followers_total = excluded.followers_total,
+			followers_gained = excluded.followers_gained,
+			followers_lost = excluded.followers_lost,
+			subscribers_total = excluded.subscribers_total,
+			subscribers_gained = excluded.subscribers_gained,
+			subscribers_lost = excluded.subscribers_lost,
+			average_completion_rate = excluded.average_completion_rate,
+			total_comments = excluded.total_comments,
+			total_likes = excluded.total_likes,
+			total_shares = excluded.total_shares,
+			top_countries = excluded.top_countries,
+			top_cities = excluded.top_cities,
+			raw_data = excluded.raw_data,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		metrics.PodcastID,
+		metrics.MetricDate.Format("2006-01-02"),
+		metrics.TotalPlays,
+		metrics.TotalListeners,
+		metrics.TotalEngagedListeners,
+		metrics.TotalViews,
+		metrics.TotalDownloads,
+		metrics.FollowersTotal,
+		metrics.FollowersGained,
+		metrics.FollowersLost,
+		metrics.SubscribersTotal,
+		metrics.SubscribersGained,
+		metrics.SubscribersLost,
+		metrics.AverageCompletionRate,
+		metrics.TotalComments,
+		metrics.TotalLikes,
+		metrics.TotalShares,
+		string(topCountriesJSON),
+		string(topCitiesJSON),
+		string(rawDataJSON),
+		time.Now(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to upsert show metrics: %w", err)
+	}
+
+	return nil
+}
+
+// InsertComment inserts a comment
+func (s *SQLiteStore) InsertComment(ctx context.Context, comment *scrapers.Comment) error {
+	query := `
+		INSERT INTO podcast_comments (
+			episode_id, platform_comment_id, author_name, author_id,
+			comment_text, likes_count, reply_count, parent_comment_id, published_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (episode_id, platform_comment_id) DO NOTHING
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		comment.EpisodeID,
+		comment.PlatformCommentID,
+		comment.AuthorName,
+		comment.AuthorID,
+		comment.CommentText,
+		comment.LikesCount,
+		comment.ReplyCount,
+		comment.ParentCommentID,
+		comment.PublishedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to insert comment: %w", err)
+	}
+
+	return nil
+}
+
+// RecordScraperRun records a scraper run
+func (s *SQLiteStore) RecordScraperRun(ctx context.Context, run *scrapers.ScraperRun) (int64, error) {
+	query := `
+		INSERT INTO podcast_scraper_runs (
+			platform, run_started_at, run_completed_at, status,
+			episodes_processed, metrics_collected, error_message
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.ExecContext(ctx, query,
+		run.Platform,
+		run.RunStartedAt,
+		run.RunCompletedAt,
+		run.Status,
+		run.EpisodesProcessed,
+		run.MetricsCollected,
+		run.ErrorMessage,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record scraper run: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record scraper run: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpdateScraperRun updates a scraper run status
+func (s *SQLiteStore) UpdateScraperRun(ctx context.Context, runID int64, run *scrapers.ScraperRun) error {
+	query := `
+		UPDATE podcast_scraper_runs
+		SET run_completed_at = ?,
+		    status = ?,
+		    episodes_processed = ?,
+		    metrics_collected = ?,
+		    error_message = ?
+		WHERE id = ?
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		run.RunCompletedAt,
+		run.Status,
+		run.EpisodesProcessed,
+		run.MetricsCollected,
+		run.ErrorMessage,
+		runID,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update scraper run: %w", err)
+	}
+
+	return nil
+}
+
+// RecordScraperError adds a per-item failure to the run's error ledger
+func (s *SQLiteStore) RecordScraperError(ctx context.Context, e *scrapers.ScraperError) error {
+	query := `
+		INSERT INTO podcast_scraper_errors (
+			run_id, platform, episode_id, episode_title, stage,
+			error_class, http_status, message, occurred_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		e.RunID,
+		e.Platform,
+		e.EpisodeID,
+		e.EpisodeTitle,
+		e.Stage,
+		e.ErrorClass,
+		e.HTTPStatus,
+		e.Message,
+		e.OccurredAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to record scraper error: %w", err)
+	}
+
+	return nil
+}
+
+// QuarantineMetrics stores metric rows that broke data quality rules, with
+// the reasons they were held back
+func (s *SQLiteStore) QuarantineMetrics(ctx context.Context, rows []*scrapers.QuarantinedMetric) error {
+	query := `
+		INSERT INTO podcast_metrics_quarantine (
+			run_id, platform, metric_kind, podcast_id, episode_id,
+			platform_episode_id, metric_date, reasons, row_data, quarantined_at
+		)
+		VALUES (?, ?, ?, NULLIF(?, 0), ?, NULLIF(?, ''), ?, ?, ?, ?)
+	`
+
+	for _, q := range rows {
+		reasonsJSON, _ := json.Marshal(q.Reasons)
+		rowJSON, err := json.Marshal(q.Row)
+		if err != nil {
+			return fmt.Errorf("failed to marshal quarantined row: %w", err)
+		}
+
+		var metricDate *string
+		if q.MetricDate != nil {
+			date := q.MetricDate.Format("2006-01-02")
+			metricDate = &date
+		}
+
+		_, err = s.db.ExecContext(ctx, query,
+			nullRunID(q.RunID),
+			q.Platform,
+			q.Kind,
+			q.PodcastID,
+			q.EpisodeID,
+			q.PlatformEpisodeID,
+			metricDate,
+			string(reasonsJSON),
+			string(rowJSON),
+			q.QuarantinedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to quarantine metrics: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+func openTestSQLiteStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+
+	store, err := OpenSQLiteStore(context.Background(), filepath.Join(t.TempDir(), "podcasts.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLiteStoreUpserts(t *testing.T) {
+	store := openTestSQLiteStore(t)
+	ctx := context.Background()
+
+	podcast := &scrapers.Podcast{ShowName: "Test Show", Platform: scrapers.PlatformSpotify, PlatformID: "show-1"}
+	podcastID, err := store.UpsertPodcast(ctx, podcast)
+	if err != nil {
+		t.Fatalf("UpsertPodcast() error = %v", err)
+	}
+	if again, err := store.UpsertPodcast(ctx, podcast); err != nil || again != podcastID {
+		t.Fatalf("second UpsertPodcast() = %d, %v, want %d", again, err, podcastID)
+	}
+
+	episodeID, err := store.UpsertEpisode(ctx, &scrapers.Episode{
+		PodcastID:         podcastID,
+		EpisodeTitle:      "Pilot",
+		PlatformEpisodeID: "ep-1",
+		PublishDate:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("UpsertEpisode() error = %v", err)
+	}
+	if id, err := store.LookupEpisodeID(ctx, podcastID, "ep-1"); err != nil || id != episodeID {
+		t.Errorf("LookupEpisodeID() = %d, %v, want %d", id, err, episodeID)
+	}
+
+	// The second write of a day replaces the first
+	day := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	for _, plays := range []int64{10, 12} {
+		if err := store.UpsertEpisodeMetrics(ctx, &scrapers.EpisodeMetrics{EpisodeID: episodeID, MetricDate: day, Plays: plays}); err != nil {
+			t.Fatalf("UpsertEpisodeMetrics() error = %v", err)
+		}
+	}
+	var rows int
+	var plays int64
+	if err := store.db.QueryRowContext(ctx, `SELECT COUNT(*), MAX(plays) FROM podcast_episode_metrics`).Scan(&rows, &plays); err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	if rows != 1 || plays != 12 {
+		t.Errorf("metrics = %d rows with %d plays, want 1 row with 12", rows, plays)
+	}
+}
+
+func TestSQLiteStoreRuns(t *testing.T) {
+	store := openTestSQLiteStore(t)
+	ctx := context.Background()
+
+	runID, err := store.RecordScraperRun(ctx, &scrapers.ScraperRun{
+		Platform:     scrapers.PlatformSpotify,
+		RunStartedAt: time.Now(),
+		Status:       scrapers.RunStatusRunning,
+	})
+	if err != nil {
+		t.Fatalf("RecordScraperRun() error = %v", err)
+	}
+
+	completedAt := time.Now()
+	err = store.UpdateScraperRun(ctx, runID, &scrapers.ScraperRun{
+		RunCompletedAt:   &completedAt,
+		Status:           scrapers.RunStatusCompleted,
+		MetricsCollected: 3,
+	})
+	if err != nil {
+		t.Fatalf("UpdateScraperRun() error = %v", err)
+	}
+
+	var status string
+	var metrics int
+	if err := store.db.QueryRowContext(ctx, `SELECT status, metrics_collected FROM podcast_scraper_runs WHERE id = ?`, runID).Scan(&status, &metrics); err != nil {
+		t.Fatalf("failed to read run: %v", err)
+	}
+	if status != scrapers.RunStatusCompleted || metrics != 3 {
+		t.Errorf("run = %s with %d metrics, want completed with 3", status, metrics)
+	}
+}
+
+func TestSQLiteStoreForeignKeys(t *testing.T) {
+	store := openTestSQLiteStore(t)
+
+	// Foreign keys are enforced, so an episode needs its podcast
+	_, err := store.UpsertEpisode(context.Background(), &scrapers.Episode{PodcastID: 42, EpisodeTitle: "Orphan", PlatformEpisodeID: "ep-1"})
+	if err == nil {
+		t.Error("UpsertEpisode() of an unknown podcast succeeded, want a foreign key error")
+	}
+}
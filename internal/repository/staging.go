@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// Columns copied from a run's staging tables into the raw tables on publish.
+// The leading columns of each list form the table's unique key.
+var (
+	showMetricsPublishColumns = []string{
+		"podcast_id", "metric_date", "total_plays", "total_listeners", "total_engaged_listeners",
+		"total_views", "total_downloads", "followers_total", "followers_gained", "followers_lost",
+		"subscribers_total", "subscribers_gained", "subscribers_lost", "average_completion_rate",
+		"total_comments", "total_likes", "total_shares", "top_countries", "top_cities", "raw_data", "updated_at",
+	}
+	commentsPublishColumns = []string{
+		"episode_id", "platform_comment_id", "author_name", "author_id",
+		"comment_text", "likes_count", "reply_count", "parent_comment_id", "published_at",
+	}
+)
+
+// stagingTables returns the per-run staging table for each raw table a
+// staged run writes to
+func stagingTables(runID int64) map[string]string {
+	return map[string]string{
+		"raw.podcast_episode_metrics": fmt.Sprintf("raw.podcast_episode_metrics_run_%d", runID),
+		"raw.podcast_show_metrics":    fmt.Sprintf("raw.podcast_show_metrics_run_%d", runID),
+		"raw.podcast_comments":        fmt.Sprintf("raw.podcast_comments_run_%d", runID),
+	}
+}
+
+// StageRun creates empty staging tables for a run and returns a store whose
+// metric and comment writes go to them. Podcasts, episodes and the run record
+// are still written directly, so IDs stay stable. Nothing in the staging
+// tables is visible to dashboards until PublishRun.
+func (r *PodcastRepository) StageRun(ctx context.Context, runID int64) (Store, error) {
+	tables := stagingTables(runID)
+
+	for raw, staging := range tables {
+		query := fmt.Sprintf(`CREATE UNLOGGED TABLE IF NOT EXISTS %s (LIKE %s INCLUDING DEFAULTS INCLUDING INDEXES)`, staging, raw)
+		if _, err := r.db.ExecContext(ctx, query); err != nil {
+			return nil, fmt.Errorf("failed to create staging table %s: %w", staging, err)
+		}
+	}
+
+	staged := *r
+	staged.episodeMetricsTable = tables["raw.podcast_episode_metrics"]
+	staged.showMetricsTable = tables["raw.podcast_show_metrics"]
+	staged.commentsTable = tables["raw.podcast_comments"]
+	return &staged, nil
+}
+
+// PublishRun merges a run's staged rows into the raw tables and drops the
+// staging tables, all in one transaction, so readers see either none or all
+// of the run's data
+func (r *PodcastRepository) PublishRun(ctx context.Context, runID int64) error {
+	tables := stagingTables(runID)
+
+	return r.InTx(ctx, func(tx Store) error {
+		db := tx.(*PodcastRepository).db
+
+		merges := []string{
+			mergeQuery("raw.podcast_episode_metrics", tables["raw.podcast_episode_metrics"], episodeMetricsStagingColumns, 2),
+			mergeQuery("raw.podcast_show_metrics", tables["raw.podcast_show_metrics"], showMetricsPublishColumns, 2),
+			fmt.Sprintf(`
+				INSERT INTO raw.podcast_comments (%[1]s)
+				SELECT %[1]s FROM %[2]s
+				ON CONFLICT (episode_id, platform_comment_id) DO NOTHING
+			`, strings.Join(commentsPublishColumns, ", "), tables["raw.podcast_comments"]),
+		}
+
+		for _, query := range merges {
+			if _, err := db.ExecContext(ctx, query); err != nil {
+				return fmt.Errorf("failed to publish run %d: %w", runID, err)
+			}
+		}
+
+		return dropStagingTables(ctx, db, tables)
+	})
+}
+
+// DiscardRun drops a run's staging tables without publishing them
+func (r *PodcastRepository) DiscardRun(ctx context.Context, runID int64) error {
+	return dropStagingTables(ctx, r.db, stagingTables(runID))
+}
+
+// DropOrphanedStaging drops the staging tables of runs that are no longer
+// running, left behind when a staged run's process died before publishing or
+// discarding them, and returns the IDs of those runs
+func (r *PodcastRepository) DropOrphanedStaging(ctx context.Context) ([]int64, error) {
+	query := `
+		SELECT DISTINCT staged.run_id
+		FROM (
+			SELECT substring(tablename FROM '_run_([0-9]+)$')::BIGINT AS run_id
+			FROM pg_tables
+			WHERE schemaname = 'raw'
+			  AND tablename ~ '^podcast_(episode_metrics|show_metrics|comments)_run_[0-9]+$'
+		) staged
+		LEFT JOIN raw.podcast_scraper_runs r ON r.id = staged.run_id
+		WHERE r.status IS DISTINCT FROM $1
+		ORDER BY staged.run_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, scrapers.RunStatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orphaned staging tables: %w", err)
+	}
+	defer rows.Close()
+
+	var runIDs []int64
+	for rows.Next() {
+		var runID int64
+		if err := rows.Scan(&runID); err != nil {
+			return nil, fmt.Errorf("failed to scan staged run: %w", err)
+		}
+		runIDs = append(runIDs, runID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list orphaned staging tables: %w", err)
+	}
+
+	for _, runID := range runIDs {
+		if err := dropStagingTables(ctx, r.db, stagingTables(runID)); err != nil {
+			return nil, err
+		}
+	}
+	return runIDs, nil
+}
+
+func dropStagingTables(ctx context.Context, db dbtx, tables map[string]string) error {
+	for _, staging := range tables {
+		if _, err := db.ExecContext(ctx, `DROP TABLE IF EXISTS `+staging); err != nil {
+			return fmt.Errorf("failed to drop staging table %s: %w", staging, err)
+		}
+	}
+	return nil
+}
+
+// mergeQuery builds an upsert of every row of src into dst, where the first
+// keyColumns of columns form dst's unique key
+func mergeQuery(dst, src string, columns []string, keyColumns int) string {
+	set := make([]string, 0, len(columns)-keyColumns)
+	for _, c := range columns[keyColumns:] {
+		set = append(set, fmt.Sprintf("%s = EXCLUDED.%s", c, c))
+	}
+
+	return fmt.Sprintf(`
+		INSERT INTO %[1]s (%[3]s)
+		SELECT %[3]s FROM %[2]s
+		ON CONFLICT (%[4]s)
+		DO UPDATE SET %[5]s
+	`, dst, src, strings.Join(columns, ", "), strings.Join(columns[:keyColumns], ", "), strings.Join(set, ", "))
+}
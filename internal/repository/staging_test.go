@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// stageTestRun records a running run and stages one metrics row for it
+func stageTestRun(t *testing.T, repo *PodcastRepository, episodeID int64, plays int64) int64 {
+	t.Helper()
+	ctx := context.Background()
+
+	runID, err := repo.RecordScraperRun(ctx, &scrapers.ScraperRun{
+		Platform:     scrapers.PlatformSpotify,
+		RunStartedAt: time.Now(),
+		Status:       scrapers.RunStatusRunning,
+	})
+	if err != nil {
+		t.Fatalf("RecordScraperRun() error = %v", err)
+	}
+
+	staged, err := repo.StageRun(ctx, runID)
+	if err != nil {
+		t.Fatalf("StageRun() error = %v", err)
+	}
+	err = staged.UpsertEpisodeMetrics(ctx, &scrapers.EpisodeMetrics{
+		EpisodeID:  episodeID,
+		MetricDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Plays:      plays,
+		RunID:      runID,
+	})
+	if err != nil {
+		t.Fatalf("staged UpsertEpisodeMetrics() error = %v", err)
+	}
+	return runID
+}
+
+func countRows(t *testing.T, repo *PodcastRepository, query string) int {
+	t.Helper()
+	var n int
+	if err := repo.db.QueryRowContext(context.Background(), query).Scan(&n); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	return n
+}
+
+func TestStagedRunPublishAndDiscard(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+	_, episodeID := createTestEpisode(t, repo)
+
+	const stagingTableCount = `SELECT COUNT(*) FROM pg_tables WHERE schemaname = 'raw' AND tablename ~ '_run_[0-9]+$'`
+	const metricsCount = `SELECT COUNT(*) FROM raw.podcast_episode_metrics`
+
+	// A discarded run leaves nothing behind
+	runID := stageTestRun(t, repo, episodeID, 10)
+	if got := countRows(t, repo, metricsCount); got != 0 {
+		t.Fatalf("raw metrics before publishing = %d, want 0", got)
+	}
+	if err := repo.DiscardRun(ctx, runID); err != nil {
+		t.Fatalf("DiscardRun() error = %v", err)
+	}
+	if got := countRows(t, repo, stagingTableCount); got != 0 {
+		t.Errorf("staging tables after discard = %d, want 0", got)
+	}
+	if got := countRows(t, repo, metricsCount); got != 0 {
+		t.Errorf("raw metrics after discard = %d, want 0", got)
+	}
+
+	// A published run's rows land in the raw tables
+	runID = stageTestRun(t, repo, episodeID, 12)
+	if err := repo.PublishRun(ctx, runID); err != nil {
+		t.Fatalf("PublishRun() error = %v", err)
+	}
+	if got := countRows(t, repo, stagingTableCount); got != 0 {
+		t.Errorf("staging tables after publish = %d, want 0", got)
+	}
+	if got := countRows(t, repo, `SELECT COUNT(*) FROM raw.podcast_episode_metrics WHERE plays = 12`); got != 1 {
+		t.Errorf("published metrics = %d, want 1", got)
+	}
+}
+
+func TestDropOrphanedStaging(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+	_, episodeID := createTestEpisode(t, repo)
+
+	running := stageTestRun(t, repo, episodeID, 10)
+	orphaned := stageTestRun(t, repo, episodeID, 11)
+	completedAt := time.Now()
+	err := repo.UpdateScraperRun(ctx, orphaned, &scrapers.ScraperRun{RunCompletedAt: &completedAt, Status: scrapers.RunStatusAbandoned})
+	if err != nil {
+		t.Fatalf("UpdateScraperRun() error = %v", err)
+	}
+
+	dropped, err := repo.DropOrphanedStaging(ctx)
+	if err != nil {
+		t.Fatalf("DropOrphanedStaging() error = %v", err)
+	}
+	if len(dropped) != 1 || dropped[0] != orphaned {
+		t.Errorf("DropOrphanedStaging() = %v, want [%d]", dropped, orphaned)
+	}
+
+	// The running run keeps its staging tables
+	if err := repo.PublishRun(ctx, running); err != nil {
+		t.Errorf("PublishRun() of the running run error = %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// Store persists scraped podcasts, episodes, metrics and run bookkeeping.
+// PodcastRepository is the production Postgres implementation, SQLiteStore
+// keeps everything in a local file and MemoryStore is for tests.
+type Store interface {
+	UpsertPodcast(ctx context.Context, podcast *scrapers.Podcast) (int64, error)
+	UpsertEpisode(ctx context.Context, episode *scrapers.Episode) (int64, error)
+	UpsertEpisodeMetrics(ctx context.Context, metrics *scrapers.EpisodeMetrics) error
+
+	// UpsertEpisodeMetricsBatch writes many rows at once and returns one
+	// error per input row, nil for rows that were written
+	UpsertEpisodeMetricsBatch(ctx context.Context, metrics []*scrapers.EpisodeMetrics) []error
+
+	UpsertShowMetrics(ctx context.Context, metrics *scrapers.ShowMetrics) error
+	InsertComment(ctx context.Context, comment *scrapers.Comment) error
+	RecordScraperRun(ctx context.Context, run *scrapers.ScraperRun) (int64, error)
+	UpdateScraperRun(ctx context.Context, runID int64, run *scrapers.ScraperRun) error
+	RecordScraperError(ctx context.Context, e *scrapers.ScraperError) error
+
+	// LookupEpisodeID returns the ID of an existing episode, or ErrNotFound
+	LookupEpisodeID(ctx context.Context, podcastID int64, platformEpisodeID string) (int64, error)
+
+	// InTx runs fn against a store whose writes commit together if fn returns
+	// nil and are discarded otherwise
+	InTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+var (
+	_ Store = (*PodcastRepository)(nil)
+	_ Store = (*SQLiteStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
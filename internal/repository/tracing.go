@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Database systems named on statement spans
+const (
+	systemPostgres = "postgresql"
+	systemSQLite   = "sqlite"
+)
+
+// tracedDB records a span for every statement run through db
+type tracedDB struct {
+	db     dbtx
+	system string
+}
+
+func traceDB(db dbtx, system string) dbtx {
+	return &tracedDB{db: db, system: system}
+}
+
+func (t *tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := t.start(ctx, query)
+	res, err := t.db.ExecContext(ctx, query, args...)
+	if err == nil {
+		if n, rowsErr := res.RowsAffected(); rowsErr == nil {
+			span.SetAttributes(attribute.Int64("db.rows_affected", n))
+		}
+	}
+	scrapers.EndSpan(span, err)
+	return res, err
+}
+
+func (t *tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := t.start(ctx, query)
+	stmt, err := t.db.PrepareContext(ctx, query)
+	scrapers.EndSpan(span, err)
+	return stmt, err
+}
+
+func (t *tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := t.start(ctx, query)
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	scrapers.EndSpan(span, err)
+	return rows, err
+}
+
+func (t *tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := t.start(ctx, query)
+	row := t.db.QueryRowContext(ctx, query, args...)
+	scrapers.EndSpan(span, row.Err())
+	return row
+}
+
+// start opens a client span named after the statement's operation and table
+func (t *tracedDB) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation, table := describeStatement(query)
+
+	name := operation
+	if table != "" {
+		name += " " + table
+	}
+
+	return scrapers.Tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", t.system),
+			attribute.String("db.operation", operation),
+			attribute.String("db.sql.table", table),
+			attribute.String("db.statement", strings.Join(strings.Fields(query), " ")),
+		))
+}
+
+// statementTable matches the table a statement writes to or reads from first
+var statementTable = regexp.MustCompile(`(?i)\b(?:insert\s+into|update|delete\s+from|from|copy)\s+"?([a-z_][a-z0-9_.]*)`)
+
+// describeStatement returns a statement's leading keyword and the first
+// table it names
+func describeStatement(query string) (operation, table string) {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	operation = strings.ToUpper(fields[0])
+
+	if m := statementTable.FindStringSubmatch(query); m != nil {
+		table = m[1]
+	}
+	return operation, table
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestDescribeStatement(t *testing.T) {
+	tests := []struct {
+		query         string
+		wantOperation string
+		wantTable     string
+	}{
+		{query: "INSERT INTO raw.podcasts (show_name) VALUES ($1)", wantOperation: "INSERT", wantTable: "raw.podcasts"},
+		{query: "\n\t\tupdate raw.podcast_scraper_runs SET status = $1", wantOperation: "UPDATE", wantTable: "raw.podcast_scraper_runs"},
+		{query: "DELETE FROM raw.podcast_scraper_errors WHERE occurred_at < $1", wantOperation: "DELETE", wantTable: "raw.podcast_scraper_errors"},
+		{query: "SELECT id FROM podcasts WHERE platform = ?", wantOperation: "SELECT", wantTable: "podcasts"},
+		{query: `COPY "raw_metrics_tmp" FROM STDIN`, wantOperation: "COPY", wantTable: "raw_metrics_tmp"},
+		{query: "SELECT 1", wantOperation: "SELECT", wantTable: ""},
+		{query: "  ", wantOperation: "", wantTable: ""},
+	}
+
+	for _, tt := range tests {
+		operation, table := describeStatement(tt.query)
+		if operation != tt.wantOperation || table != tt.wantTable {
+			t.Errorf("describeStatement(%q) = %q, %q, want %q, %q", tt.query, operation, table, tt.wantOperation, tt.wantTable)
+		}
+	}
+}
+
+func TestStoreWritesAreTraced(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := scrapers.Tracer
+	scrapers.Tracer = provider.Tracer("test")
+	t.Cleanup(func() { scrapers.Tracer = previous })
+
+	store := openTestSQLiteStore(t)
+	ctx, parent := scrapers.Tracer.Start(context.Background(), "collectForPlatform")
+	_, err := store.UpsertPodcast(ctx, &scrapers.Podcast{
+		ShowName:   "Test Show",
+		Platform:   scrapers.PlatformSpotify,
+		PlatformID: "show-1",
+	})
+	parent.End()
+	if err != nil {
+		t.Fatalf("UpsertPodcast() error = %v", err)
+	}
+
+	var found bool
+	for _, span := range recorder.Ended() {
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			continue
+		}
+		found = true
+		attrs := attribute.NewSet(span.Attributes()...)
+		if v, _ := attrs.Value("db.system"); v.AsString() != systemSQLite {
+			t.Errorf("span %q db.system = %q, want %q", span.Name(), v.AsString(), systemSQLite)
+		}
+		if v, _ := attrs.Value("db.sql.table"); v.AsString() != "podcasts" {
+			t.Errorf("span %q db.sql.table = %q, want podcasts", span.Name(), v.AsString())
+		}
+	}
+	if !found {
+		t.Error("UpsertPodcast() recorded no statement span under the caller's span")
+	}
+}
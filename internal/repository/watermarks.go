@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// Watermark is the collection high-water mark for an episode, or for the
+// show when EpisodeID is 0
+type Watermark struct {
+	Platform         scrapers.Platform
+	PodcastID        int64
+	EpisodeID        int64
+	FinalMetricDate  time.Time
+	LastActivityDate *time.Time
+	LastPolledAt     time.Time
+}
+
+// Watermarks returns the high-water marks for a podcast keyed by episode ID
+func (r *PodcastRepository) Watermarks(ctx context.Context, platform scrapers.Platform, podcastID int64) (map[int64]*Watermark, error) {
+	query := `
+		SELECT episode_id, final_metric_date, last_activity_date, last_polled_at
+		FROM raw.podcast_collection_watermarks
+		WHERE platform = $1 AND podcast_id = $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, platform, podcastID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load watermarks: %w", err)
+	}
+	defer rows.Close()
+
+	marks := make(map[int64]*Watermark)
+	for rows.Next() {
+		wm := &Watermark{Platform: platform, PodcastID: podcastID}
+		if err := rows.Scan(&wm.EpisodeID, &wm.FinalMetricDate, &wm.LastActivityDate, &wm.LastPolledAt); err != nil {
+			return nil, fmt.Errorf("failed to scan watermark: %w", err)
+		}
+		marks[wm.EpisodeID] = wm
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load watermarks: %w", err)
+	}
+
+	return marks, nil
+}
+
+// SaveWatermark advances a high-water mark. Dates never move backwards, so
+// a restatement run over older dates cannot undo progress.
+func (r *PodcastRepository) SaveWatermark(ctx context.Context, wm *Watermark) error {
+	query := `
+		INSERT INTO raw.podcast_collection_watermarks (
+			platform, podcast_id, episode_id, final_metric_date,
+			last_activity_date, last_polled_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (platform, podcast_id, episode_id)
+		DO UPDATE SET
+			final_metric_date = GREATEST(raw.podcast_collection_watermarks.final_metric_date, EXCLUDED.final_metric_date),
+			last_activity_date = GREATEST(raw.podcast_collection_watermarks.last_activity_date, EXCLUDED.last_activity_date),
+			last_polled_at = EXCLUDED.last_polled_at,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		wm.Platform,
+		wm.PodcastID,
+		wm.EpisodeID,
+		wm.FinalMetricDate,
+		wm.LastActivityDate,
+		wm.LastPolledAt,
+		time.Now(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to save watermark: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package schedule computes when scheduled collections are due from cron
+// expressions evaluated in a timezone.
+package schedule
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+	// Timezones resolve in images without a zoneinfo database
+	_ "time/tzdata"
+
+	"github.com/robfig/cron/v3"
+)
+
+// parser accepts standard five-field expressions and descriptors such as
+// @hourly, @daily and @every 6h
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	spec     string
+	schedule cron.Schedule
+	location *time.Location
+}
+
+// Parse parses a cron expression such as "0 6 * * *" evaluated in location.
+// An expression may name its own timezone with a CRON_TZ= prefix.
+func Parse(spec string, location *time.Location) (*Schedule, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	s, err := parser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	return &Schedule{spec: spec, schedule: s, location: location}, nil
+}
+
+// String returns the expression s was parsed from
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first time s is due strictly after t
+func (s *Schedule) Next(t time.Time) time.Time {
+	return s.schedule.Next(t.In(s.location))
+}
+
+// Missed reports whether s was due between last and now, that is whether a
+// run that started at last has since been overtaken by a tick
+func (s *Schedule) Missed(last, now time.Time) bool {
+	return !s.Next(last).After(now)
+}
+
+// Period returns the gap between the next two times s is due after t
+func (s *Schedule) Period(t time.Time) time.Duration {
+	next := s.Next(t)
+	return s.Next(next).Sub(next)
+}
+
+// Jitter returns a random delay in [0, max) to spread out runs that share a
+// schedule; zero when max is not positive
+func Jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
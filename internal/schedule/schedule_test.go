@@ -0,0 +1,134 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, spec := range []string{"0 6 * * *", "@hourly", "@every 6h", "CRON_TZ=America/Denver 0 6 * * *"} {
+		if _, err := Parse(spec, nil); err != nil {
+			t.Errorf("Parse(%q) error = %v", spec, err)
+		}
+	}
+
+	for _, spec := range []string{"", "0 6 * *", "0 0 6 * * *", "@fortnightly"} {
+		if _, err := Parse(spec, nil); err == nil {
+			t.Errorf("Parse(%q) error = nil, want an invalid schedule error", spec)
+		}
+	}
+}
+
+func TestNextInLocation(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		spec     string
+		location *time.Location
+		after    time.Time
+		want     time.Time
+	}{
+		{
+			name:  "utc daily",
+			spec:  "0 6 * * *",
+			after: time.Date(2026, 3, 1, 7, 0, 0, 0, time.UTC),
+			want:  time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "daily in the schedule's location",
+			spec:     "0 6 * * *",
+			location: denver,
+			after:    time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 1, 15, 13, 0, 0, 0, time.UTC), // 06:00 MST
+		},
+		{
+			name:     "daylight saving time",
+			spec:     "0 6 * * *",
+			location: denver,
+			after:    time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 7, 15, 12, 0, 0, 0, time.UTC), // 06:00 MDT
+		},
+		{
+			name:     "CRON_TZ overrides the location",
+			spec:     "CRON_TZ=UTC 0 6 * * *",
+			location: denver,
+			after:    time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 1, 15, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "strictly after a due time",
+			spec:  "@hourly",
+			after: time.Date(2026, 3, 1, 7, 0, 0, 0, time.UTC),
+			want:  time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.spec, tt.location)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := s.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.after, got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMissed(t *testing.T) {
+	s, err := Parse("@hourly", nil)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	last := time.Date(2026, 3, 1, 7, 5, 0, 0, time.UTC)
+	if s.Missed(last, last.Add(30*time.Minute)) {
+		t.Error("Missed() = true before the next tick")
+	}
+	if !s.Missed(last, time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Error("Missed() = false at the next tick")
+	}
+	if !s.Missed(last, last.Add(5*time.Hour)) {
+		t.Error("Missed() = false after downtime")
+	}
+}
+
+func TestPeriod(t *testing.T) {
+	tests := []struct {
+		spec string
+		want time.Duration
+	}{
+		{spec: "@hourly", want: time.Hour},
+		{spec: "@every 6h", want: 6 * time.Hour},
+		{spec: "0 6 * * *", want: 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.spec, nil)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.spec, err)
+		}
+		if got := s.Period(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)); got != tt.want {
+			t.Errorf("Period() of %q = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if got := Jitter(0); got != 0 {
+		t.Errorf("Jitter(0) = %v, want 0", got)
+	}
+	if got := Jitter(-time.Minute); got != 0 {
+		t.Errorf("Jitter(-1m) = %v, want 0", got)
+	}
+	for i := 0; i < 100; i++ {
+		if got := Jitter(time.Minute); got < 0 || got >= time.Minute {
+			t.Fatalf("Jitter(1m) = %v, want [0, 1m)", got)
+		}
+	}
+}
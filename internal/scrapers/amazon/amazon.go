@@ -15,8 +15,10 @@ import (
 
 // AmazonMusicScraper scrapes metrics from Amazon Music for Podcasters
 type AmazonMusicScraper struct {
-	httpClient *http.Client
-	baseURL    string
+	httpClient      *http.Client
+	baseURL         string
+	cookieExpiresAt *time.Time
+	archiver        scrapers.ResponseArchiver
 }
 
 // Config holds configuration for Amazon Music scraper
@@ -24,6 +26,15 @@ type Config struct {
 	// Amazon Music for Podcasters authentication
 	// Typically requires Amazon account credentials or session cookies
 	SessionCookie string
+
+	// SessionCookieExpiresAt is the session cookie expiry copied from the browser, if known
+	SessionCookieExpiresAt *time.Time
+
+	// Archiver stores every successful response body when set
+	Archiver scrapers.ResponseArchiver
+
+	// Observer receives the outcome of every API request when set
+	Observer scrapers.RequestObserver
 }
 
 // NewScraper creates a new Amazon Music scraper
@@ -35,10 +46,13 @@ func NewScraper(cfg Config) (*AmazonMusicScraper, error) {
 
 	return &AmazonMusicScraper{
 		httpClient: &http.Client{
-			Jar:     jar,
-			Timeout: 30 * time.Second,
+			Jar:       jar,
+			Timeout:   30 * time.Second,
+			Transport: scrapers.NewTransport(scrapers.PlatformAmazonMusic, cfg.Observer),
 		},
-		baseURL: "https://podcasters.amazon.com",
+		baseURL:         "https://podcasters.amazon.com",
+		cookieExpiresAt: cfg.SessionCookieExpiresAt,
+		archiver:        cfg.Archiver,
 	}, nil
 }
 
@@ -47,6 +61,45 @@ func (s *AmazonMusicScraper) GetPlatform() scrapers.Platform {
 	return scrapers.PlatformAmazonMusic
 }
 
+// CheckCredentials verifies the session by listing the account's podcasts
+func (s *AmazonMusicScraper) CheckCredentials(ctx context.Context) *scrapers.CredentialCheck {
+	check := &scrapers.CredentialCheck{
+		Platform:  scrapers.PlatformAmazonMusic,
+		ExpiresAt: s.cookieExpiresAt,
+	}
+
+	if s.cookieExpiresAt != nil && time.Now().After(*s.cookieExpiresAt) {
+		check.Status = scrapers.CredentialExpired
+		check.Message = fmt.Sprintf("session cookie expired at %s", s.cookieExpiresAt.Format(time.RFC3339))
+		return check
+	}
+
+	apiURL := fmt.Sprintf("%s/api/podcasts", s.baseURL)
+
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointAuth), "GET", apiURL, nil)
+	if err != nil {
+		check.Status = scrapers.CredentialError
+		check.Message = fmt.Sprintf("failed to create request: %v", err)
+		return check
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		check.Status = scrapers.CredentialError
+		check.Message = fmt.Sprintf("request failed: %v", err)
+		return check
+	}
+	defer resp.Body.Close()
+
+	check.HTTPStatus = resp.StatusCode
+	check.Status = scrapers.ClassifyHTTPStatus(resp.StatusCode)
+	if check.Status != scrapers.CredentialValid {
+		check.Message = fmt.Sprintf("API returned status %d", resp.StatusCode)
+	}
+
+	return check
+}
+
 // authenticate logs into Amazon Music for Podcasters
 func (s *AmazonMusicScraper) authenticate(ctx context.Context) error {
 	// Amazon Music for Podcasters authentication flow
@@ -81,7 +134,7 @@ func (s *AmazonMusicScraper) FetchEpisodes(ctx context.Context, podcast *scraper
 	// GET /v1.0/podcasts/{podcastId}/episodes
 	apiURL := fmt.Sprintf("%s/api/podcasts/%s/episodes", s.baseURL, podcast.PlatformID)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointEpisodes), "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -93,7 +146,7 @@ func (s *AmazonMusicScraper) FetchEpisodes(ctx context.Context, podcast *scraper
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode}
 	}
 
 	var episodes []*scrapers.Episode
@@ -113,7 +166,7 @@ func (s *AmazonMusicScraper) FetchEpisodeMetrics(ctx context.Context, episode *s
 	// Provides: Starts, Plays, Listeners, Engaged Listeners, Followers
 	apiURL := fmt.Sprintf("%s/api/analytics/episode/%s", s.baseURL, episode.PlatformEpisodeID)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, nil)
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointEpisodeMetrics), "POST", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -140,11 +193,42 @@ func (s *AmazonMusicScraper) FetchEpisodeMetrics(ctx context.Context, episode *s
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	body, key, err := scrapers.ReadResponse(ctx, s.archiver, resp, &scrapers.Response{
+		Platform:          scrapers.PlatformAmazonMusic,
+		Endpoint:          scrapers.EndpointEpisodeMetrics,
+		PodcastID:         episode.PodcastID,
+		EpisodeID:         episode.ID,
+		PlatformEpisodeID: episode.PlatformEpisodeID,
+		StartDate:         startDate,
+		EndDate:           endDate,
+		Shape:             episodeMetricsShape,
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	metrics, err := s.parseEpisodeMetrics(episode, startDate, endDate, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// The archive holds the response; rows only point at it
+	if key != "" {
+		for _, metric := range metrics {
+			metric.RawData = scrapers.ArchiveRef(key)
+		}
+	}
+
+	return metrics, nil
+}
+
+// parseEpisodeMetrics parses an Amazon Music episode analytics response
+func (s *AmazonMusicScraper) parseEpisodeMetrics(episode *scrapers.Episode, startDate, endDate time.Time, body []byte) ([]*scrapers.EpisodeMetrics, error) {
 	var metricsData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&metricsData); err != nil {
+	if err := json.Unmarshal(body, &metricsData); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -153,7 +237,7 @@ func (s *AmazonMusicScraper) FetchEpisodeMetrics(ctx context.Context, episode *s
 	metrics := []*scrapers.EpisodeMetrics{
 		{
 			EpisodeID:  episode.ID,
-			MetricDate: time.Now(),
+			MetricDate: endDate,
 			RawData:    metricsData,
 			// Map Amazon metrics to our schema
 		},
@@ -172,7 +256,7 @@ func (s *AmazonMusicScraper) FetchShowMetrics(ctx context.Context, podcast *scra
 	// Provides trends and overview metrics
 	apiURL := fmt.Sprintf("%s/api/analytics/show/%s", s.baseURL, podcast.PlatformID)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, nil)
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointShowMetrics), "POST", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -197,18 +281,46 @@ func (s *AmazonMusicScraper) FetchShowMetrics(ctx context.Context, podcast *scra
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode}
+	}
+
+	body, key, err := scrapers.ReadResponse(ctx, s.archiver, resp, &scrapers.Response{
+		Platform:  scrapers.PlatformAmazonMusic,
+		Endpoint:  scrapers.EndpointShowMetrics,
+		PodcastID: podcast.ID,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Shape:     showMetricsShape,
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	metrics, err := s.parseShowMetrics(podcast, startDate, endDate, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if key != "" {
+		for _, metric := range metrics {
+			metric.RawData = scrapers.ArchiveRef(key)
+		}
+	}
+
+	return metrics, nil
+}
+
+// parseShowMetrics parses an Amazon Music show analytics response
+func (s *AmazonMusicScraper) parseShowMetrics(podcast *scrapers.Podcast, startDate, endDate time.Time, body []byte) ([]*scrapers.ShowMetrics, error) {
 	var metricsData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&metricsData); err != nil {
+	if err := json.Unmarshal(body, &metricsData); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	metrics := []*scrapers.ShowMetrics{
 		{
 			PodcastID:  podcast.ID,
-			MetricDate: time.Now(),
+			MetricDate: endDate,
 			RawData:    metricsData,
 		},
 	}
@@ -216,6 +328,17 @@ func (s *AmazonMusicScraper) FetchShowMetrics(ctx context.Context, podcast *scra
 	return metrics, nil
 }
 
+// ParseEpisodeMetrics implements scrapers.ResponseParser. The response is
+// stored as raw data only, so there are no typed fields to rebuild yet.
+func (s *AmazonMusicScraper) ParseEpisodeMetrics(episode *scrapers.Episode, startDate, endDate time.Time, body []byte) ([]*scrapers.EpisodeMetrics, error) {
+	return nil, scrapers.ErrParseUnsupported
+}
+
+// ParseShowMetrics implements scrapers.ResponseParser; see ParseEpisodeMetrics
+func (s *AmazonMusicScraper) ParseShowMetrics(podcast *scrapers.Podcast, startDate, endDate time.Time, body []byte) ([]*scrapers.ShowMetrics, error) {
+	return nil, scrapers.ErrParseUnsupported
+}
+
 // FetchComments - Amazon Music for Podcasters doesn't support comments
 func (s *AmazonMusicScraper) FetchComments(ctx context.Context, episode *scrapers.Episode) ([]*scrapers.Comment, error) {
 	// Amazon Music doesn't have podcast comments
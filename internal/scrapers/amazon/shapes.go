@@ -0,0 +1,25 @@
+package amazon
+
+import "github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+
+// Declared shapes of the Amazon Music for Podcasters analytics responses.
+// The parsers keep these responses as raw data only, so the shapes list the
+// metrics each request asks for; anything else the API sends is reported as
+// new fields, which is how the real format shows up once collected.
+
+// episodeMetricsShape is an episode analytics response
+var episodeMetricsShape = scrapers.Shape{
+	"starts":            scrapers.Optional(scrapers.JSONNumber),
+	"plays":             scrapers.Optional(scrapers.JSONNumber),
+	"listeners":         scrapers.Optional(scrapers.JSONNumber),
+	"engaged_listeners": scrapers.Optional(scrapers.JSONNumber),
+}
+
+// showMetricsShape is a show analytics response, which adds followers
+var showMetricsShape = scrapers.Shape{
+	"starts":            scrapers.Optional(scrapers.JSONNumber),
+	"plays":             scrapers.Optional(scrapers.JSONNumber),
+	"listeners":         scrapers.Optional(scrapers.JSONNumber),
+	"engaged_listeners": scrapers.Optional(scrapers.JSONNumber),
+	"followers":         scrapers.Optional(scrapers.JSONNumber),
+}
@@ -20,12 +20,19 @@ type ApplePodcastsScraper struct {
 	password   string
 	httpClient *http.Client
 	baseURL    string
+	archiver   scrapers.ResponseArchiver
 }
 
 // Config holds configuration for Apple Podcasts scraper
 type Config struct {
 	Email    string
 	Password string
+
+	// Archiver stores every successful response body when set
+	Archiver scrapers.ResponseArchiver
+
+	// Observer receives the outcome of every API request when set
+	Observer scrapers.RequestObserver
 }
 
 // NewScraper creates a new Apple Podcasts scraper
@@ -39,10 +46,12 @@ func NewScraper(cfg Config) (*ApplePodcastsScraper, error) {
 		email:    cfg.Email,
 		password: cfg.Password,
 		httpClient: &http.Client{
-			Jar:     jar,
-			Timeout: 30 * time.Second,
+			Jar:       jar,
+			Timeout:   30 * time.Second,
+			Transport: scrapers.NewTransport(scrapers.PlatformApplePodcasts, cfg.Observer),
 		},
-		baseURL: "https://podcastsconnect.apple.com",
+		baseURL:  "https://podcastsconnect.apple.com",
+		archiver: cfg.Archiver,
 	}, nil
 }
 
@@ -64,7 +73,7 @@ func (s *ApplePodcastsScraper) authenticate(ctx context.Context) error {
 		"password": {s.password},
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", loginURL, strings.NewReader(formData.Encode()))
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointAuth), "POST", loginURL, strings.NewReader(formData.Encode()))
 	if err != nil {
 		return fmt.Errorf("failed to create login request: %w", err)
 	}
@@ -85,6 +94,43 @@ func (s *ApplePodcastsScraper) authenticate(ctx context.Context) error {
 	return nil
 }
 
+// CheckCredentials verifies the Apple ID by logging into Podcasts Connect
+func (s *ApplePodcastsScraper) CheckCredentials(ctx context.Context) *scrapers.CredentialCheck {
+	check := &scrapers.CredentialCheck{Platform: scrapers.PlatformApplePodcasts}
+
+	loginURL := fmt.Sprintf("%s/api/v1.0/auth/login", s.baseURL)
+
+	formData := url.Values{
+		"email":    {s.email},
+		"password": {s.password},
+	}
+
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointAuth), "POST", loginURL, strings.NewReader(formData.Encode()))
+	if err != nil {
+		check.Status = scrapers.CredentialError
+		check.Message = fmt.Sprintf("failed to create login request: %v", err)
+		return check
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		check.Status = scrapers.CredentialError
+		check.Message = fmt.Sprintf("login request failed: %v", err)
+		return check
+	}
+	defer resp.Body.Close()
+
+	check.HTTPStatus = resp.StatusCode
+	check.Status = scrapers.ClassifyHTTPStatus(resp.StatusCode)
+	if check.Status != scrapers.CredentialValid {
+		check.Message = fmt.Sprintf("login returned status %d", resp.StatusCode)
+	}
+
+	return check
+}
+
 // FetchPodcastInfo fetches basic podcast information
 func (s *ApplePodcastsScraper) FetchPodcastInfo(ctx context.Context, showName string) (*scrapers.Podcast, error) {
 	if err := s.authenticate(ctx); err != nil {
@@ -113,7 +159,7 @@ func (s *ApplePodcastsScraper) FetchEpisodes(ctx context.Context, podcast *scrap
 
 	apiURL := fmt.Sprintf("%s/api/v1.0/podcasts/%s/episodes", s.baseURL, podcast.PlatformID)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointEpisodes), "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -125,7 +171,7 @@ func (s *ApplePodcastsScraper) FetchEpisodes(ctx context.Context, podcast *scrap
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode}
 	}
 
 	var episodes []*scrapers.Episode
@@ -146,7 +192,7 @@ func (s *ApplePodcastsScraper) FetchEpisodeMetrics(ctx context.Context, episode
 
 	apiURL := fmt.Sprintf("%s/api/v1.0/analytics/episode/%s", s.baseURL, episode.PlatformEpisodeID)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointEpisodeMetrics), "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -165,11 +211,42 @@ func (s *ApplePodcastsScraper) FetchEpisodeMetrics(ctx context.Context, episode
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	body, key, err := scrapers.ReadResponse(ctx, s.archiver, resp, &scrapers.Response{
+		Platform:          scrapers.PlatformApplePodcasts,
+		Endpoint:          scrapers.EndpointEpisodeMetrics,
+		PodcastID:         episode.PodcastID,
+		EpisodeID:         episode.ID,
+		PlatformEpisodeID: episode.PlatformEpisodeID,
+		StartDate:         startDate,
+		EndDate:           endDate,
+		Shape:             episodeMetricsShape,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := s.parseEpisodeMetrics(episode, startDate, endDate, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// The archive holds the response; rows only point at it
+	if key != "" {
+		for _, metric := range metrics {
+			metric.RawData = scrapers.ArchiveRef(key)
+		}
 	}
 
+	return metrics, nil
+}
+
+// parseEpisodeMetrics parses an Apple Podcasts episode analytics response
+func (s *ApplePodcastsScraper) parseEpisodeMetrics(episode *scrapers.Episode, startDate, endDate time.Time, body []byte) ([]*scrapers.EpisodeMetrics, error) {
 	var metricsData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&metricsData); err != nil {
+	if err := json.Unmarshal(body, &metricsData); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -178,7 +255,7 @@ func (s *ApplePodcastsScraper) FetchEpisodeMetrics(ctx context.Context, episode
 	metrics := []*scrapers.EpisodeMetrics{
 		{
 			EpisodeID:   episode.ID,
-			MetricDate:  time.Now(),
+			MetricDate:  endDate,
 			RawData:     metricsData,
 			// Map Apple's metrics to our schema
 			// Plays, Listeners, EngagedListeners, etc.
@@ -196,7 +273,7 @@ func (s *ApplePodcastsScraper) FetchShowMetrics(ctx context.Context, podcast *sc
 
 	apiURL := fmt.Sprintf("%s/api/v1.0/analytics/show/%s", s.baseURL, podcast.PlatformID)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointShowMetrics), "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -213,18 +290,46 @@ func (s *ApplePodcastsScraper) FetchShowMetrics(ctx context.Context, podcast *sc
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode}
+	}
+
+	body, key, err := scrapers.ReadResponse(ctx, s.archiver, resp, &scrapers.Response{
+		Platform:  scrapers.PlatformApplePodcasts,
+		Endpoint:  scrapers.EndpointShowMetrics,
+		PodcastID: podcast.ID,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Shape:     showMetricsShape,
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	metrics, err := s.parseShowMetrics(podcast, startDate, endDate, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if key != "" {
+		for _, metric := range metrics {
+			metric.RawData = scrapers.ArchiveRef(key)
+		}
+	}
+
+	return metrics, nil
+}
+
+// parseShowMetrics parses an Apple Podcasts show analytics response
+func (s *ApplePodcastsScraper) parseShowMetrics(podcast *scrapers.Podcast, startDate, endDate time.Time, body []byte) ([]*scrapers.ShowMetrics, error) {
 	var metricsData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&metricsData); err != nil {
+	if err := json.Unmarshal(body, &metricsData); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	metrics := []*scrapers.ShowMetrics{
 		{
 			PodcastID:  podcast.ID,
-			MetricDate: time.Now(),
+			MetricDate: endDate,
 			RawData:    metricsData,
 		},
 	}
@@ -232,6 +337,17 @@ func (s *ApplePodcastsScraper) FetchShowMetrics(ctx context.Context, podcast *sc
 	return metrics, nil
 }
 
+// ParseEpisodeMetrics implements scrapers.ResponseParser. The response is
+// stored as raw data only, so there are no typed fields to rebuild yet.
+func (s *ApplePodcastsScraper) ParseEpisodeMetrics(episode *scrapers.Episode, startDate, endDate time.Time, body []byte) ([]*scrapers.EpisodeMetrics, error) {
+	return nil, scrapers.ErrParseUnsupported
+}
+
+// ParseShowMetrics implements scrapers.ResponseParser; see ParseEpisodeMetrics
+func (s *ApplePodcastsScraper) ParseShowMetrics(podcast *scrapers.Podcast, startDate, endDate time.Time, body []byte) ([]*scrapers.ShowMetrics, error) {
+	return nil, scrapers.ErrParseUnsupported
+}
+
 // FetchComments - Apple Podcasts doesn't support comments
 func (s *ApplePodcastsScraper) FetchComments(ctx context.Context, episode *scrapers.Episode) ([]*scrapers.Comment, error) {
 	// Apple Podcasts doesn't have a comments feature
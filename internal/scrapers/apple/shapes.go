@@ -0,0 +1,23 @@
+package apple
+
+import "github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+
+// Declared shapes of the Apple Podcasts Connect analytics responses. The
+// parsers keep these responses as raw data only, so the shapes list the
+// metrics the schema maps them to; anything else the API sends is reported
+// as new fields, which is how the real format shows up once collected.
+
+// episodeMetricsShape is an episode analytics response
+var episodeMetricsShape = scrapers.Shape{
+	"plays":            scrapers.Optional(scrapers.JSONNumber),
+	"listeners":        scrapers.Optional(scrapers.JSONNumber),
+	"engagedListeners": scrapers.Optional(scrapers.JSONNumber),
+}
+
+// showMetricsShape is a show analytics response
+var showMetricsShape = scrapers.Shape{
+	"plays":            scrapers.Optional(scrapers.JSONNumber),
+	"listeners":        scrapers.Optional(scrapers.JSONNumber),
+	"engagedListeners": scrapers.Optional(scrapers.JSONNumber),
+	"followers":        scrapers.Optional(scrapers.JSONNumber),
+}
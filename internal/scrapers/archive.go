@@ -0,0 +1,131 @@
+package scrapers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+)
+
+// Endpoints whose response bodies are archived
+const (
+	EndpointPodcastInfo    = "podcast_info"
+	EndpointEpisodes       = "episodes"
+	EndpointEpisodeMetrics = "episode_metrics"
+	EndpointShowMetrics    = "show_metrics"
+	EndpointComments       = "comments"
+)
+
+// ArchiveKeyField is the raw_data field naming the archived response a row
+// was parsed from
+const ArchiveKeyField = "archive_key"
+
+// Response is a successful API response body handed to a ResponseArchiver
+type Response struct {
+	Platform  Platform
+	Endpoint  string
+	PodcastID int64
+	EpisodeID int64 // 0 for show-level responses and episodes not yet stored
+
+	// PlatformEpisodeID identifies the episode when EpisodeID is not known yet
+	PlatformEpisodeID string
+
+	// StartDate and EndDate are the requested metrics window, zero for
+	// endpoints without one
+	StartDate time.Time
+	EndDate   time.Time
+
+	// Shape is the declared structure of the body, nil when not declared
+	Shape Shape
+
+	Body []byte
+}
+
+// ResponseArchiver stores API response bodies and returns the key each one
+// was stored under
+type ResponseArchiver interface {
+	Archive(ctx context.Context, resp *Response) (string, error)
+}
+
+// ErrParseUnsupported is returned by parsers that cannot rebuild metrics from
+// a stored response
+var ErrParseUnsupported = errors.New("parser does not map this platform's responses to metric fields")
+
+// ResponseParser rebuilds metrics from a stored response body, so a parser
+// fix can be applied to history without calling the platform again.
+// startDate and endDate are the window the response was requested for;
+// platforms that report totals rather than daily rows date them endDate.
+// Parsers that do not map a response to typed fields return
+// ErrParseUnsupported rather than rows of zeros.
+type ResponseParser interface {
+	ParseEpisodeMetrics(episode *Episode, startDate, endDate time.Time, body []byte) ([]*EpisodeMetrics, error)
+	ParseShowMetrics(podcast *Podcast, startDate, endDate time.Time, body []byte) ([]*ShowMetrics, error)
+}
+
+// ArchiveFailureReporter is told about responses that could not be archived
+type ArchiveFailureReporter interface {
+	ReportArchiveFailure(ctx context.Context, resp *Response, err error)
+}
+
+type archiveFailureReporterKey struct{}
+
+// WithArchiveFailureReporter returns a context whose archive failures are
+// reported to reporter
+func WithArchiveFailureReporter(ctx context.Context, reporter ArchiveFailureReporter) context.Context {
+	return context.WithValue(ctx, archiveFailureReporterKey{}, reporter)
+}
+
+// ReadResponse reads a successful response body, checks it against meta's
+// shape when the context has a drift reporter, and archives it with archiver,
+// which may be nil when archiving is disabled. meta describes the request;
+// its Body is filled in. It returns the body and the archive key, or "" when
+// nothing was archived. An archive outage never fails the fetch: the failure
+// is logged and reported, and the caller keeps the whole body inline.
+func ReadResponse(ctx context.Context, archiver ResponseArchiver, resp *http.Response, meta *Response) ([]byte, string, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	checkShape(ctx, meta, body)
+
+	if archiver == nil {
+		return body, "", nil
+	}
+
+	meta.Body = body
+	key, err := archiver.Archive(ctx, meta)
+	if err != nil {
+		err = fmt.Errorf("failed to archive %s response: %w", meta.Endpoint, err)
+		slog.ErrorContext(ctx, "Failed to archive response, storing it inline", "endpoint", meta.Endpoint, logging.KeyError, err)
+		if reporter, ok := ctx.Value(archiveFailureReporterKey{}).(ArchiveFailureReporter); ok {
+			reporter.ReportArchiveFailure(ctx, meta, err)
+		}
+		return body, "", nil
+	}
+	return body, key, nil
+}
+
+// ArchiveRef is the raw_data stored in place of a response archived under key
+func ArchiveRef(key string) map[string]interface{} {
+	return map[string]interface{}{ArchiveKeyField: key}
+}
+
+type runIDKey struct{}
+
+// WithRunID returns a context carrying the scraper run that requests made
+// with it belong to, so archived responses can be filed under the run
+func WithRunID(ctx context.Context, runID int64) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// RunIDFromContext returns the run set by WithRunID, or 0
+func RunIDFromContext(ctx context.Context) int64 {
+	runID, _ := ctx.Value(runIDKey{}).(int64)
+	return runID
+}
@@ -0,0 +1,98 @@
+package scrapers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+)
+
+// maxErrorBody bounds the response body kept in an HTTPError's message
+const maxErrorBody = 500
+
+// HTTPError is returned when a platform API responds with a non-success status
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("API returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, truncateMessage(logging.Redact(e.Body), maxErrorBody))
+}
+
+// truncateMessage shortens s to at most n bytes, cutting on a rune boundary
+// so the result stays valid UTF-8 for Postgres text columns
+func truncateMessage(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
+
+// ErrorClass groups per-item errors recorded in the error ledger
+type ErrorClass string
+
+const (
+	ErrorClassAuth        ErrorClass = "auth"
+	ErrorClassRateLimited ErrorClass = "rate_limited"
+	ErrorClassNotFound    ErrorClass = "not_found"
+	ErrorClassClient      ErrorClass = "client_error"
+	ErrorClassServer      ErrorClass = "server_error"
+	ErrorClassTimeout     ErrorClass = "timeout"
+	ErrorClassCanceled    ErrorClass = "canceled"
+	ErrorClassNetwork     ErrorClass = "network"
+	ErrorClassDecode      ErrorClass = "decode"
+	ErrorClassStorage     ErrorClass = "storage"
+	ErrorClassUnknown     ErrorClass = "unknown"
+)
+
+// ClassifyError returns the class of a scraper error and, for HTTP errors,
+// the response status
+func ClassifyError(err error) (ErrorClass, *int) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		status := httpErr.StatusCode
+		switch {
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			return ErrorClassAuth, &status
+		case status == http.StatusTooManyRequests:
+			return ErrorClassRateLimited, &status
+		case status == http.StatusNotFound:
+			return ErrorClassNotFound, &status
+		case status >= 500:
+			return ErrorClassServer, &status
+		default:
+			return ErrorClassClient, &status
+		}
+	}
+
+	var netErr net.Error
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout, nil
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled, nil
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return ErrorClassTimeout, nil
+		}
+		return ErrorClassNetwork, nil
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return ErrorClassDecode, nil
+	}
+
+	return ErrorClassUnknown, nil
+}
@@ -0,0 +1,60 @@
+package scrapers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{name: "short", s: "timeout", n: 10, want: "timeout"},
+		{name: "exact", s: "timeout", n: 7, want: "timeout"},
+		{name: "ascii", s: "connection reset", n: 10, want: "connection..."},
+		// "é" is two bytes; cutting at 2 would split it
+		{name: "multi-byte rune at the cut", s: "café crème", n: 4, want: "caf..."},
+		{name: "cut after a multi-byte rune", s: "café crème", n: 5, want: "café..."},
+		{name: "emoji", s: "🎙️🎙️🎙️", n: 5, want: "🎙..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateMessage(tt.s, tt.n)
+			if got != tt.want {
+				t.Errorf("truncateMessage(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateMessage(%q, %d) = %q, which is not valid UTF-8", tt.s, tt.n, got)
+			}
+		})
+	}
+}
+
+func TestNewScraperErrorMessageIsValidUTF8(t *testing.T) {
+	// Postgres rejects text with a split multi-byte character
+	msg := "x" + strings.Repeat("é", maxErrorMessage)
+	e := NewScraperError(1, PlatformSpotify, nil, StageFetchMetrics, errors.New(msg))
+
+	if len(e.Message) > maxErrorMessage+len("...") {
+		t.Errorf("message is %d bytes, want at most %d", len(e.Message), maxErrorMessage+len("..."))
+	}
+	if !utf8.ValidString(e.Message) {
+		t.Error("message is not valid UTF-8")
+	}
+}
+
+func TestSampleJSONIsValidUTF8(t *testing.T) {
+	sample := sampleJSON(strings.Repeat("ü", maxDriftSample))
+	if !utf8.ValidString(sample) {
+		t.Error("sampleJSON() is not valid UTF-8")
+	}
+	if !strings.HasSuffix(sample, "...") {
+		t.Errorf("sampleJSON() = %q, want it truncated", sample[len(sample)-10:])
+	}
+}
@@ -0,0 +1,271 @@
+package scrapers
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JSONType is the type of a value in a decoded JSON response
+type JSONType string
+
+const (
+	JSONObject JSONType = "object"
+	JSONArray  JSONType = "array"
+	JSONString JSONType = "string"
+	JSONNumber JSONType = "number"
+	JSONBool   JSONType = "bool"
+	JSONNull   JSONType = "null"
+
+	// JSONAny accepts any value and leaves everything below it unchecked
+	JSONAny JSONType = "any"
+)
+
+// Field is the expected type of one path in a response
+type Field struct {
+	Type     JSONType
+	Optional bool // May be absent or null
+}
+
+// Required declares a field that must be present with type t
+func Required(t JSONType) Field {
+	return Field{Type: t}
+}
+
+// Optional declares a field that may be absent or null
+func Optional(t JSONType) Field {
+	return Field{Type: t, Optional: true}
+}
+
+// Shape declares the expected structure of a platform response. Keys are
+// paths from the document root, with "." between object fields and "[]" for
+// the elements of an array, e.g. "items[].snippet.title".
+type Shape map[string]Field
+
+// Kinds of schema drift
+const (
+	DriftNew     = "new"     // Field present in the response but not declared
+	DriftMissing = "missing" // Required field absent from the response
+	DriftRetyped = "retyped" // Field present with a different type
+)
+
+// maxDriftSample bounds the JSON sample kept with each drift
+const maxDriftSample = 1000
+
+// SchemaDrift is a difference between a response and its declared shape
+type SchemaDrift struct {
+	ID           int64
+	RunID        int64
+	Platform     Platform
+	Endpoint     string
+	Path         string
+	Kind         string
+	ExpectedType JSONType // Empty for new fields
+	ObservedType JSONType // Empty for missing fields
+	Sample       string   // JSON of the field, or of its parent when missing
+	DetectedAt   time.Time
+}
+
+// DriftReporter receives the drift found in responses made during a run
+type DriftReporter interface {
+	ReportDrift(ctx context.Context, drift []*SchemaDrift)
+}
+
+type driftReporterKey struct{}
+
+// WithDriftReporter returns a context whose responses are validated against
+// their declared shapes, with any drift reported to reporter
+func WithDriftReporter(ctx context.Context, reporter DriftReporter) context.Context {
+	return context.WithValue(ctx, driftReporterKey{}, reporter)
+}
+
+// checkShape validates body against meta's shape and reports any drift to
+// the context's reporter. Responses without a shape are not checked.
+func checkShape(ctx context.Context, meta *Response, body []byte) {
+	reporter, _ := ctx.Value(driftReporterKey{}).(DriftReporter)
+	if reporter == nil || meta.Shape == nil {
+		return
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		// Undecodable bodies surface as decode errors in the scraper
+		return
+	}
+
+	drift := meta.Shape.Validate(doc)
+	if len(drift) == 0 {
+		return
+	}
+
+	runID := RunIDFromContext(ctx)
+	now := time.Now()
+	for _, d := range drift {
+		d.RunID = runID
+		d.Platform = meta.Platform
+		d.Endpoint = meta.Endpoint
+		d.DetectedAt = now
+	}
+	reporter.ReportDrift(ctx, drift)
+}
+
+// observedField collects what was seen at one path of a document
+type observedField struct {
+	types  map[JSONType]bool
+	sample interface{}
+}
+
+// Validate compares a decoded JSON document with the shape and returns the
+// drift, sorted by path. Elements of empty arrays are never reported missing.
+func (s Shape) Validate(doc interface{}) []*SchemaDrift {
+	observed := make(map[string]*observedField)
+	s.observe("", doc, observed)
+
+	var drift []*SchemaDrift
+
+	for path, obs := range observed {
+		field, declared := s[path]
+		if !declared {
+			drift = append(drift, &SchemaDrift{
+				Path:         path,
+				Kind:         DriftNew,
+				ObservedType: obs.firstType(),
+				Sample:       sampleJSON(obs.sample),
+			})
+			continue
+		}
+		if field.Type == JSONAny {
+			continue
+		}
+		for t := range obs.types {
+			if t == field.Type || (t == JSONNull && field.Optional) {
+				continue
+			}
+			drift = append(drift, &SchemaDrift{
+				Path:         path,
+				Kind:         DriftRetyped,
+				ExpectedType: field.Type,
+				ObservedType: t,
+				Sample:       sampleJSON(obs.sample),
+			})
+			break
+		}
+	}
+
+	for path, field := range s {
+		if field.Optional || strings.HasSuffix(path, "[]") {
+			continue
+		}
+		if _, ok := observed[path]; ok {
+			continue
+		}
+		// Only report fields whose parent was seen with a value
+		parent := parentPath(path)
+		parentObs, ok := observed[parent]
+		if parent != "" && (!ok || !parentObs.types[JSONObject]) {
+			continue
+		}
+		sample := doc
+		if ok {
+			sample = parentObs.sample
+		}
+		drift = append(drift, &SchemaDrift{
+			Path:         path,
+			Kind:         DriftMissing,
+			ExpectedType: field.Type,
+			Sample:       sampleJSON(sample),
+		})
+	}
+
+	sort.Slice(drift, func(i, j int) bool {
+		if drift[i].Path != drift[j].Path {
+			return drift[i].Path < drift[j].Path
+		}
+		return drift[i].Kind < drift[j].Kind
+	})
+	return drift
+}
+
+// observe records the type of value at path and walks into it, unless the
+// shape accepts anything there
+func (s Shape) observe(path string, value interface{}, observed map[string]*observedField) {
+	if path != "" {
+		obs, ok := observed[path]
+		if !ok {
+			obs = &observedField{types: make(map[JSONType]bool), sample: value}
+			observed[path] = obs
+		}
+		obs.types[jsonType(value)] = true
+
+		if field, ok := s[path]; ok && field.Type == JSONAny {
+			return
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			s.observe(childPath, child, observed)
+		}
+	case []interface{}:
+		for _, child := range v {
+			s.observe(path+"[]", child, observed)
+		}
+	}
+}
+
+// firstType returns one observed type, preferring non-null ones
+func (o *observedField) firstType() JSONType {
+	t := jsonType(o.sample)
+	if t == JSONNull {
+		for other := range o.types {
+			if other != JSONNull {
+				return other
+			}
+		}
+	}
+	return t
+}
+
+// parentPath returns the path of the object or array holding path
+func parentPath(path string) string {
+	if strings.HasSuffix(path, "[]") {
+		return strings.TrimSuffix(path, "[]")
+	}
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func jsonType(value interface{}) JSONType {
+	switch value.(type) {
+	case map[string]interface{}:
+		return JSONObject
+	case []interface{}:
+		return JSONArray
+	case string:
+		return JSONString
+	case float64, json.Number:
+		return JSONNumber
+	case bool:
+		return JSONBool
+	default:
+		return JSONNull
+	}
+}
+
+// sampleJSON encodes value for a drift record, truncated to maxDriftSample
+func sampleJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return truncateMessage(string(data), maxDriftSample)
+}
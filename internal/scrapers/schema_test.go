@@ -0,0 +1,136 @@
+package scrapers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var testShape = Shape{
+	"id":              Required(JSONString),
+	"title":           Optional(JSONString),
+	"stats":           Required(JSONObject),
+	"stats.plays":     Required(JSONNumber),
+	"stats.breakdown": Optional(JSONAny),
+	"items":           Optional(JSONArray),
+	"items[]":         Required(JSONObject),
+	"items[].date":    Required(JSONString),
+}
+
+func decode(t *testing.T, body string) interface{} {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// driftKeys renders drift as "path kind" for comparison
+func driftKeys(drift []*SchemaDrift) []string {
+	var keys []string
+	for _, d := range drift {
+		keys = append(keys, d.Path+" "+d.Kind)
+	}
+	return keys
+}
+
+func TestShapeValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "matching document",
+			body: `{"id": "ep-1", "title": "Pilot", "stats": {"plays": 3, "breakdown": {"any": ["thing"]}}, "items": [{"date": "2026-01-01"}]}`,
+		},
+		{
+			name: "optional fields absent or null",
+			body: `{"id": "ep-1", "title": null, "stats": {"plays": 3}}`,
+		},
+		{
+			name: "empty arrays have no missing elements",
+			body: `{"id": "ep-1", "stats": {"plays": 3}, "items": []}`,
+		},
+		{
+			name: "new field",
+			body: `{"id": "ep-1", "stats": {"plays": 3, "starts": 4}}`,
+			want: []string{"stats.starts new"},
+		},
+		{
+			name: "missing required field",
+			body: `{"stats": {}}`,
+			want: []string{"id missing", "stats.plays missing"},
+		},
+		{
+			name: "retyped field",
+			body: `{"id": 7, "stats": {"plays": "3"}, "items": [{"date": 20260101}]}`,
+			want: []string{"id retyped", "items[].date retyped", "stats.plays retyped"},
+		},
+		{
+			name: "children of a missing parent are not reported",
+			body: `{"id": "ep-1"}`,
+			want: []string{"stats missing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := driftKeys(testShape.Validate(decode(t, tt.body)))
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShapeValidateTypes(t *testing.T) {
+	drift := testShape.Validate(decode(t, `{"id": 7, "stats": {"plays": 3}}`))
+	if len(drift) != 1 {
+		t.Fatalf("Validate() = %v, want one drift", driftKeys(drift))
+	}
+	if d := drift[0]; d.ExpectedType != JSONString || d.ObservedType != JSONNumber || d.Sample != "7" {
+		t.Errorf("drift = expected %s, observed %s, sample %s, want string, number, 7", d.ExpectedType, d.ObservedType, d.Sample)
+	}
+}
+
+// recordingReporter keeps the drift reported to it
+type recordingReporter struct {
+	drift []*SchemaDrift
+}
+
+func (r *recordingReporter) ReportDrift(ctx context.Context, drift []*SchemaDrift) {
+	r.drift = append(r.drift, drift...)
+}
+
+func TestReadResponseReportsDrift(t *testing.T) {
+	reporter := &recordingReporter{}
+	ctx := WithRunID(WithDriftReporter(context.Background(), reporter), 42)
+
+	read := func(meta *Response) {
+		t.Helper()
+		resp := &http.Response{Body: io.NopCloser(strings.NewReader(`{"id": "ep-1", "stats": {"plays": 3}, "extra": true}`))}
+		if _, _, err := ReadResponse(ctx, nil, resp, meta); err != nil {
+			t.Fatalf("ReadResponse() error = %v", err)
+		}
+	}
+
+	// Responses without a declared shape are not checked
+	read(&Response{Platform: PlatformSpotify, Endpoint: EndpointEpisodeMetrics})
+	if len(reporter.drift) != 0 {
+		t.Fatalf("drift without a shape = %v, want none", driftKeys(reporter.drift))
+	}
+
+	read(&Response{Platform: PlatformSpotify, Endpoint: EndpointEpisodeMetrics, Shape: testShape})
+	if len(reporter.drift) != 1 {
+		t.Fatalf("drift = %v, want one new field", driftKeys(reporter.drift))
+	}
+	d := reporter.drift[0]
+	if d.Path != "extra" || d.Kind != DriftNew || d.RunID != 42 || d.Platform != PlatformSpotify || d.Endpoint != EndpointEpisodeMetrics {
+		t.Errorf("drift = %+v, want a new field \"extra\" of run 42's Spotify episode metrics", d)
+	}
+}
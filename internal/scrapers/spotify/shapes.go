@@ -0,0 +1,20 @@
+package spotify
+
+import "github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+
+// Declared shapes of the Spotify for Podcasters analytics responses. The
+// parsers keep these responses as raw data only, so the shapes list the
+// metrics each request asks for; anything else the API sends is reported as
+// new fields, which is how the real format shows up once collected.
+
+// episodeMetricsShape is an episode analytics response
+var episodeMetricsShape = scrapers.Shape{
+	"starts":    scrapers.Optional(scrapers.JSONNumber),
+	"streams":   scrapers.Optional(scrapers.JSONNumber),
+	"listeners": scrapers.Optional(scrapers.JSONNumber),
+	"followers": scrapers.Optional(scrapers.JSONNumber),
+}
+
+// showMetricsShape is a show analytics response, reporting the same metrics
+// for the whole show
+var showMetricsShape = episodeMetricsShape
@@ -15,9 +15,11 @@ import (
 
 // SpotifyScraper scrapes metrics from Spotify for Podcasters
 type SpotifyScraper struct {
-	accessToken string
-	httpClient  *http.Client
-	baseURL     string
+	accessToken     string
+	httpClient      *http.Client
+	baseURL         string
+	cookieExpiresAt *time.Time
+	archiver        scrapers.ResponseArchiver
 }
 
 // Config holds configuration for Spotify scraper
@@ -26,6 +28,15 @@ type Config struct {
 	// These would typically be extracted from browser session
 	SpCookie     string // sp_dc cookie
 	SpKeyCookie  string // sp_key cookie
+
+	// SpCookieExpiresAt is the sp_dc expiry copied from the browser, if known
+	SpCookieExpiresAt *time.Time
+
+	// Archiver stores every successful response body when set
+	Archiver scrapers.ResponseArchiver
+
+	// Observer receives the outcome of every API request when set
+	Observer scrapers.RequestObserver
 }
 
 // NewScraper creates a new Spotify scraper
@@ -37,10 +48,13 @@ func NewScraper(cfg Config) (*SpotifyScraper, error) {
 
 	scraper := &SpotifyScraper{
 		httpClient: &http.Client{
-			Jar:     jar,
-			Timeout: 30 * time.Second,
+			Jar:       jar,
+			Timeout:   30 * time.Second,
+			Transport: scrapers.NewTransport(scrapers.PlatformSpotify, cfg.Observer),
 		},
-		baseURL: "https://podcasters.spotify.com",
+		baseURL:         "https://podcasters.spotify.com",
+		cookieExpiresAt: cfg.SpCookieExpiresAt,
+		archiver:        cfg.Archiver,
 	}
 
 	// Set authentication cookies
@@ -57,6 +71,45 @@ func (s *SpotifyScraper) GetPlatform() scrapers.Platform {
 	return scrapers.PlatformSpotify
 }
 
+// CheckCredentials verifies the session cookies against the login endpoint
+func (s *SpotifyScraper) CheckCredentials(ctx context.Context) *scrapers.CredentialCheck {
+	check := &scrapers.CredentialCheck{
+		Platform:  scrapers.PlatformSpotify,
+		ExpiresAt: s.cookieExpiresAt,
+	}
+
+	if s.cookieExpiresAt != nil && time.Now().After(*s.cookieExpiresAt) {
+		check.Status = scrapers.CredentialExpired
+		check.Message = fmt.Sprintf("sp_dc cookie expired at %s", s.cookieExpiresAt.Format(time.RFC3339))
+		return check
+	}
+
+	authURL := fmt.Sprintf("%s/api/login", s.baseURL)
+
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointAuth), "GET", authURL, nil)
+	if err != nil {
+		check.Status = scrapers.CredentialError
+		check.Message = fmt.Sprintf("failed to create auth request: %v", err)
+		return check
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		check.Status = scrapers.CredentialError
+		check.Message = fmt.Sprintf("auth request failed: %v", err)
+		return check
+	}
+	defer resp.Body.Close()
+
+	check.HTTPStatus = resp.StatusCode
+	check.Status = scrapers.ClassifyHTTPStatus(resp.StatusCode)
+	if check.Status != scrapers.CredentialValid {
+		check.Message = fmt.Sprintf("authentication returned status %d", resp.StatusCode)
+	}
+
+	return check
+}
+
 // authenticate obtains access token for Spotify for Podcasters API
 func (s *SpotifyScraper) authenticate(ctx context.Context) error {
 	// Spotify for Podcasters uses an internal API that requires:
@@ -68,7 +121,7 @@ func (s *SpotifyScraper) authenticate(ctx context.Context) error {
 
 	authURL := fmt.Sprintf("%s/api/login", s.baseURL)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", authURL, nil)
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointAuth), "GET", authURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create auth request: %w", err)
 	}
@@ -112,7 +165,7 @@ func (s *SpotifyScraper) FetchEpisodes(ctx context.Context, podcast *scrapers.Po
 	// Based on openpodcast/spotify-connector reverse engineering
 	apiURL := fmt.Sprintf("%s/api/episodes?showId=%s", s.baseURL, podcast.PlatformID)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointEpisodes), "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -124,7 +177,7 @@ func (s *SpotifyScraper) FetchEpisodes(ctx context.Context, podcast *scrapers.Po
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode}
 	}
 
 	var episodes []*scrapers.Episode
@@ -144,7 +197,7 @@ func (s *SpotifyScraper) FetchEpisodeMetrics(ctx context.Context, episode *scrap
 	// Reference: https://github.com/openpodcast/spotify-connector
 	apiURL := fmt.Sprintf("%s/api/analytics/episode/%s", s.baseURL, episode.PlatformEpisodeID)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, nil)
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointEpisodeMetrics), "POST", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -172,11 +225,42 @@ func (s *SpotifyScraper) FetchEpisodeMetrics(ctx context.Context, episode *scrap
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	body, key, err := scrapers.ReadResponse(ctx, s.archiver, resp, &scrapers.Response{
+		Platform:          scrapers.PlatformSpotify,
+		Endpoint:          scrapers.EndpointEpisodeMetrics,
+		PodcastID:         episode.PodcastID,
+		EpisodeID:         episode.ID,
+		PlatformEpisodeID: episode.PlatformEpisodeID,
+		StartDate:         startDate,
+		EndDate:           endDate,
+		Shape:             episodeMetricsShape,
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	metrics, err := s.parseEpisodeMetrics(episode, startDate, endDate, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// The archive holds the response; rows only point at it
+	if key != "" {
+		for _, metric := range metrics {
+			metric.RawData = scrapers.ArchiveRef(key)
+		}
+	}
+
+	return metrics, nil
+}
+
+// parseEpisodeMetrics parses a Spotify episode analytics response
+func (s *SpotifyScraper) parseEpisodeMetrics(episode *scrapers.Episode, startDate, endDate time.Time, body []byte) ([]*scrapers.EpisodeMetrics, error) {
 	var metricsData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&metricsData); err != nil {
+	if err := json.Unmarshal(body, &metricsData); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -186,7 +270,7 @@ func (s *SpotifyScraper) FetchEpisodeMetrics(ctx context.Context, episode *scrap
 	metrics := []*scrapers.EpisodeMetrics{
 		{
 			EpisodeID:  episode.ID,
-			MetricDate: time.Now(),
+			MetricDate: endDate,
 			RawData:    metricsData,
 			// Map Spotify metrics:
 			// - starts -> Plays
@@ -206,7 +290,7 @@ func (s *SpotifyScraper) FetchShowMetrics(ctx context.Context, podcast *scrapers
 
 	apiURL := fmt.Sprintf("%s/api/analytics/show/%s", s.baseURL, podcast.PlatformID)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, nil)
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointShowMetrics), "POST", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -231,18 +315,46 @@ func (s *SpotifyScraper) FetchShowMetrics(ctx context.Context, podcast *scrapers
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode}
+	}
+
+	body, key, err := scrapers.ReadResponse(ctx, s.archiver, resp, &scrapers.Response{
+		Platform:  scrapers.PlatformSpotify,
+		Endpoint:  scrapers.EndpointShowMetrics,
+		PodcastID: podcast.ID,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Shape:     showMetricsShape,
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	metrics, err := s.parseShowMetrics(podcast, startDate, endDate, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if key != "" {
+		for _, metric := range metrics {
+			metric.RawData = scrapers.ArchiveRef(key)
+		}
+	}
+
+	return metrics, nil
+}
+
+// parseShowMetrics parses a Spotify show analytics response
+func (s *SpotifyScraper) parseShowMetrics(podcast *scrapers.Podcast, startDate, endDate time.Time, body []byte) ([]*scrapers.ShowMetrics, error) {
 	var metricsData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&metricsData); err != nil {
+	if err := json.Unmarshal(body, &metricsData); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	metrics := []*scrapers.ShowMetrics{
 		{
 			PodcastID:  podcast.ID,
-			MetricDate: time.Now(),
+			MetricDate: endDate,
 			RawData:    metricsData,
 		},
 	}
@@ -250,6 +362,17 @@ func (s *SpotifyScraper) FetchShowMetrics(ctx context.Context, podcast *scrapers
 	return metrics, nil
 }
 
+// ParseEpisodeMetrics implements scrapers.ResponseParser. The response is
+// stored as raw data only, so there are no typed fields to rebuild yet.
+func (s *SpotifyScraper) ParseEpisodeMetrics(episode *scrapers.Episode, startDate, endDate time.Time, body []byte) ([]*scrapers.EpisodeMetrics, error) {
+	return nil, scrapers.ErrParseUnsupported
+}
+
+// ParseShowMetrics implements scrapers.ResponseParser; see ParseEpisodeMetrics
+func (s *SpotifyScraper) ParseShowMetrics(podcast *scrapers.Podcast, startDate, endDate time.Time, body []byte) ([]*scrapers.ShowMetrics, error) {
+	return nil, scrapers.ErrParseUnsupported
+}
+
 // FetchComments - Spotify for Podcasters doesn't support comments
 func (s *SpotifyScraper) FetchComments(ctx context.Context, episode *scrapers.Episode) ([]*scrapers.Comment, error) {
 	// Spotify doesn't have podcast comments
@@ -0,0 +1,107 @@
+package scrapers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer starts the spans of the collector and scrapers
+var Tracer = otel.Tracer("github.com/soypete/eleduck-analytics-connector/internal/scrapers")
+
+// Span attribute keys shared by the collector, scrapers and repository
+const (
+	AttrRunID     = attribute.Key("podcast.run_id")
+	AttrPlatform  = attribute.Key("podcast.platform")
+	AttrShow      = attribute.Key("podcast.show")
+	AttrEpisodeID = attribute.Key("podcast.episode_id")
+	AttrEndpoint  = attribute.Key("podcast.endpoint")
+	AttrItems     = attribute.Key("podcast.items")
+)
+
+// EndSpan records err on span, if any, with credentials redacted, and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		msg := logging.Redact(err.Error())
+		span.RecordError(errors.New(msg))
+		span.SetStatus(codes.Error, msg)
+	}
+	span.End()
+}
+
+// Traced returns a scraper that records a span around each Fetch call of s.
+// It still implements QuotaReporter when s does.
+func Traced(s Scraper) Scraper {
+	traced := &tracedScraper{Scraper: s}
+	if quota, ok := s.(QuotaReporter); ok {
+		return &tracedQuotaScraper{tracedScraper: traced, QuotaReporter: quota}
+	}
+	return traced
+}
+
+type tracedScraper struct {
+	Scraper
+}
+
+type tracedQuotaScraper struct {
+	*tracedScraper
+	QuotaReporter
+}
+
+func (t *tracedScraper) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, AttrPlatform.String(string(t.GetPlatform())))
+	return Tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+func windowAttrs(startDate, endDate time.Time) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("podcast.window_start", startDate.Format("2006-01-02")),
+		attribute.String("podcast.window_end", endDate.Format("2006-01-02")),
+	}
+}
+
+func (t *tracedScraper) FetchPodcastInfo(ctx context.Context, showName string) (*Podcast, error) {
+	ctx, span := t.start(ctx, "FetchPodcastInfo", AttrShow.String(showName))
+	podcast, err := t.Scraper.FetchPodcastInfo(ctx, showName)
+	EndSpan(span, err)
+	return podcast, err
+}
+
+func (t *tracedScraper) FetchEpisodes(ctx context.Context, podcast *Podcast) ([]*Episode, error) {
+	ctx, span := t.start(ctx, "FetchEpisodes")
+	episodes, err := t.Scraper.FetchEpisodes(ctx, podcast)
+	span.SetAttributes(AttrItems.Int(len(episodes)))
+	EndSpan(span, err)
+	return episodes, err
+}
+
+func (t *tracedScraper) FetchEpisodeMetrics(ctx context.Context, episode *Episode, startDate, endDate time.Time) ([]*EpisodeMetrics, error) {
+	attrs := append(windowAttrs(startDate, endDate), AttrEpisodeID.String(episode.PlatformEpisodeID))
+	ctx, span := t.start(ctx, "FetchEpisodeMetrics", attrs...)
+	metrics, err := t.Scraper.FetchEpisodeMetrics(ctx, episode, startDate, endDate)
+	span.SetAttributes(AttrItems.Int(len(metrics)))
+	EndSpan(span, err)
+	return metrics, err
+}
+
+func (t *tracedScraper) FetchShowMetrics(ctx context.Context, podcast *Podcast, startDate, endDate time.Time) ([]*ShowMetrics, error) {
+	ctx, span := t.start(ctx, "FetchShowMetrics", windowAttrs(startDate, endDate)...)
+	metrics, err := t.Scraper.FetchShowMetrics(ctx, podcast, startDate, endDate)
+	span.SetAttributes(AttrItems.Int(len(metrics)))
+	EndSpan(span, err)
+	return metrics, err
+}
+
+func (t *tracedScraper) FetchComments(ctx context.Context, episode *Episode) ([]*Comment, error) {
+	ctx, span := t.start(ctx, "FetchComments", AttrEpisodeID.String(episode.PlatformEpisodeID))
+	comments, err := t.Scraper.FetchComments(ctx, episode)
+	span.SetAttributes(AttrItems.Int(len(comments)))
+	EndSpan(span, err)
+	return comments, err
+}
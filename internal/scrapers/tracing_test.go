@@ -0,0 +1,169 @@
+package scrapers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans points Tracer at a recorder for the rest of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := Tracer
+	Tracer = provider.Tracer("test")
+	t.Cleanup(func() { Tracer = previous })
+	return recorder
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+// tracingScraper calls its client from FetchEpisodes and fails FetchShowMetrics
+type tracingScraper struct {
+	client *http.Client
+	url    string
+}
+
+func (s *tracingScraper) GetPlatform() Platform { return PlatformYouTube }
+
+func (s *tracingScraper) FetchPodcastInfo(ctx context.Context, showName string) (*Podcast, error) {
+	return &Podcast{ShowName: showName}, nil
+}
+
+func (s *tracingScraper) FetchEpisodes(ctx context.Context, podcast *Podcast) ([]*Episode, error) {
+	req, err := http.NewRequestWithContext(WithEndpoint(ctx, EndpointEpisodes), http.MethodGet, s.url+"/videos?key=secret", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return []*Episode{{PlatformEpisodeID: "ep-1"}, {PlatformEpisodeID: "ep-2"}}, nil
+}
+
+func (s *tracingScraper) FetchEpisodeMetrics(ctx context.Context, episode *Episode, startDate, endDate time.Time) ([]*EpisodeMetrics, error) {
+	return nil, nil
+}
+
+func (s *tracingScraper) FetchShowMetrics(ctx context.Context, podcast *Podcast, startDate, endDate time.Time) ([]*ShowMetrics, error) {
+	return nil, errors.New("401: Authorization: Bearer abc")
+}
+
+func (s *tracingScraper) FetchComments(ctx context.Context, episode *Episode) ([]*Comment, error) {
+	return nil, nil
+}
+
+func (s *tracingScraper) CheckCredentials(ctx context.Context) *CredentialCheck {
+	return &CredentialCheck{}
+}
+
+type quotaTracingScraper struct {
+	tracingScraper
+}
+
+func (s *quotaTracingScraper) DailyQuota() int64 { return 10000 }
+func (s *quotaTracingScraper) QuotaUsed() int64  { return 42 }
+
+func TestTracedKeepsQuotaReporter(t *testing.T) {
+	if _, ok := Traced(&tracingScraper{}).(QuotaReporter); ok {
+		t.Error("Traced() of a scraper without a quota implements QuotaReporter")
+	}
+
+	quota, ok := Traced(&quotaTracingScraper{}).(QuotaReporter)
+	if !ok {
+		t.Fatal("Traced() of a quota scraper does not implement QuotaReporter")
+	}
+	if got := quota.QuotaUsed(); got != 42 {
+		t.Errorf("QuotaUsed() = %d, want 42", got)
+	}
+}
+
+func TestTracedLinksRequestSpansUnderFetchSpans(t *testing.T) {
+	recorder := recordSpans(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	scraper := Traced(&tracingScraper{
+		client: &http.Client{Transport: NewTransport(PlatformYouTube, nil)},
+		url:    server.URL,
+	})
+
+	if _, err := scraper.FetchEpisodes(context.Background(), &Podcast{}); err != nil {
+		t.Fatalf("FetchEpisodes() error = %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want the request and FetchEpisodes", len(spans))
+	}
+	request, fetch := spans[0], spans[1]
+
+	if fetch.Name() != "FetchEpisodes" {
+		t.Errorf("fetch span name = %q, want FetchEpisodes", fetch.Name())
+	}
+	if v, _ := spanAttr(fetch, AttrPlatform); v.AsString() != string(PlatformYouTube) {
+		t.Errorf("fetch span platform = %q, want %q", v.AsString(), PlatformYouTube)
+	}
+	if v, _ := spanAttr(fetch, AttrItems); v.AsInt64() != 2 {
+		t.Errorf("fetch span items = %d, want 2", v.AsInt64())
+	}
+
+	if request.Parent().SpanID() != fetch.SpanContext().SpanID() {
+		t.Error("request span is not a child of the fetch span")
+	}
+	if request.Name() != "HTTP GET "+EndpointEpisodes {
+		t.Errorf("request span name = %q, want %q", request.Name(), "HTTP GET "+EndpointEpisodes)
+	}
+	for _, kv := range request.Attributes() {
+		if strings.Contains(kv.Value.Emit(), "secret") {
+			t.Errorf("request span attribute %s = %q leaks the query string", kv.Key, kv.Value.Emit())
+		}
+	}
+	if v, _ := spanAttr(request, "http.response.status_code"); v.AsInt64() != http.StatusOK {
+		t.Errorf("request span status code = %d, want %d", v.AsInt64(), http.StatusOK)
+	}
+}
+
+func TestTracedRecordsRedactedErrors(t *testing.T) {
+	recorder := recordSpans(t)
+
+	_, err := Traced(&tracingScraper{}).FetchShowMetrics(context.Background(), &Podcast{}, time.Now(), time.Now())
+	if err == nil {
+		t.Fatal("FetchShowMetrics() error = nil, want the scraper's error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	status := spans[0].Status()
+	if status.Code != codes.Error {
+		t.Errorf("span status = %v, want Error", status.Code)
+	}
+	if strings.Contains(status.Description, "abc") {
+		t.Errorf("span status %q leaks the bearer token", status.Description)
+	}
+}
@@ -0,0 +1,180 @@
+package scrapers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EndpointAuth labels login, session and credential check requests
+const EndpointAuth = "auth"
+
+// EndpointOther labels requests made without an endpoint in their context
+const EndpointOther = "other"
+
+type endpointKey struct{}
+
+// WithEndpoint returns a context labelling requests made with it as endpoint
+func WithEndpoint(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, endpointKey{}, endpoint)
+}
+
+// EndpointFromContext returns the endpoint set by WithEndpoint, or EndpointOther
+func EndpointFromContext(ctx context.Context) string {
+	if endpoint, ok := ctx.Value(endpointKey{}).(string); ok {
+		return endpoint
+	}
+	return EndpointOther
+}
+
+type attemptHookKey struct{}
+
+// WithAttemptHook returns a context whose requests call hook before every
+// attempt is sent, retries included, so per-call costs such as API quota are
+// counted for each request the platform actually receives
+func WithAttemptHook(ctx context.Context, hook func()) context.Context {
+	return context.WithValue(ctx, attemptHookKey{}, hook)
+}
+
+// RequestObserver receives the outcome of every platform API request.
+// status is 0 when no response was received.
+type RequestObserver interface {
+	ObserveRequest(platform Platform, endpoint string, status int, duration time.Duration)
+	ObserveRetry(platform Platform, endpoint string)
+}
+
+// Retry policy for rate-limited and failed requests
+const (
+	maxRetries     = 2
+	retryBaseDelay = time.Second
+	maxRetryAfter  = 10 * time.Second
+)
+
+// transport retries rate-limited and server-error responses and reports
+// every attempt to an observer
+type transport struct {
+	platform Platform
+	observer RequestObserver
+	base     http.RoundTripper
+}
+
+// NewTransport returns a round tripper for platform's API client. Requests
+// answered with 429 or a 5xx status are retried up to twice, honouring
+// Retry-After. Every attempt calls the request's attempt hook, is reported
+// to observer, which may be nil, and is traced as a client span.
+func NewTransport(platform Platform, observer RequestObserver) http.RoundTripper {
+	return &transport{platform: platform, observer: observer, base: http.DefaultTransport}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := EndpointFromContext(req.Context())
+	hook, _ := req.Context().Value(attemptHookKey{}).(func())
+
+	for attempt := 0; ; attempt++ {
+		if hook != nil {
+			hook()
+		}
+
+		started := time.Now()
+		resp, err := t.roundTrip(req, endpoint, attempt)
+
+		status := 0
+		if err == nil {
+			status = resp.StatusCode
+		}
+		duration := time.Since(started)
+		if t.observer != nil {
+			t.observer.ObserveRequest(t.platform, endpoint, status, duration)
+		}
+		slog.DebugContext(req.Context(), "API request", "endpoint", endpoint, "method", req.Method,
+			logging.KeyHTTPStatus, status, "duration_ms", duration.Milliseconds())
+
+		if err != nil || !retryable(status) || attempt == maxRetries {
+			return resp, err
+		}
+
+		// Bodies can only be sent again when the request can recreate them
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		delay := retryDelay(resp, attempt)
+		slog.WarnContext(req.Context(), "Retrying API request", "endpoint", endpoint,
+			logging.KeyHTTPStatus, status, "attempt", attempt+1, "delay", delay.String())
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		if t.observer != nil {
+			t.observer.ObserveRetry(t.platform, endpoint)
+		}
+	}
+}
+
+// roundTrip sends one attempt of req in a client span. The span leaves out
+// the query string, which carries API keys on some platforms.
+func (t *transport) roundTrip(req *http.Request, endpoint string, attempt int) (*http.Response, error) {
+	_, span := Tracer.Start(req.Context(), "HTTP "+req.Method+" "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			AttrPlatform.String(string(t.platform)),
+			AttrEndpoint.String(endpoint),
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname()),
+			attribute.String("url.path", req.URL.Path),
+			attribute.Int("http.request.resend_count", attempt),
+		))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		EndSpan(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	span.End()
+	return resp, nil
+}
+
+// retryable reports whether a response status is worth retrying
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryDelay returns how long to wait before retrying: the response's
+// Retry-After in seconds when present, otherwise exponential backoff
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		delay := time.Duration(seconds) * time.Second
+		if delay > maxRetryAfter {
+			delay = maxRetryAfter
+		}
+		return delay
+	}
+	return retryBaseDelay << attempt
+}
@@ -0,0 +1,94 @@
+package scrapers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// countingObserver records the requests and retries a transport reports
+type countingObserver struct {
+	statuses []int
+	retries  int
+}
+
+func (o *countingObserver) ObserveRequest(platform Platform, endpoint string, status int, duration time.Duration) {
+	o.statuses = append(o.statuses, status)
+}
+
+func (o *countingObserver) ObserveRetry(platform Platform, endpoint string) {
+	o.retries++
+}
+
+func TestTransportCallsAttemptHookOnRetries(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	observer := &countingObserver{}
+	client := &http.Client{Transport: NewTransport(PlatformYouTube, observer)}
+
+	var attempts int
+	ctx := WithAttemptHook(WithEndpoint(context.Background(), EndpointEpisodes), func() { attempts++ })
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if attempts != 2 {
+		t.Errorf("attempt hook calls = %d, want 2", attempts)
+	}
+	if observer.retries != 1 || len(observer.statuses) != 2 {
+		t.Errorf("observed %d requests and %d retries, want 2 and 1", len(observer.statuses), observer.retries)
+	}
+}
+
+func TestTransportStopsAfterMaxRetries(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(PlatformYouTube, nil)}
+
+	var attempts int
+	req, err := http.NewRequestWithContext(WithAttemptHook(context.Background(), func() { attempts++ }), http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	if calls != maxRetries+1 || attempts != calls {
+		t.Errorf("server saw %d requests and the hook %d, want %d each", calls, attempts, maxRetries+1)
+	}
+}
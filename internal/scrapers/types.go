@@ -2,7 +2,11 @@ package scrapers
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"time"
+
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
 )
 
 // Platform represents a podcast/video platform
@@ -15,6 +19,48 @@ const (
 	PlatformYouTube       Platform = "youtube"
 )
 
+// AllPlatforms lists every supported platform
+var AllPlatforms = []Platform{
+	PlatformApplePodcasts,
+	PlatformSpotify,
+	PlatformAmazonMusic,
+	PlatformYouTube,
+}
+
+// ParsePlatform validates a platform name
+func ParsePlatform(name string) (Platform, error) {
+	for _, p := range AllPlatforms {
+		if string(p) == name {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown platform %q", name)
+}
+
+// BackfillLimits describes how much history a platform serves per request
+// and how fast it may be asked for it
+type BackfillLimits struct {
+	WindowDays        int // Largest date range a single metrics request may span
+	RequestsPerMinute int // Upper bound on metrics requests during a backfill
+}
+
+// DefaultBackfillLimits holds conservative per-platform backfill limits
+var DefaultBackfillLimits = map[Platform]BackfillLimits{
+	PlatformApplePodcasts: {WindowDays: 30, RequestsPerMinute: 20},
+	PlatformSpotify:       {WindowDays: 30, RequestsPerMinute: 20},
+	PlatformAmazonMusic:   {WindowDays: 30, RequestsPerMinute: 20},
+	PlatformYouTube:       {WindowDays: 90, RequestsPerMinute: 60},
+}
+
+// FinalizationLagDays is how many days each platform keeps revising a day's
+// numbers; metric dates older than this are treated as final
+var FinalizationLagDays = map[Platform]int{
+	PlatformApplePodcasts: 2,
+	PlatformSpotify:       2,
+	PlatformAmazonMusic:   2,
+	PlatformYouTube:       3,
+}
+
 // Podcast represents a podcast show
 type Podcast struct {
 	ID          int64
@@ -68,6 +114,9 @@ type EpisodeMetrics struct {
 	TopCities                map[string]interface{}
 	DeviceBreakdown          map[string]interface{}
 	RawData                  map[string]interface{}
+
+	// RunID is the scraper run that observed these values, 0 when unknown
+	RunID int64
 }
 
 // ShowMetrics represents aggregate metrics for the entire show
@@ -107,8 +156,28 @@ type Comment struct {
 	PublishedAt       time.Time
 }
 
+// Scraper run statuses recorded in raw.podcast_scraper_runs
+const (
+	RunStatusRunning    = "running"
+	RunStatusCompleted  = "completed"
+	RunStatusFailed     = "failed"
+	RunStatusAuthFailed = "auth_failed"
+
+	// RunStatusPartial marks a run that finished but recorded per-item errors
+	RunStatusPartial = "partial"
+
+	// RunStatusSkipped marks a run that did not collect because another
+	// process was already collecting the same show and platform
+	RunStatusSkipped = "skipped"
+
+	// RunStatusAbandoned marks a run whose process stopped sending heartbeats
+	// before it finished, usually because it was killed
+	RunStatusAbandoned = "abandoned"
+)
+
 // ScraperRun tracks a scraper execution
 type ScraperRun struct {
+	ID                int64
 	Platform          Platform
 	RunStartedAt      time.Time
 	RunCompletedAt    *time.Time
@@ -116,6 +185,159 @@ type ScraperRun struct {
 	EpisodesProcessed int
 	MetricsCollected  int
 	ErrorMessage      *string
+
+	// SchemaDrift counts the response fields that drifted from their declared
+	// shape during the run; only filled in when reading runs back
+	SchemaDrift int
+}
+
+// Stages of a run a per-item error can occur in
+const (
+	StageUpsertEpisode    = "upsert_episode"
+	StageFetchMetrics     = "fetch_metrics"
+	StageFetchComments    = "fetch_comments"
+	StageStoreMetric      = "store_metric"
+	StageStoreComment     = "store_comment"
+	StageFetchShowMetrics = "fetch_show_metrics"
+	StageStoreShowMetric  = "store_show_metric"
+	StageArchiveResponse  = "archive_response"
+)
+
+// ScraperError is a per-item failure recorded against a scraper run
+type ScraperError struct {
+	ID           int64
+	RunID        int64
+	Platform     Platform
+	EpisodeID    *int64
+	EpisodeTitle string
+	Stage        string
+	ErrorClass   ErrorClass
+	HTTPStatus   *int
+	Message      string
+	OccurredAt   time.Time
+}
+
+// maxErrorMessage bounds the message recorded with a ledger entry
+const maxErrorMessage = 2000
+
+// NewScraperError classifies err into a ledger entry for a run. The message
+// is redacted and truncated, since errors can carry platform response bodies.
+func NewScraperError(runID int64, platform Platform, episode *Episode, stage string, err error) *ScraperError {
+	class, status := ClassifyError(err)
+	switch stage {
+	case StageStoreMetric, StageStoreComment, StageStoreShowMetric, StageArchiveResponse:
+		if class == ErrorClassUnknown {
+			class = ErrorClassStorage
+		}
+	}
+
+	e := &ScraperError{
+		RunID:      runID,
+		Platform:   platform,
+		Stage:      stage,
+		ErrorClass: class,
+		HTTPStatus: status,
+		Message:    truncateMessage(logging.Redact(err.Error()), maxErrorMessage),
+		OccurredAt: time.Now(),
+	}
+	if episode != nil {
+		e.EpisodeTitle = episode.EpisodeTitle
+		if episode.ID > 0 {
+			id := episode.ID
+			e.EpisodeID = &id
+		}
+	}
+	return e
+}
+
+// Kinds of metric rows a run can quarantine
+const (
+	MetricKindEpisode = "episode"
+	MetricKindShow    = "show"
+)
+
+// QuarantinedMetric is a metric row held back from the metric tables
+// because it broke data quality rules
+type QuarantinedMetric struct {
+	ID                int64
+	RunID             int64
+	Platform          Platform
+	Kind              string
+	PodcastID         int64
+	EpisodeID         *int64 // nil for show metrics and episodes never stored
+	PlatformEpisodeID string
+	MetricDate        *time.Time // nil when the row had no date
+	Reasons           []string
+	Row               interface{} // the *EpisodeMetrics or *ShowMetrics as collected
+	QuarantinedAt     time.Time
+}
+
+// MetricAnomaly is a day's metric value that stands out from its baseline,
+// recorded in raw.podcast_metric_anomalies
+type MetricAnomaly struct {
+	ID           int64
+	RunID        int64 // 0 when found outside a collection
+	Platform     Platform
+	PodcastID    int64
+	EpisodeID    *int64 // nil for show metrics
+	EpisodeTitle string // only filled in when reading anomalies back
+	Metric       string // e.g. 'plays', 'total_views', 'followers_gained'
+	MetricDate   time.Time
+	Value        float64
+	Baseline     float64 // median of the baseline window
+	MAD          float64
+	Score        float64
+	Direction    string // 'spike', 'drop'
+	Method       string // 'rolling', 'weekday'
+	DetectedAt   time.Time
+}
+
+// CredentialStatus describes the outcome of a credential health check
+type CredentialStatus string
+
+const (
+	CredentialValid        CredentialStatus = "valid"
+	CredentialExpired      CredentialStatus = "expired"
+	CredentialMissingScope CredentialStatus = "missing_scope"
+	CredentialRateLimited  CredentialStatus = "rate_limited"
+	CredentialError        CredentialStatus = "error"
+)
+
+// CredentialCheck is the result of the cheapest authenticated call a scraper can make
+type CredentialCheck struct {
+	Platform       Platform
+	Status         CredentialStatus
+	HTTPStatus     int
+	Message        string
+	ExpiresAt      *time.Time // Cookie or token expiry, when known
+	QuotaRemaining *int64     // Remaining API quota units today, when the platform has one and usage is tracked
+}
+
+// OK reports whether the credentials can be used for collection
+func (c *CredentialCheck) OK() bool {
+	return c.Status == CredentialValid
+}
+
+// AuthFailed reports whether the credentials themselves are unusable,
+// as opposed to a transient problem such as rate limiting
+func (c *CredentialCheck) AuthFailed() bool {
+	return c.Status == CredentialExpired || c.Status == CredentialMissingScope
+}
+
+// ClassifyHTTPStatus maps the status code of an authenticated call to a credential status
+func ClassifyHTTPStatus(statusCode int) CredentialStatus {
+	switch {
+	case statusCode >= 200 && statusCode < 300:
+		return CredentialValid
+	case statusCode == http.StatusUnauthorized:
+		return CredentialExpired
+	case statusCode == http.StatusForbidden:
+		return CredentialMissingScope
+	case statusCode == http.StatusTooManyRequests:
+		return CredentialRateLimited
+	default:
+		return CredentialError
+	}
 }
 
 // Scraper is the interface that all platform scrapers must implement
@@ -137,4 +359,17 @@ type Scraper interface {
 
 	// FetchComments fetches comments for an episode (if supported by platform)
 	FetchComments(ctx context.Context, episode *Episode) ([]*Comment, error)
+
+	// CheckCredentials performs the cheapest authenticated call to verify credentials
+	CheckCredentials(ctx context.Context) *CredentialCheck
+}
+
+// QuotaReporter is implemented by scrapers for platforms with a metered API quota
+type QuotaReporter interface {
+	// DailyQuota returns the quota units available per day
+	DailyQuota() int64
+
+	// QuotaUsed returns the quota units consumed by this process; usage
+	// across processes is persisted per run by the caller
+	QuotaUsed() int64
 }
@@ -0,0 +1,110 @@
+package youtube
+
+import "github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+
+// Declared shapes of the YouTube responses the scraper parses. Fields the
+// parsers read are required; subtrees they ignore are accepted as anything.
+
+// pageShape holds the fields common to every Data API list response
+var pageShape = scrapers.Shape{
+	"kind":                    scrapers.Required(scrapers.JSONString),
+	"etag":                    scrapers.Required(scrapers.JSONString),
+	"nextPageToken":           scrapers.Optional(scrapers.JSONString),
+	"prevPageToken":           scrapers.Optional(scrapers.JSONString),
+	"regionCode":              scrapers.Optional(scrapers.JSONString),
+	"pageInfo":                scrapers.Required(scrapers.JSONObject),
+	"pageInfo.totalResults":   scrapers.Required(scrapers.JSONNumber),
+	"pageInfo.resultsPerPage": scrapers.Required(scrapers.JSONNumber),
+	"items":                   scrapers.Required(scrapers.JSONArray),
+	"items[]":                 scrapers.Required(scrapers.JSONObject),
+	"items[].kind":            scrapers.Required(scrapers.JSONString),
+	"items[].etag":            scrapers.Required(scrapers.JSONString),
+}
+
+// channelShape is a channels.list response with snippet and statistics
+var channelShape = withPage(scrapers.Shape{
+	"items[].id":                      scrapers.Required(scrapers.JSONString),
+	"items[].snippet":                 scrapers.Required(scrapers.JSONObject),
+	"items[].snippet.title":           scrapers.Required(scrapers.JSONString),
+	"items[].snippet.description":     scrapers.Required(scrapers.JSONString),
+	"items[].snippet.customUrl":       scrapers.Optional(scrapers.JSONString),
+	"items[].snippet.publishedAt":     scrapers.Required(scrapers.JSONString),
+	"items[].snippet.defaultLanguage": scrapers.Optional(scrapers.JSONString),
+	"items[].snippet.country":         scrapers.Optional(scrapers.JSONString),
+	"items[].snippet.thumbnails":      scrapers.Optional(scrapers.JSONAny),
+	"items[].snippet.localized":       scrapers.Optional(scrapers.JSONAny),
+	"items[].statistics":              scrapers.Optional(scrapers.JSONAny),
+})
+
+// searchShape is a search.list response for videos with snippet
+var searchShape = withPage(scrapers.Shape{
+	"items[].id":                           scrapers.Required(scrapers.JSONObject),
+	"items[].id.kind":                      scrapers.Required(scrapers.JSONString),
+	"items[].id.videoId":                   scrapers.Required(scrapers.JSONString),
+	"items[].snippet":                      scrapers.Required(scrapers.JSONObject),
+	"items[].snippet.publishedAt":          scrapers.Required(scrapers.JSONString),
+	"items[].snippet.channelId":            scrapers.Required(scrapers.JSONString),
+	"items[].snippet.title":                scrapers.Required(scrapers.JSONString),
+	"items[].snippet.description":          scrapers.Required(scrapers.JSONString),
+	"items[].snippet.thumbnails":           scrapers.Optional(scrapers.JSONAny),
+	"items[].snippet.channelTitle":         scrapers.Optional(scrapers.JSONString),
+	"items[].snippet.liveBroadcastContent": scrapers.Optional(scrapers.JSONString),
+	"items[].snippet.publishTime":          scrapers.Optional(scrapers.JSONString),
+})
+
+// commentThreadShape is a commentThreads.list response with snippet
+var commentThreadShape = withPage(scrapers.Shape{
+	"items[].id":                                                    scrapers.Required(scrapers.JSONString),
+	"items[].snippet":                                               scrapers.Required(scrapers.JSONObject),
+	"items[].snippet.channelId":                                     scrapers.Optional(scrapers.JSONString),
+	"items[].snippet.videoId":                                       scrapers.Required(scrapers.JSONString),
+	"items[].snippet.canReply":                                      scrapers.Optional(scrapers.JSONBool),
+	"items[].snippet.totalReplyCount":                               scrapers.Required(scrapers.JSONNumber),
+	"items[].snippet.isPublic":                                      scrapers.Optional(scrapers.JSONBool),
+	"items[].snippet.topLevelComment":                               scrapers.Required(scrapers.JSONObject),
+	"items[].snippet.topLevelComment.kind":                          scrapers.Required(scrapers.JSONString),
+	"items[].snippet.topLevelComment.etag":                          scrapers.Required(scrapers.JSONString),
+	"items[].snippet.topLevelComment.id":                            scrapers.Required(scrapers.JSONString),
+	"items[].snippet.topLevelComment.snippet":                       scrapers.Required(scrapers.JSONObject),
+	"items[].snippet.topLevelComment.snippet.channelId":             scrapers.Optional(scrapers.JSONString),
+	"items[].snippet.topLevelComment.snippet.videoId":               scrapers.Optional(scrapers.JSONString),
+	"items[].snippet.topLevelComment.snippet.textDisplay":           scrapers.Required(scrapers.JSONString),
+	"items[].snippet.topLevelComment.snippet.textOriginal":          scrapers.Optional(scrapers.JSONString),
+	"items[].snippet.topLevelComment.snippet.authorDisplayName":     scrapers.Required(scrapers.JSONString),
+	"items[].snippet.topLevelComment.snippet.authorProfileImageUrl": scrapers.Optional(scrapers.JSONString),
+	"items[].snippet.topLevelComment.snippet.authorChannelUrl":      scrapers.Optional(scrapers.JSONString),
+	"items[].snippet.topLevelComment.snippet.authorChannelId":       scrapers.Optional(scrapers.JSONObject),
+	"items[].snippet.topLevelComment.snippet.authorChannelId.value": scrapers.Required(scrapers.JSONString),
+	"items[].snippet.topLevelComment.snippet.canRate":               scrapers.Optional(scrapers.JSONBool),
+	"items[].snippet.topLevelComment.snippet.viewerRating":          scrapers.Optional(scrapers.JSONString),
+	"items[].snippet.topLevelComment.snippet.likeCount":             scrapers.Required(scrapers.JSONNumber),
+	"items[].snippet.topLevelComment.snippet.publishedAt":           scrapers.Required(scrapers.JSONString),
+	"items[].snippet.topLevelComment.snippet.updatedAt":             scrapers.Optional(scrapers.JSONString),
+	"items[].replies":                                               scrapers.Optional(scrapers.JSONAny),
+})
+
+// reportShape is an Analytics API reports.query response. Row cells mix
+// dimension strings and metric numbers, so rows are checked only as arrays.
+var reportShape = scrapers.Shape{
+	"kind":                       scrapers.Required(scrapers.JSONString),
+	"columnHeaders":              scrapers.Required(scrapers.JSONArray),
+	"columnHeaders[]":            scrapers.Required(scrapers.JSONObject),
+	"columnHeaders[].name":       scrapers.Required(scrapers.JSONString),
+	"columnHeaders[].columnType": scrapers.Required(scrapers.JSONString),
+	"columnHeaders[].dataType":   scrapers.Required(scrapers.JSONString),
+	"rows":                       scrapers.Optional(scrapers.JSONArray), // Absent when there is no data
+	"rows[]":                     scrapers.Required(scrapers.JSONArray),
+	"rows[][]":                   scrapers.Required(scrapers.JSONAny),
+}
+
+// withPage returns pageShape extended with fields
+func withPage(fields scrapers.Shape) scrapers.Shape {
+	shape := make(scrapers.Shape, len(pageShape)+len(fields))
+	for path, field := range pageShape {
+		shape[path] = field
+	}
+	for path, field := range fields {
+		shape[path] = field
+	}
+	return shape
+}
@@ -7,6 +7,9 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
@@ -14,9 +17,13 @@ import (
 
 // YouTubeScraper scrapes metrics from YouTube Analytics API
 type YouTubeScraper struct {
-	apiKey     string
-	httpClient *http.Client
-	baseURL    string
+	apiKey      string
+	accessToken string
+	httpClient  *http.Client
+	baseURL     string
+	dailyQuota  int64
+	quotaUsed   atomic.Int64
+	archiver    scrapers.ResponseArchiver
 }
 
 // Config holds configuration for YouTube scraper
@@ -24,24 +31,205 @@ type Config struct {
 	// YouTube Analytics API requires OAuth 2.0 or API Key
 	APIKey      string
 	AccessToken string // OAuth 2.0 access token
+
+	// DailyQuota is the API quota in units per day (defaults to 10000)
+	DailyQuota int64
+
+	// Archiver stores every successful response body when set
+	Archiver scrapers.ResponseArchiver
+
+	// Observer receives the outcome of every API request when set
+	Observer scrapers.RequestObserver
 }
 
+// Quota cost per call, in units. Analytics reports are metered alongside
+// Data API calls so a backfill's report windows count against the reserve.
+const (
+	quotaCostList   = 1
+	quotaCostSearch = 100
+	quotaCostReport = 1
+)
+
+// analyticsScope is the OAuth scope required for YouTube Analytics reports
+const analyticsScope = "https://www.googleapis.com/auth/yt-analytics.readonly"
+
 // NewScraper creates a new YouTube scraper
 func NewScraper(cfg Config) (*YouTubeScraper, error) {
+	dailyQuota := cfg.DailyQuota
+	if dailyQuota <= 0 {
+		dailyQuota = 10000
+	}
+
 	return &YouTubeScraper{
-		apiKey: cfg.APIKey,
+		apiKey:      cfg.APIKey,
+		accessToken: cfg.AccessToken,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: scrapers.NewTransport(scrapers.PlatformYouTube, cfg.Observer),
 		},
-		baseURL: "https://youtubeanalytics.googleapis.com/v2",
+		baseURL:    "https://youtubeanalytics.googleapis.com/v2",
+		dailyQuota: dailyQuota,
+		archiver:   cfg.Archiver,
 	}, nil
 }
 
+// QuotaUsed returns the quota units consumed by this scraper
+func (s *YouTubeScraper) QuotaUsed() int64 {
+	return s.quotaUsed.Load()
+}
+
+// metered returns a context for a request to endpoint whose every attempt,
+// retries included, is counted against the quota at cost units
+func (s *YouTubeScraper) metered(ctx context.Context, endpoint string, cost int64) context.Context {
+	return scrapers.WithAttemptHook(scrapers.WithEndpoint(ctx, endpoint), func() {
+		s.quotaUsed.Add(cost)
+	})
+}
+
+// DailyQuota returns the quota units available per day
+func (s *YouTubeScraper) DailyQuota() int64 {
+	return s.dailyQuota
+}
+
 // GetPlatform returns the platform identifier
 func (s *YouTubeScraper) GetPlatform() scrapers.Platform {
 	return scrapers.PlatformYouTube
 }
 
+// CheckCredentials verifies the OAuth token (via tokeninfo, which costs no quota)
+// or, when only an API key is configured, makes a single-unit Data API call
+func (s *YouTubeScraper) CheckCredentials(ctx context.Context) *scrapers.CredentialCheck {
+	check := &scrapers.CredentialCheck{Platform: scrapers.PlatformYouTube}
+
+	if s.accessToken != "" {
+		s.checkAccessToken(ctx, check)
+	} else {
+		s.checkAPIKey(ctx, check)
+	}
+
+	return check
+}
+
+// checkAccessToken inspects the OAuth token expiry and granted scopes
+func (s *YouTubeScraper) checkAccessToken(ctx context.Context, check *scrapers.CredentialCheck) {
+	params := url.Values{}
+	params.Add("access_token", s.accessToken)
+
+	apiURL := fmt.Sprintf("https://oauth2.googleapis.com/tokeninfo?%s", params.Encode())
+
+	req, err := http.NewRequestWithContext(scrapers.WithEndpoint(ctx, scrapers.EndpointAuth), "GET", apiURL, nil)
+	if err != nil {
+		check.Status = scrapers.CredentialError
+		check.Message = fmt.Sprintf("failed to create request: %v", err)
+		return
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		check.Status = scrapers.CredentialError
+		check.Message = fmt.Sprintf("request failed: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	check.HTTPStatus = resp.StatusCode
+
+	// tokeninfo answers 400 for expired or revoked tokens
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		check.Status = scrapers.CredentialExpired
+		check.Message = "access token is expired or revoked"
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		check.Status = scrapers.ClassifyHTTPStatus(resp.StatusCode)
+		check.Message = fmt.Sprintf("tokeninfo returned status %d", resp.StatusCode)
+		return
+	}
+
+	var tokenInfo struct {
+		Scope string `json:"scope"`
+		Exp   string `json:"exp"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenInfo); err != nil {
+		check.Status = scrapers.CredentialError
+		check.Message = fmt.Sprintf("failed to decode response: %v", err)
+		return
+	}
+
+	if exp, err := strconv.ParseInt(tokenInfo.Exp, 10, 64); err == nil {
+		expiresAt := time.Unix(exp, 0)
+		check.ExpiresAt = &expiresAt
+	}
+
+	if !strings.Contains(tokenInfo.Scope, analyticsScope) {
+		check.Status = scrapers.CredentialMissingScope
+		check.Message = fmt.Sprintf("token is missing scope %s", analyticsScope)
+		return
+	}
+
+	check.Status = scrapers.CredentialValid
+}
+
+// checkAPIKey validates the API key with the cheapest Data API list call
+func (s *YouTubeScraper) checkAPIKey(ctx context.Context, check *scrapers.CredentialCheck) {
+	params := url.Values{}
+	params.Add("part", "id")
+	params.Add("regionCode", "US")
+	params.Add("key", s.apiKey)
+
+	apiURL := fmt.Sprintf("https://www.googleapis.com/youtube/v3/videoCategories?%s", params.Encode())
+
+	req, err := http.NewRequestWithContext(s.metered(ctx, scrapers.EndpointAuth, quotaCostList), "GET", apiURL, nil)
+	if err != nil {
+		check.Status = scrapers.CredentialError
+		check.Message = fmt.Sprintf("failed to create request: %v", err)
+		return
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		check.Status = scrapers.CredentialError
+		check.Message = fmt.Sprintf("request failed: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	check.HTTPStatus = resp.StatusCode
+	if resp.StatusCode == http.StatusOK {
+		check.Status = scrapers.CredentialValid
+		return
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	check.Status = classifyAPIError(resp.StatusCode, body)
+	check.Message = fmt.Sprintf("API returned status %d: %s", resp.StatusCode, string(body))
+}
+
+// classifyAPIError maps a Google API error body to a credential status.
+// Google reports quota exhaustion and invalid keys as 403/400 with a reason.
+func classifyAPIError(statusCode int, body []byte) scrapers.CredentialStatus {
+	var apiErr struct {
+		Error struct {
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &apiErr); err == nil {
+		for _, e := range apiErr.Error.Errors {
+			switch e.Reason {
+			case "quotaExceeded", "rateLimitExceeded", "userRateLimitExceeded", "dailyLimitExceeded":
+				return scrapers.CredentialRateLimited
+			case "keyInvalid", "keyExpired", "authError":
+				return scrapers.CredentialExpired
+			case "insufficientPermissions", "forbidden", "accessNotConfigured":
+				return scrapers.CredentialMissingScope
+			}
+		}
+	}
+	return scrapers.ClassifyHTTPStatus(statusCode)
+}
+
 // FetchPodcastInfo fetches basic channel/show information
 func (s *YouTubeScraper) FetchPodcastInfo(ctx context.Context, showName string) (*scrapers.Podcast, error) {
 	// For YouTube, we need to get channel information
@@ -56,7 +244,7 @@ func (s *YouTubeScraper) FetchPodcastInfo(ctx context.Context, showName string)
 
 	apiURL := fmt.Sprintf("%s?%s", dataAPIURL, params.Encode())
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(s.metered(ctx, scrapers.EndpointPodcastInfo, quotaCostList), "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -69,11 +257,20 @@ func (s *YouTubeScraper) FetchPodcastInfo(ctx context.Context, showName string)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	body, key, err := scrapers.ReadResponse(ctx, s.archiver, resp, &scrapers.Response{
+		Platform: scrapers.PlatformYouTube,
+		Endpoint: scrapers.EndpointPodcastInfo,
+		Shape:    channelShape,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	var channelData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&channelData); err != nil {
+	if err := json.Unmarshal(body, &channelData); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -83,6 +280,9 @@ func (s *YouTubeScraper) FetchPodcastInfo(ctx context.Context, showName string)
 		Platform:   scrapers.PlatformYouTube,
 		RawData:    channelData,
 	}
+	if key != "" {
+		podcast.RawData = scrapers.ArchiveRef(key)
+	}
 
 	// Extract from response
 	if items, ok := channelData["items"].([]interface{}); ok && len(items) > 0 {
@@ -116,7 +316,7 @@ func (s *YouTubeScraper) FetchEpisodes(ctx context.Context, podcast *scrapers.Po
 
 	apiURL := fmt.Sprintf("%s?%s", dataAPIURL, params.Encode())
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(s.metered(ctx, scrapers.EndpointEpisodes, quotaCostSearch), "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -129,11 +329,21 @@ func (s *YouTubeScraper) FetchEpisodes(ctx context.Context, podcast *scrapers.Po
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	body, _, err := scrapers.ReadResponse(ctx, s.archiver, resp, &scrapers.Response{
+		Platform:  scrapers.PlatformYouTube,
+		Endpoint:  scrapers.EndpointEpisodes,
+		PodcastID: podcast.ID,
+		Shape:     searchShape,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	var videosData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&videosData); err != nil {
+	if err := json.Unmarshal(body, &videosData); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -190,7 +400,7 @@ func (s *YouTubeScraper) FetchEpisodeMetrics(ctx context.Context, episode *scrap
 
 	apiURL := fmt.Sprintf("%s/reports?%s", s.baseURL, params.Encode())
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(s.metered(ctx, scrapers.EndpointEpisodeMetrics, quotaCostReport), "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -206,11 +416,42 @@ func (s *YouTubeScraper) FetchEpisodeMetrics(ctx context.Context, episode *scrap
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	body, key, err := scrapers.ReadResponse(ctx, s.archiver, resp, &scrapers.Response{
+		Platform:          scrapers.PlatformYouTube,
+		Endpoint:          scrapers.EndpointEpisodeMetrics,
+		PodcastID:         episode.PodcastID,
+		EpisodeID:         episode.ID,
+		PlatformEpisodeID: episode.PlatformEpisodeID,
+		StartDate:         startDate,
+		EndDate:           endDate,
+		Shape:             reportShape,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := s.ParseEpisodeMetrics(episode, startDate, endDate, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// The archive holds the response; rows only point at it
+	if key != "" {
+		for _, metric := range metrics {
+			metric.RawData = scrapers.ArchiveRef(key)
+		}
 	}
 
+	return metrics, nil
+}
+
+// ParseEpisodeMetrics parses a YouTube Analytics report for a single video
+func (s *YouTubeScraper) ParseEpisodeMetrics(episode *scrapers.Episode, startDate, endDate time.Time, body []byte) ([]*scrapers.EpisodeMetrics, error) {
 	var analyticsData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&analyticsData); err != nil {
+	if err := json.Unmarshal(body, &analyticsData); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -220,7 +461,7 @@ func (s *YouTubeScraper) FetchEpisodeMetrics(ctx context.Context, episode *scrap
 	// Response format includes columnHeaders and rows
 	if rows, ok := analyticsData["rows"].([]interface{}); ok {
 		for _, row := range rows {
-			if rowData, ok := row.([]interface{}); ok && len(rowData) >= 9 {
+			if rowData, ok := row.([]interface{}); ok && len(rowData) >= 10 {
 				metric := &scrapers.EpisodeMetrics{
 					EpisodeID: episode.ID,
 					RawData:   analyticsData,
@@ -283,7 +524,7 @@ func (s *YouTubeScraper) FetchShowMetrics(ctx context.Context, podcast *scrapers
 
 	apiURL := fmt.Sprintf("%s/reports?%s", s.baseURL, params.Encode())
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(s.metered(ctx, scrapers.EndpointShowMetrics, quotaCostReport), "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -297,20 +538,59 @@ func (s *YouTubeScraper) FetchShowMetrics(ctx context.Context, podcast *scrapers
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode}
+	}
+
+	body, key, err := scrapers.ReadResponse(ctx, s.archiver, resp, &scrapers.Response{
+		Platform:  scrapers.PlatformYouTube,
+		Endpoint:  scrapers.EndpointShowMetrics,
+		PodcastID: podcast.ID,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Shape:     reportShape,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := s.ParseShowMetrics(podcast, startDate, endDate, body)
+	if err != nil {
+		return nil, err
 	}
 
+	if key != "" {
+		for _, metric := range metrics {
+			metric.RawData = scrapers.ArchiveRef(key)
+		}
+	}
+
+	return metrics, nil
+}
+
+// ParseShowMetrics parses a YouTube Analytics report for the whole channel
+func (s *YouTubeScraper) ParseShowMetrics(podcast *scrapers.Podcast, startDate, endDate time.Time, body []byte) ([]*scrapers.ShowMetrics, error) {
 	var analyticsData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&analyticsData); err != nil {
+	if err := json.Unmarshal(body, &analyticsData); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	var metrics []*scrapers.ShowMetrics
 
+	// Columns are looked up by name from columnHeaders rather than position
+	columns := columnIndexes(analyticsData)
+	value := func(rowData []interface{}, name string) int64 {
+		i, ok := columns[name]
+		if !ok || i >= len(rowData) {
+			return 0
+		}
+		v, _ := rowData[i].(float64)
+		return int64(v)
+	}
+
 	// Parse channel metrics
 	if rows, ok := analyticsData["rows"].([]interface{}); ok {
 		for _, row := range rows {
-			if rowData, ok := row.([]interface{}); ok {
+			if rowData, ok := row.([]interface{}); ok && len(rowData) > 0 {
 				metric := &scrapers.ShowMetrics{
 					PodcastID: podcast.ID,
 					RawData:   analyticsData,
@@ -323,6 +603,13 @@ func (s *YouTubeScraper) FetchShowMetrics(ctx context.Context, podcast *scrapers
 					}
 				}
 
+				metric.TotalViews = value(rowData, "views")
+				metric.TotalLikes = value(rowData, "likes")
+				metric.TotalComments = value(rowData, "comments")
+				metric.TotalShares = value(rowData, "shares")
+				metric.SubscribersGained = int(value(rowData, "subscribersGained"))
+				metric.SubscribersLost = int(value(rowData, "subscribersLost"))
+
 				metrics = append(metrics, metric)
 			}
 		}
@@ -331,6 +618,21 @@ func (s *YouTubeScraper) FetchShowMetrics(ctx context.Context, podcast *scrapers
 	return metrics, nil
 }
 
+// columnIndexes maps the column names of an Analytics report to their
+// position in each row
+func columnIndexes(report map[string]interface{}) map[string]int {
+	columns := make(map[string]int)
+	headers, _ := report["columnHeaders"].([]interface{})
+	for i, h := range headers {
+		if header, ok := h.(map[string]interface{}); ok {
+			if name, ok := header["name"].(string); ok {
+				columns[name] = i
+			}
+		}
+	}
+	return columns
+}
+
 // FetchComments fetches comments for a video
 func (s *YouTubeScraper) FetchComments(ctx context.Context, episode *scrapers.Episode) ([]*scrapers.Comment, error) {
 	// Use YouTube Data API v3 to get comments
@@ -345,7 +647,7 @@ func (s *YouTubeScraper) FetchComments(ctx context.Context, episode *scrapers.Ep
 
 	apiURL := fmt.Sprintf("%s?%s", dataAPIURL, params.Encode())
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(s.metered(ctx, scrapers.EndpointComments, quotaCostList), "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -358,11 +660,23 @@ func (s *YouTubeScraper) FetchComments(ctx context.Context, episode *scrapers.Ep
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &scrapers.HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	body, _, err := scrapers.ReadResponse(ctx, s.archiver, resp, &scrapers.Response{
+		Platform:          scrapers.PlatformYouTube,
+		Endpoint:          scrapers.EndpointComments,
+		PodcastID:         episode.PodcastID,
+		EpisodeID:         episode.ID,
+		PlatformEpisodeID: episode.PlatformEpisodeID,
+		Shape:             commentThreadShape,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	var commentsData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&commentsData); err != nil {
+	if err := json.Unmarshal(body, &commentsData); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -0,0 +1,200 @@
+// Package telemetry exposes the scraper's Prometheus metrics, either on an
+// HTTP listener for long-running processes or pushed to a Pushgateway for
+// one-shot runs such as the CronJob, and exports its OpenTelemetry traces.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/soypete/eleduck-analytics-connector/internal/logging"
+	"github.com/soypete/eleduck-analytics-connector/internal/scrapers"
+)
+
+// Metrics holds the scraper's Prometheus collectors
+type Metrics struct {
+	registry *prometheus.Registry
+
+	runDuration     *prometheus.HistogramVec
+	runs            *prometheus.CounterVec
+	lastSuccess     *prometheus.GaugeVec
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	retries         *prometheus.CounterVec
+	rowsUpserted    *prometheus.CounterVec
+	itemErrors      *prometheus.CounterVec
+	qualityRows     *prometheus.CounterVec
+	anomalies       *prometheus.CounterVec
+	quotaUsed       *prometheus.GaugeVec
+}
+
+// NewMetrics creates the scraper's collectors on a registry of their own,
+// alongside the Go runtime and process collectors
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		runDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "podcast_scraper_run_duration_seconds",
+			Help:    "Duration of scraper runs by platform and final status.",
+			Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+		}, []string{"platform", "status"}),
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "podcast_scraper_runs_total",
+			Help: "Scraper runs by platform and final status.",
+		}, []string{"platform", "status"}),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "podcast_scraper_last_success_timestamp_seconds",
+			Help: "Unix time the last completed or partial run of each platform finished.",
+		}, []string{"platform"}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "podcast_scraper_http_requests_total",
+			Help: "Platform API requests by endpoint and HTTP status (0 when no response was received).",
+		}, []string{"platform", "endpoint", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "podcast_scraper_http_request_duration_seconds",
+			Help:    "Latency of platform API requests by endpoint.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"platform", "endpoint"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "podcast_scraper_http_retries_total",
+			Help: "Platform API requests retried after a 429 or 5xx response.",
+		}, []string{"platform", "endpoint"}),
+		rowsUpserted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "podcast_scraper_rows_upserted_total",
+			Help: "Rows written by table.",
+		}, []string{"platform", "table"}),
+		itemErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "podcast_scraper_item_errors_total",
+			Help: "Per-item errors recorded in the run error ledger by stage and class.",
+		}, []string{"platform", "stage", "class"}),
+		qualityRows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "podcast_scraper_quality_violations_total",
+			Help: "Metric rows that broke a data quality rule, by rule and the action taken.",
+		}, []string{"platform", "rule", "action"}),
+		anomalies: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "podcast_scraper_metric_anomalies_total",
+			Help: "Daily metric values found standing out from their baseline, by metric and direction.",
+		}, []string{"platform", "metric", "direction"}),
+		quotaUsed: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "podcast_scraper_api_quota_used_units",
+			Help: "API quota units consumed by this process, for platforms with a daily quota such as YouTube.",
+		}, []string{"platform"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.runDuration,
+		m.runs,
+		m.lastSuccess,
+		m.requests,
+		m.requestDuration,
+		m.retries,
+		m.rowsUpserted,
+		m.itemErrors,
+		m.qualityRows,
+		m.anomalies,
+		m.quotaUsed,
+	)
+	return m
+}
+
+// ObserveRequest implements scrapers.RequestObserver
+func (m *Metrics) ObserveRequest(platform scrapers.Platform, endpoint string, status int, duration time.Duration) {
+	m.requests.WithLabelValues(string(platform), endpoint, strconv.Itoa(status)).Inc()
+	m.requestDuration.WithLabelValues(string(platform), endpoint).Observe(duration.Seconds())
+}
+
+// ObserveRetry implements scrapers.RequestObserver
+func (m *Metrics) ObserveRetry(platform scrapers.Platform, endpoint string) {
+	m.retries.WithLabelValues(string(platform), endpoint).Inc()
+}
+
+// ObserveRun records a finished run
+func (m *Metrics) ObserveRun(run *scrapers.ScraperRun) {
+	if run.RunCompletedAt == nil {
+		return
+	}
+
+	m.runs.WithLabelValues(string(run.Platform), run.Status).Inc()
+	m.runDuration.WithLabelValues(string(run.Platform), run.Status).Observe(run.RunCompletedAt.Sub(run.RunStartedAt).Seconds())
+
+	if run.Status == scrapers.RunStatusCompleted || run.Status == scrapers.RunStatusPartial {
+		m.lastSuccess.WithLabelValues(string(run.Platform)).Set(float64(run.RunCompletedAt.Unix()))
+	}
+}
+
+// AddRowsUpserted counts rows written to table
+func (m *Metrics) AddRowsUpserted(platform scrapers.Platform, table string, rows int) {
+	m.rowsUpserted.WithLabelValues(string(platform), table).Add(float64(rows))
+}
+
+// ObserveItemError counts a per-item error
+func (m *Metrics) ObserveItemError(e *scrapers.ScraperError) {
+	m.itemErrors.WithLabelValues(string(e.Platform), e.Stage, string(e.ErrorClass)).Inc()
+}
+
+// ObserveQualityViolation counts a metric row breaking a data quality rule
+func (m *Metrics) ObserveQualityViolation(platform scrapers.Platform, rule, action string) {
+	m.qualityRows.WithLabelValues(string(platform), rule, action).Inc()
+}
+
+// ObserveAnomaly counts a metric anomaly
+func (m *Metrics) ObserveAnomaly(a *scrapers.MetricAnomaly) {
+	m.anomalies.WithLabelValues(string(a.Platform), a.Metric, a.Direction).Inc()
+}
+
+// SetQuotaUsed records the API quota a platform has consumed
+func (m *Metrics) SetQuotaUsed(platform scrapers.Platform, units int64) {
+	m.quotaUsed.WithLabelValues(string(platform)).Set(float64(units))
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Serve exposes the metrics on addr at /metrics until ctx is done
+func (m *Metrics) Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.ErrorContext(ctx, "Metrics listener stopped", logging.KeyError, err)
+		}
+	}()
+	return nil
+}
+
+// Push sends the metrics to a Pushgateway at url under job, replacing the
+// metrics previously pushed for the job
+func (m *Metrics) Push(ctx context.Context, url, job string) error {
+	if err := push.New(url, job).Gatherer(m.registry).PushContext(ctx); err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", url, err)
+	}
+	return nil
+}
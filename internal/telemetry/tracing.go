@@ -0,0 +1,71 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Trace exporters selectable with TracingConfig.Exporter
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// TracingConfig selects where spans are exported
+type TracingConfig struct {
+	// Exporter is ExporterOTLP, ExporterStdout or ExporterNone. The OTLP
+	// exporter sends over HTTP and reads its endpoint and headers from the
+	// standard OTEL_EXPORTER_OTLP_* variables.
+	Exporter string
+
+	// ServiceName names the service in exported spans unless OTEL_SERVICE_NAME is set
+	ServiceName string
+}
+
+// SetupTracing installs the global tracer provider and returns a function
+// that flushes and stops it. With ExporterNone spans are not recorded.
+func SetupTracing(ctx context.Context, cfg TracingConfig) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		// Spans go to stderr so they never mix with command output such as export CSV
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	default:
+		return nil, fmt.Errorf("invalid trace exporter %q (want otlp, stdout or none)", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	// Attributes from the environment override the default service name
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}